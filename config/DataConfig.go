@@ -1,10 +1,74 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+)
 
 var (
 	ListenPort = getEnv("LISTEN_PORT", "3061")
 	MysqlDsn   = getEnv("MYSQL_DSN", "root:root123456@(localhost:3306)/FilmSite?charset=utf8mb4&parseTime=True&loc=Local")
+
+	BriefingTime      = getEnv("BRIEFING_TIME", "07:30")    // 每日简报默认生成时间(HH:MM)
+	BriefingNewsLimit = getEnvInt("BRIEFING_NEWS_LIMIT", 5) // 每日简报中的新闻条数
+
+	// 邮件通知, 本地开发可使用 mailpit 等 SMTP 测试服务器(默认 localhost:1025, 不加密)
+	SMTPHost     = getEnv("SMTP_HOST", "localhost")
+	SMTPPort     = getEnvInt("SMTP_PORT", 1025)
+	SMTPUsername = getEnv("SMTP_USERNAME", "")
+	SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	SMTPFrom     = getEnv("SMTP_FROM", "Go Schedule <noreply@localhost>")
+	SMTPSecurity = getEnv("SMTP_SECURITY", "none") // none | starttls | tls
+
+	// 群机器人 webhook 地址, 渠道只配置令牌时拼接使用, 测试时可替换为本地服务; 渠道填写完整地址时只允许官方域名与这些地址所在域名
+	WeComWebhookURL    = getEnv("WECOM_WEBHOOK_URL", "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=")
+	DingTalkWebhookURL = getEnv("DINGTALK_WEBHOOK_URL", "https://oapi.dingtalk.com/robot/send?access_token=")
+	FeishuWebhookURL   = getEnv("FEISHU_WEBHOOK_URL", "https://open.feishu.cn/open-apis/bot/v2/hook/")
+
+	NotifyMaxAttempts   = getEnvInt("NOTIFY_MAX_ATTEMPTS", 5)    // 通知最大投递次数
+	ReminderLeadMinutes = getEnvInt("REMINDER_LEAD_MINUTES", 15) // 日程开始前多少分钟发送提醒
+
+	WebhookMaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 6) // Webhook 最大投递次数
+
+	// 路程时间估算
+	TravelSpeedKmh        = getEnvInt("TRAVEL_SPEED_KMH", 30)        // 按直线距离估算路程时间的平均速度(km/h)
+	TravelFallbackMinutes = getEnvInt("TRAVEL_FALLBACK_MINUTES", 30) // 地点不同但缺少坐标时的默认路程时间
+
+	// 认证
+	JWTSecret          = getEnv("JWT_SECRET", "") // HS256 签名密钥, 为空时启动时随机生成(重启后令牌失效)
+	JWTIssuer          = getEnv("JWT_ISSUER", "go-schedule")
+	AccessTokenMinutes = getEnvInt("ACCESS_TOKEN_MINUTES", 15) // 访问令牌有效期
+	RefreshTokenHours  = getEnvInt("REFRESH_TOKEN_HOURS", 720) // 刷新令牌有效期
+	BcryptCost         = getEnvInt("BCRYPT_COST", 10)
+
+	// 初始管理员, 启动时用户不存在则使用 ADMIN_PASSWORD 创建, 已存在则设置为管理员; 注册与单点登录创建的用户都是普通成员
+	AdminUsername = getEnv("ADMIN_USERNAME", "")
+	AdminPassword = getEnv("ADMIN_PASSWORD", "")
+
+	// 两步验证
+	TOTPIssuer        = getEnv("TOTP_ISSUER", "go-schedule")   // 验证器应用中显示的服务名称
+	TOTPRequiredRoles = getEnv("TOTP_REQUIRED_ROLES", "admin") // 必须启用两步验证的角色, 多个用逗号分隔, 为空时不强制
+
+	// 限流, 格式为 次数/时间单位(s/m/h):突发容量, 如 600/m:100; 设置为 off 时不限流
+	RateLimitIP    = getEnv("RATE_LIMIT_IP", "1200/m:200")  // 每个 IP 全部接口
+	RateLimitUser  = getEnv("RATE_LIMIT_USER", "600/m:100") // 每个登录用户全部接口
+	RateLimitAuth  = getEnv("RATE_LIMIT_AUTH", "10/m:5")    // 每个 IP 每个登录、注册、两步验证接口
+	RateLimitWrite = getEnv("RATE_LIMIT_WRITE", "60/m:20")  // 每个用户每个写接口
+	TrustedProxies = getEnv("TRUSTED_PROXIES", "")          // 可信反向代理地址(逗号分隔), 只有来自这些地址的 X-Forwarded-For 会被用于识别客户端 IP
+
+	// OpenID Connect 单点登录, OIDC_ISSUER 为空时不启用
+	OIDCIssuer        = getEnv("OIDC_ISSUER", "")
+	OIDCClientID      = getEnv("OIDC_CLIENT_ID", "")
+	OIDCClientSecret  = getEnv("OIDC_CLIENT_SECRET", "") // 只使用 PKCE 的公共客户端可以为空
+	OIDCRedirectURL   = getEnv("OIDC_REDIRECT_URL", "http://localhost:3061/auth/oidc/callback")
+	OIDCScopes        = getEnv("OIDC_SCOPES", "openid profile email")
+	OIDCUsernameClaim = getEnv("OIDC_USERNAME_CLAIM", "preferred_username") // 自动创建用户时作为用户名的声明
+	OIDCAutoProvision = getEnvBool("OIDC_AUTO_PROVISION", true)             // 首次登录时自动创建本地用户
+	OIDCSuccessURL    = getEnv("OIDC_SUCCESS_URL", "")                      // 登录成功后重定向的前端地址, 令牌放在 URL fragment 中; 为空时返回 JSON
+
+	// 逾期任务顺延
+	RolloverTime = getEnv("ROLLOVER_TIME", "00:05")    // 每日执行时间(HH:MM)
+	WorkingDays  = getEnv("WORKING_DAYS", "1,2,3,4,5") // 工作日, 0或7表示周日
 )
 
 func getEnv(key, defaultValue string) string {
@@ -13,3 +77,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
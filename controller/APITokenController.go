@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/model/system"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateAPIToken 创建个人访问令牌
+// @Summary      创建个人访问令牌
+// @Description  为脚本与第三方集成创建个人访问令牌, 通过 Authorization: Bearer gst_... 调用接口;
+// @Description  权限范围: schedule:read、schedule:write、news:read; 令牌明文只在创建时返回一次, 服务端只保存哈希
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.APITokenReq  true  "令牌参数"
+// @Success      200      {object}  system.Response{data=user.APITokenResp}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /auth/token/store [post]
+func CreateAPIToken(c *gin.Context) {
+	req := user.APITokenReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	resp, err := auth.CreateAPIToken(middleware.UserID(c), req)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(resp, "请妥善保存令牌, 关闭后将无法再次查看", c)
+}
+
+// ListAPITokens 查询个人访问令牌
+// @Summary      查询个人访问令牌
+// @Description  查询当前用户的全部个人访问令牌(不含令牌明文), 包含最近使用时间与撤销状态
+// @Tags         用户认证
+// @Produce      json
+// @Success      200  {object}  system.Response{data=[]user.APIToken}
+// @Failure      500  {object}  system.Response
+// @Security     BearerAuth
+// @Router       /auth/token/list [post]
+func ListAPITokens(c *gin.Context) {
+	list, err := UserDao.ListAPITokens(middleware.UserID(c))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// RevokeAPIToken 撤销个人访问令牌
+// @Summary      撤销个人访问令牌
+// @Description  撤销后令牌立即失效
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.APITokenIDReq  true  "令牌ID"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /auth/token/revoke [post]
+func RevokeAPIToken(c *gin.Context) {
+	req := user.APITokenIDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if err := UserDao.RevokeAPIToken(req.ID, middleware.UserID(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("令牌不存在或已撤销", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("令牌已撤销", c)
+}
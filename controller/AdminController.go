@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/dao"
+	"go-film-demo/model/admin"
+	"go-film-demo/model/system"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/cron"
+	"go-film-demo/plugin/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListUsers 查询用户
+// @Summary      查询用户
+// @Description  分页查询全部用户及其角色, 需要 user:manage 权限
+// @Tags         系统管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.UserListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /admin/user/list [post]
+func ListUsers(c *gin.Context) {
+	req := user.UserListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	list, page, err := UserDao.UserPage(req.Keyword, pageInfo(req.Page, req.Size, "", "", ""))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
+
+// SetUserRole 修改用户角色
+// @Summary      修改用户角色
+// @Description  将用户设置为管理员(admin)或普通成员(member), 不能修改自己的角色, 需要 user:manage 权限
+// @Tags         系统管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.RoleReq  true  "角色参数"
+// @Success      200      {object}  system.Response
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /admin/user/role [post]
+func SetUserRole(c *gin.Context) {
+	req := user.RoleReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if !user.ValidRole(req.Role) {
+		system.Failed("角色取值为 admin 或 member", c)
+		return
+	}
+	if req.UserID == middleware.UserID(c) {
+		system.Failed("不能修改自己的角色", c)
+		return
+	}
+	if err := UserDao.UpdateRole(req.UserID, req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("用户不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("ok", c)
+}
+
+// CronTasks 查询定时任务
+// @Summary      查询定时任务
+// @Description  查询已注册的定时任务及其上次、下次执行时间, 需要 cron:manage 权限
+// @Tags         系统管理
+// @Produce      json
+// @Success      200  {object}  system.Response{data=[]cron.TaskInfo}
+// @Failure      403  {object}  system.Response
+// @Security     BearerAuth
+// @Router       /admin/cron/list [get]
+func CronTasks(c *gin.Context) {
+	system.Success(cron.Default.Tasks(), "ok", c)
+}
+
+// RunCronTask 手动执行定时任务
+// @Summary      手动执行定时任务
+// @Description  立即在后台执行一次定时任务, 不影响原有的执行计划, 需要 cron:manage 权限
+// @Tags         系统管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      admin.CronRunReq  true  "任务名称"
+// @Success      200      {object}  system.Response
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /admin/cron/run [post]
+func RunCronTask(c *gin.Context) {
+	req := admin.CronRunReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if err := cron.Default.RunTask(req.Name); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("已启动", c)
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/dao"
+	"go-film-demo/model/system"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/middleware"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+var UserDao = dao.NewUserDao()
+
+// usernamePattern 用户名只允许字母、数字、下划线、点与短横线
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Register 用户注册
+// @Summary      用户注册
+// @Description  创建账号并直接返回访问令牌与刷新令牌, 密码使用 bcrypt 哈希保存; 注册的用户均为普通成员
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.RegisterReq  true  "注册信息"
+// @Success      200      {object}  system.Response{data=user.TokenResp}
+// @Failure      500      {object}  system.Response
+// @Router       /auth/register [post]
+func Register(c *gin.Context) {
+	req := user.RegisterReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if n := utf8.RuneCountInString(req.Username); n < user.UsernameMinLen || n > user.UsernameMaxLen || !usernamePattern.MatchString(req.Username) {
+		system.Failed("用户名为 3-50 位字母、数字、下划线、点或短横线", c)
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			system.Failed("邮箱格式错误", c)
+			return
+		}
+	}
+	if utf8.RuneCountInString(req.Nickname) > 50 {
+		system.Failed("昵称长度不能超过 50", c)
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	u := &user.User{Username: req.Username, Email: req.Email, Nickname: req.Nickname, PasswordHash: hash, Role: user.RoleMember}
+	if err = UserDao.CreateUser(u); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	tokens, err := auth.IssueTokens(u)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(tokens, "注册成功", c)
+}
+
+// Login 用户登录
+// @Summary      用户登录
+// @Description  校验用户名与密码, 返回访问令牌(Authorization: Bearer)与刷新令牌;
+// @Description  启用两步验证的用户只返回 mfa_required 与 mfa_token, 需要再调用 /auth/2fa/verify 提交验证码
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.LoginReq  true  "登录信息"
+// @Success      200      {object}  system.Response{data=user.TokenResp}
+// @Failure      401      {object}  system.Response
+// @Router       /auth/login [post]
+func Login(c *gin.Context) {
+	req := user.LoginReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	tokens, err := auth.Login(strings.TrimSpace(req.Username), req.Password)
+	if errors.Is(err, auth.ErrBadCredentials) {
+		system.Unauthorized(err.Error(), c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(tokens, "登录成功", c)
+}
+
+// RefreshToken 刷新令牌
+// @Summary      刷新令牌
+// @Description  使用刷新令牌换取新的访问令牌与刷新令牌, 旧的刷新令牌随即失效; 已失效的令牌被重复使用时会撤销该用户的全部刷新令牌
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.RefreshReq  true  "刷新令牌"
+// @Success      200      {object}  system.Response{data=user.TokenResp}
+// @Failure      401      {object}  system.Response
+// @Router       /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	req := user.RefreshReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	tokens, err := auth.Refresh(req.RefreshToken)
+	if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrTokenExpired) {
+		system.Unauthorized(err.Error(), c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(tokens, "ok", c)
+}
+
+// Logout 退出登录
+// @Summary      退出登录
+// @Description  撤销刷新令牌, 访问令牌会在过期后自然失效
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.RefreshReq  true  "刷新令牌"
+// @Success      200      {object}  system.Response
+// @Failure      401      {object}  system.Response
+// @Router       /auth/logout [post]
+func Logout(c *gin.Context) {
+	req := user.RefreshReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if err := auth.Logout(req.RefreshToken); err != nil {
+		system.Unauthorized(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("已退出登录", c)
+}
+
+// Me 当前用户信息
+// @Summary      当前用户
+// @Description  返回访问令牌对应的用户信息, 角色要求两步验证而尚未启用时也可以调用
+// @Tags         用户认证
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  system.Response{data=user.User}
+// @Failure      401  {object}  system.Response
+// @Router       /auth/me [get]
+func Me(c *gin.Context) {
+	u, err := UserDao.GetUserByID(middleware.UserID(c))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if u == nil {
+		system.Unauthorized("用户不存在", c)
+		return
+	}
+	system.Success(u, "ok", c)
+}
+
+// ChangePassword 修改密码
+// @Summary      修改密码
+// @Description  校验原密码后修改密码, 并撤销该用户全部刷新令牌(其他设备需要重新登录)
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      user.PasswordReq  true  "密码"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Router       /auth/password [post]
+func ChangePassword(c *gin.Context) {
+	req := user.PasswordReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	u, err := UserDao.GetUserByID(middleware.UserID(c))
+	if err != nil || u == nil {
+		system.Failed("用户不存在", c)
+		return
+	}
+	if !auth.CheckPassword(u.PasswordHash, req.OldPassword) {
+		system.Failed("原密码错误", c)
+		return
+	}
+	if err = auth.ValidatePassword(req.NewPassword); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if err = UserDao.UpdatePassword(u.ID, hash); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if err = UserDao.RevokeUserRefreshTokens(u.ID); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("密码已修改", c)
+}
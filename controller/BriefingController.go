@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"go-film-demo/config"
+	"go-film-demo/model/briefing"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/digest"
+	"go-film-demo/plugin/middleware"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Briefing 获取每日简报
+// @Summary      每日简报
+// @Description  汇总当天日程(按优先级排序)与当天阅读量最高的新闻, 支持 JSON、Markdown、HTML 三种格式
+// @Tags         每日简报
+// @Produce      json,text/markdown,text/html
+// @Param        date     query     string  false  "日期 YYYY-MM-DD, 默认今天"
+// @Param        format   query     string  false  "输出格式 json/markdown/html"
+// @Param        refresh  query     bool    false  "忽略预生成结果重新生成"
+// @Success      200      {object}  system.Response{data=briefing.Briefing}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /briefing [get]
+func Briefing(c *gin.Context) {
+	req := briefing.QueryReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	date := time.Now()
+	if req.Date != "" {
+		var err error
+		if date, err = time.ParseInLocation("2006-01-02", req.Date, time.Local); err != nil {
+			system.Failed("日期格式错误, 期望格式: YYYY-MM-DD", c)
+			return
+		}
+	}
+
+	b, err := digest.Get(req.UserID, date, req.Refresh)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	switch req.Format {
+	case briefing.FormatMarkdown:
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(digest.RenderMarkdown(b)))
+	case briefing.FormatHTML:
+		html, err := digest.RenderHTML(b)
+		if err != nil {
+			system.Failed(err.Error(), c)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	default:
+		system.Success(b, "ok", c)
+	}
+}
+
+// SaveBriefingSetting 设置每日简报
+// @Summary      每日简报设置
+// @Description  设置每天预生成简报的时间, 关闭后不再预生成
+// @Tags         每日简报
+// @Accept       json
+// @Produce      json
+// @Param        request  body      briefing.SettingReq  true  "简报设置"
+// @Success      200      {object}  system.Response{data=briefing.BriefingSetting}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /briefing/setting [post]
+func SaveBriefingSetting(c *gin.Context) {
+	req := briefing.SettingReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.SendTime == "" {
+		req.SendTime = config.BriefingTime
+	}
+	if _, _, err := digest.ParseClock(req.SendTime); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	setting := &briefing.BriefingSetting{
+		UserID:   req.UserID,
+		SendTime: req.SendTime,
+		Enabled:  req.Enabled,
+	}
+	if err := digest.BriefingDao.SaveSetting(setting); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if err := digest.ScheduleUser(setting); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(setting, "ok", c)
+}
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/countdown"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var CountdownDao = dao.NewCountdownDao()
+
+// upcomingDefaultDays 默认查询未来一年内的倒数日
+const upcomingDefaultDays = 365
+
+// upcomingMaxDays 查询未来天数的上限, 纪念日在区间内每年都会返回一次
+const upcomingMaxDays = 3660
+
+// StoreCountdown 创建倒数日/纪念日
+// @Summary      创建倒数日
+// @Description  创建倒数日(只发生一次)或纪念日(每年重复), 支持农历日期
+// @Tags         倒数日
+// @Accept       json
+// @Produce      json
+// @Param        request  body      countdown.StoreReq  true  "倒数日信息"
+// @Success      200      {object}  system.Response{data=countdown.Countdown}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /countdown/store [post]
+func StoreCountdown(c *gin.Context) {
+	req := countdown.StoreReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+
+	cd := countdownFromReq(req)
+	if err := cd.Validate(); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if err := CountdownDao.CreateCountdown(cd); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(cd, "ok", c)
+}
+
+// UpdateCountdown 更新倒数日/纪念日
+// @Summary      更新倒数日
+// @Description  更新倒数日或纪念日
+// @Tags         倒数日
+// @Accept       json
+// @Produce      json
+// @Param        request  body      countdown.UpdateReq  true  "倒数日信息"
+// @Success      200      {object}  system.Response{data=countdown.Countdown}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /countdown/update [post]
+func UpdateCountdown(c *gin.Context) {
+	req := countdown.UpdateReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	old, err := CountdownDao.GetCountdownByID(req.ID)
+	if err != nil || old == nil || old.UserID != req.UserID {
+		system.Failed("倒数日不存在", c)
+		return
+	}
+
+	cd := countdownFromReq(req.StoreReq)
+	cd.ID = old.ID
+	cd.CreateAt = old.CreateAt
+	cd.UpdateAt = time.Now()
+	if err = cd.Validate(); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if err = CountdownDao.UpdateCountdown(cd); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(cd, "ok", c)
+}
+
+// DeleteCountdown 删除倒数日/纪念日
+// @Summary      删除倒数日
+// @Description  删除倒数日或纪念日
+// @Tags         倒数日
+// @Accept       json
+// @Produce      json
+// @Param        request  body      countdown.DeleteReq  true  "删除参数"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /countdown/delete [post]
+func DeleteCountdown(c *gin.Context) {
+	req := countdown.DeleteReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	cd, err := CountdownDao.GetCountdownByID(req.ID)
+	if err != nil || cd == nil || cd.UserID != req.UserID {
+		system.Failed("倒数日不存在", c)
+		return
+	}
+	if err = CountdownDao.DeleteCountdown(req.ID); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(nil, "ok", c)
+}
+
+// Upcoming 查询即将到来的倒数日/纪念日
+// @Summary      即将到来的倒数日
+// @Description  按剩余天数升序返回未来 N 天内(最多 3660 天)的倒数日与纪念日, 纪念日在区间内每年各返回一次, 农历纪念日自动换算为公历日期
+// @Tags         倒数日
+// @Accept       json
+// @Produce      json
+// @Param        request  body      countdown.UpcomingReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=[]countdown.Occurrence}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /countdown/upcoming [post]
+func Upcoming(c *gin.Context) {
+	req := countdown.UpcomingReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.Days <= 0 {
+		req.Days = upcomingDefaultDays
+	}
+	if req.Days > upcomingMaxDays {
+		system.Failed(fmt.Sprintf("days 不能超过 %d", upcomingMaxDays), c)
+		return
+	}
+
+	today := time.Now()
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	list, err := countdownsBetween(req.UserID, from, from.AddDate(0, 0, req.Days+1))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// countdownFromReq 根据请求构建倒数日, 类型与历法使用默认值
+func countdownFromReq(req countdown.StoreReq) *countdown.Countdown {
+	cd := &countdown.Countdown{
+		UserID:    req.UserID,
+		Title:     req.Title,
+		Kind:      req.Kind,
+		Calendar:  req.Calendar,
+		Year:      req.Year,
+		Month:     req.Month,
+		Day:       req.Day,
+		LeapMonth: req.LeapMonth,
+		Remark:    req.Remark,
+	}
+	if cd.Kind == "" {
+		cd.Kind = countdown.KindCountdown
+	}
+	if cd.Calendar == "" {
+		cd.Calendar = countdown.CalendarSolar
+	}
+	return cd
+}
+
+// countdownsBetween 获取用户在 [from, to) 内发生的倒数日, 按日期升序
+func countdownsBetween(userID int64, from, to time.Time) ([]countdown.Occurrence, error) {
+	list, err := CountdownDao.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]countdown.Occurrence, 0)
+	now := time.Now()
+	for i := range list {
+		for _, date := range list[i].OccurrencesBetween(from, to) {
+			result = append(result, list[i].OccurrenceOn(date, now))
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+	return result, nil
+}
@@ -14,13 +14,20 @@ var NewsDao = dao.NewNewsRepository()
 
 // Start 启动新闻采集
 // @Summary      启动新闻采集
-// @Description  手动触发新闻数据采集任务
+// @Description  手动触发新闻数据采集任务, 需要 spider:manage 权限(管理员); 同一时间只允许一个采集任务, 正在采集时返回 409
 // @Tags         新闻管理
 // @Produce      json
 // @Success      200  {object}  system.Response
+// @Failure      403  {object}  system.Response
+// @Failure      409  {object}  system.Response
+// @Failure      429  {object}  system.Response
+// @Security     BearerAuth
 // @Router       /news/start [get]
 func Start(c *gin.Context) {
-	go spider.CollectNews()
+	if !spider.StartCollectNews() {
+		system.Conflict(nil, "新闻采集正在进行中, 请稍后再试", c)
+		return
+	}
 	system.Success("ok", "已启动", c)
 }
 
@@ -33,6 +40,7 @@ func Start(c *gin.Context) {
 // @Param        request  body      news.QueryReq  true  "查询参数"
 // @Success      200      {object}  system.Response{data=[]news.News}
 // @Failure      500      {object}  system.Response
+// @Security     BearerAuth
 // @Router       /news/query [post]
 func QueryNews(c *gin.Context) {
 	var req news.QueryReq
@@ -46,3 +54,44 @@ func QueryNews(c *gin.Context) {
 	dateRange, err := NewsDao.GetNewsByDateRange(parse)
 	system.Success(dateRange, "ok", c)
 }
+
+// ListNews 分页查询新闻列表
+// @Summary      分页查询新闻
+// @Description  按日期、标题关键字、来源、作者分页查询新闻, 支持页码与游标两种分页方式以及排序字段选择
+// @Tags         新闻管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      news.PageReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /news/list [post]
+func ListNews(c *gin.Context) {
+	var req news.PageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	vo := dao.NewsRequestVo{
+		Keyword: req.Keyword,
+		Source:  req.Source,
+		Creator: req.Creator,
+		Paging:  pageInfo(req.Page, req.Size, req.Cursor, req.Sort, req.Order),
+	}
+	if req.Date != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			system.Failed("日期格式错误, 期望格式: YYYY-MM-DD", c)
+			return
+		}
+		vo.Date = date
+	}
+
+	list, page, err := NewsDao.Page(vo)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
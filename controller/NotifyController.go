@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/notify"
+	"go-film-demo/model/system"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/middleware"
+	"go-film-demo/plugin/notifier"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var NotifyDao = dao.NewNotifyDao()
+
+// SaveChannel 创建或修改通知渠道
+// @Summary      保存通知渠道
+// @Description  id 为 0 时新建; type 为 email/wecom/dingtalk/feishu, 邮件渠道的 target 为收件地址,
+// @Description  群机器人的 target 为 webhook 地址或令牌, 钉钉/飞书开启加签时需填写 secret;
+// @Description  events 可选 schedule.reminder/briefing.daily/news.breaking, 为空时接收日程提醒与每日简报;
+// @Description  team 为 true 时保存为团队共享渠道(需要 notify:team 权限), 团队共享渠道只接收新闻速递等广播事件
+// @Tags         通知渠道
+// @Accept       json
+// @Produce      json
+// @Param        request  body      notify.ChannelReq  true  "渠道参数"
+// @Success      200      {object}  system.Response{data=notify.Channel}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /notify/channel/store [post]
+func SaveChannel(c *gin.Context) {
+	req := notify.ChannelReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	owner, ok := channelOwner(c, req.Team)
+	if !ok {
+		return
+	}
+	req.UserID = owner
+	ch := &notify.Channel{
+		ID:       req.ID,
+		UserID:   req.UserID,
+		Type:     strings.ToLower(req.Type),
+		Name:     req.Name,
+		Target:   strings.TrimSpace(req.Target),
+		Secret:   req.Secret,
+		Events:   strings.Join(req.Events, ","),
+		Enabled:  req.Enabled,
+		UpdateAt: time.Now(),
+	}
+	if err := notifier.Validate(ch); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if err := NotifyDao.SaveChannel(ch); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("通知渠道不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(ch, "ok", c)
+}
+
+// DeleteChannel 删除通知渠道
+// @Summary      删除通知渠道
+// @Description  删除用户的通知渠道, 未投递的通知将标记为失败
+// @Tags         通知渠道
+// @Accept       json
+// @Produce      json
+// @Param        request  body      notify.ChannelIDReq  true  "渠道ID"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /notify/channel/delete [post]
+func DeleteChannel(c *gin.Context) {
+	req := notify.ChannelIDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	owner, ok := channelOwner(c, req.Team)
+	if !ok {
+		return
+	}
+	req.UserID = owner
+	if err := NotifyDao.DeleteChannel(req.ID, req.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("通知渠道不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(nil, "ok", c)
+}
+
+// ListChannels 查询通知渠道
+// @Summary      查询通知渠道
+// @Description  查询用户配置的全部通知渠道
+// @Tags         通知渠道
+// @Accept       json
+// @Produce      json
+// @Param        request  body      notify.ChannelListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=[]notify.Channel}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /notify/channel/list [post]
+func ListChannels(c *gin.Context) {
+	req := notify.ChannelListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	owner, ok := channelOwner(c, req.Team)
+	if !ok {
+		return
+	}
+	req.UserID = owner
+	list, err := NotifyDao.ListChannels(req.UserID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// TestChannel 发送测试通知
+// @Summary      测试通知渠道
+// @Description  向指定渠道投递一条测试消息, 投递结果可在投递日志中查看
+// @Tags         通知渠道
+// @Accept       json
+// @Produce      json
+// @Param        request  body      notify.ChannelIDReq  true  "渠道ID"
+// @Success      200      {object}  system.Response{data=notify.Delivery}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /notify/channel/test [post]
+func TestChannel(c *gin.Context) {
+	req := notify.ChannelIDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	owner, ok := channelOwner(c, req.Team)
+	if !ok {
+		return
+	}
+	req.UserID = owner
+	ch, err := NotifyDao.GetChannel(req.ID)
+	if err != nil || ch == nil || ch.UserID != req.UserID {
+		system.Failed("通知渠道不存在", c)
+		return
+	}
+	key := fmt.Sprintf("%s:%d:%d", notify.EventTest, ch.ID, time.Now().UnixNano())
+	d, err := notifier.Enqueue(ch, notify.EventTest, key, notifier.TestMessage())
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	go notifier.ProcessQueue()
+	system.Success(d, "测试通知已加入投递队列", c)
+}
+
+// Deliveries 查询通知投递日志
+// @Summary      通知投递日志
+// @Description  分页查询通知投递记录, 可按用户与状态(pending/sent/failed)筛选
+// @Tags         通知渠道
+// @Accept       json
+// @Produce      json
+// @Param        request  body      notify.DeliveryListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /notify/deliveries [post]
+func Deliveries(c *gin.Context) {
+	req := notify.DeliveryListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	list, page, err := NotifyDao.DeliveryPage(req.UserID, req.Status, pageInfo(req.Page, req.Size, "", "", ""))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
+
+// channelOwner 通知渠道的所属用户, 团队共享渠道为0且需要 notify:team 权限; 没有权限时已写入响应
+func channelOwner(c *gin.Context, team bool) (int64, bool) {
+	if !team {
+		return middleware.UserID(c), true
+	}
+	ok, err := auth.Can(middleware.UserID(c), user.PermTeamChannel)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return 0, false
+	}
+	if !ok {
+		system.Forbidden("只有管理员可以管理团队共享渠道", c)
+		return 0, false
+	}
+	return 0, true
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"go-film-demo/config"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/oidc"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie 保存登录请求 state 哈希的 Cookie, 回调时与 state 参数比对, 确保回调来自发起登录的浏览器
+const oidcStateCookie = "oidc_state"
+
+// OIDCLogin 发起单点登录
+// @Summary      单点登录
+// @Description  重定向到 OpenID Connect 提供方的授权页面(授权码模式 + PKCE); redirect=false 时返回授权地址, 由前端自行跳转;
+// @Description  同时设置 HttpOnly、SameSite=Lax 的 oidc_state Cookie, 回调时必须携带
+// @Tags         用户认证
+// @Produce      json
+// @Param        redirect  query     bool  false  "是否直接重定向, 默认 true"
+// @Success      302       {string}  string  "重定向到授权页面"
+// @Success      200       {object}  system.Response{data=map[string]string}
+// @Failure      500       {object}  system.Response
+// @Router       /auth/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	provider := auth.OIDC()
+	if provider == nil {
+		system.Failed("未启用单点登录", c)
+		return
+	}
+	authURL, state, err := provider.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	setOIDCStateCookie(c, stateHash(state), int(oidc.StateTTL().Seconds()))
+	if c.Query("redirect") == "false" {
+		system.Success(gin.H{"url": authURL}, "ok", c)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 单点登录回调
+// @Summary      单点登录回调
+// @Description  校验 state 与发起登录时设置的 oidc_state Cookie 一致后使用授权码与 code_verifier 换取 ID Token, 校验签名与声明后登录对应的本地用户, 首次登录时自动创建用户;
+// @Description  配置 OIDC_SUCCESS_URL 时重定向到该地址并在 URL fragment 中携带令牌, 否则直接返回令牌;
+// @Description  启用两步验证的用户返回 mfa_required 与 mfa_token, 需要再调用 /auth/2fa/verify
+// @Tags         用户认证
+// @Produce      json
+// @Param        code   query     string  true  "授权码"
+// @Param        state  query     string  true  "登录请求标识"
+// @Success      200    {object}  system.Response{data=user.TokenResp}
+// @Failure      401    {object}  system.Response
+// @Router       /auth/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	provider := auth.OIDC()
+	if provider == nil {
+		system.Failed("未启用单点登录", c)
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		system.Unauthorized("单点登录失败: "+e+" "+c.Query("error_description"), c)
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		system.Failed("非法参数", c)
+		return
+	}
+	// state 必须属于当前浏览器发起的登录, 防止攻击者诱导用户完成攻击者账号的回调(登录 CSRF)
+	cookie, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+	if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(stateHash(state))) != 1 {
+		system.Unauthorized(oidc.ErrInvalidState.Error(), c)
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), state, code)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidState) || errors.Is(err, oidc.ErrInvalidToken) {
+			system.Unauthorized(err.Error(), c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	tokens, err := auth.LoginOIDC(claims)
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCNotProvisioned) {
+			system.Unauthorized(err.Error(), c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	if config.OIDCSuccessURL != "" {
+		fragment := url.Values{}
+		if tokens.MFARequired {
+			fragment.Set("mfa_required", "true")
+			fragment.Set("mfa_token", tokens.MFAToken)
+		} else {
+			fragment.Set("access_token", tokens.AccessToken)
+			fragment.Set("refresh_token", tokens.RefreshToken)
+			fragment.Set("token_type", tokens.TokenType)
+		}
+		fragment.Set("expires_in", strconv.FormatInt(tokens.ExpiresIn, 10))
+		c.Redirect(http.StatusFound, config.OIDCSuccessURL+"#"+fragment.Encode())
+		return
+	}
+	system.Success(tokens, "登录成功", c)
+}
+
+// stateHash Cookie 中只保存 state 的哈希
+func stateHash(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// setOIDCStateCookie 设置或清除(maxAge 为 -1) oidc_state Cookie, 回调地址为 https 时只通过 https 发送
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.HasPrefix(config.OIDCRedirectURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, "/", "", secure, true)
+}
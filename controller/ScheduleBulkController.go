@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkMaxItems 单次批量操作允许的最大日程数量
+const bulkMaxItems = 500
+
+var errBulkRollback = errors.New("批量操作存在失败项, 已全部回滚")
+
+// Bulk 批量操作日程
+// @Summary      批量操作日程
+// @Description  对指定ID或符合筛选条件的日程批量修改状态、优先级、标签、整体平移时间或删除;
+// @Description  所有操作在同一事务中执行, 任意一项失败(不存在、非本人日程、版本冲突、平移后与依赖冲突)则全部回滚;
+// @Description  按筛选条件操作时筛选条件不能为空, 且匹配结果不能超过 500 条
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.BulkReq  true  "批量操作参数"
+// @Success      200      {object}  system.Response{data=[]schedule.BulkItemResult}
+// @Failure      500      {object}  system.Response{data=[]schedule.BulkItemResult}
+// @Security     BearerAuth
+// @Router       /schedule/bulk [post]
+func Bulk(c *gin.Context) {
+	req := schedule.BulkReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if len(req.IDs) == 0 && req.Filter == nil {
+		system.Failed("请指定日程ID列表或筛选条件", c)
+		return
+	}
+	// 空筛选条件会匹配当前用户的全部日程, 不允许作为批量操作的目标
+	if len(req.IDs) == 0 && *req.Filter == (schedule.BulkFilter{}) {
+		system.Failed("筛选条件不能为空", c)
+		return
+	}
+	if err := validateBulkOps(req.Ops); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	var results []schedule.BulkItemResult
+	err := ScheduleDao.OperationTransaction(req.UserID, schedule.OpBulk, func(tx *dao.ScheduleDao) error {
+		rows, err := loadBulkTargets(tx, req, &results)
+		if err != nil {
+			return err
+		}
+		return applyBulkOps(tx, req.UserID, req.Ops, rows, results)
+	})
+	if errors.Is(err, errBulkRollback) {
+		system.FailedWithData(results, err.Error(), c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	system.Success(results, "ok", c)
+}
+
+// validateBulkOps 校验批量操作内容
+func validateBulkOps(ops schedule.BulkOps) error {
+	hasUpdate := ops.Status != nil || ops.Priority != nil || len(ops.AddTags) > 0 ||
+		len(ops.RemoveTags) > 0 || ops.ShiftMinutes != 0
+	if ops.Delete && hasUpdate {
+		return errors.New("删除操作不能与其他操作同时使用")
+	}
+	if !ops.Delete && !hasUpdate {
+		return errors.New("没有需要执行的操作")
+	}
+	if ops.Status != nil && !schedule.ValidStatus(*ops.Status) {
+		return errors.New("status 取值范围为 1-4")
+	}
+	if ops.Priority != nil && !schedule.ValidPriority(*ops.Priority) {
+		return errors.New("priority 取值范围为 0-2")
+	}
+	return nil
+}
+
+// loadBulkTargets 加载批量操作的目标日程并逐条校验归属, 结果按目标顺序写入 results
+func loadBulkTargets(tx *dao.ScheduleDao, req schedule.BulkReq, results *[]schedule.BulkItemResult) ([]schedule.Schedule, error) {
+	var rows []schedule.Schedule
+	if len(req.IDs) > 0 {
+		ids := uniqueIDs(req.IDs)
+		if len(ids) > bulkMaxItems {
+			return nil, fmt.Errorf("单次最多操作 %d 条日程", bulkMaxItems)
+		}
+		found, err := tx.GetSchedulesByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[int64]schedule.Schedule, len(found))
+		for _, s := range found {
+			byID[s.ID] = s
+		}
+		for _, id := range ids {
+			s, ok := byID[id]
+			switch {
+			case !ok:
+				*results = append(*results, schedule.BulkItemResult{ID: id, Error: "日程不存在"})
+			case s.UserID != req.UserID:
+				*results = append(*results, schedule.BulkItemResult{ID: id, Error: "无权操作该日程"})
+			default:
+				*results = append(*results, schedule.BulkItemResult{ID: id, Success: true})
+				rows = append(rows, s)
+			}
+		}
+	} else {
+		vo, err := bulkFilterVo(req.UserID, req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		// 多查询一条用于判断是否超过上限, 同时对匹配的日程加行锁, 避免更新时被其他请求修改
+		if rows, err = tx.ScheduleListLimit(vo, bulkMaxItems+1, true); err != nil {
+			return nil, err
+		}
+		if len(rows) > bulkMaxItems {
+			return nil, fmt.Errorf("筛选结果超过 %d 条, 请缩小筛选范围", bulkMaxItems)
+		}
+		for _, s := range rows {
+			*results = append(*results, schedule.BulkItemResult{ID: s.ID, Success: true})
+		}
+	}
+
+	for _, r := range *results {
+		if !r.Success {
+			return nil, errBulkRollback
+		}
+	}
+	return rows, nil
+}
+
+// applyBulkOps 在事务中对目标日程执行批量操作, 单条失败时标记结果并回滚
+func applyBulkOps(tx *dao.ScheduleDao, actorID int64, ops schedule.BulkOps, rows []schedule.Schedule, results []schedule.BulkItemResult) error {
+	fail := func(id int64, err error) error {
+		for i := range results {
+			if results[i].ID == id {
+				results[i].Success = false
+				results[i].Error = err.Error()
+			}
+		}
+		return errBulkRollback
+	}
+
+	statusOnly := ops.Status != nil && ops.Priority == nil && len(ops.AddTags) == 0 &&
+		len(ops.RemoveTags) == 0 && ops.ShiftMinutes == 0
+
+	var shifted [][2]*schedule.Schedule
+	for i := range rows {
+		before := rows[i]
+		if ops.Delete {
+			if err := tx.DeleteScheduleVersion(before.ID, before.Version); err != nil {
+				return fail(before.ID, err)
+			}
+			if err := tx.RecordHistory(schedule.HistoryActionDelete, actorID, &before, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		after := before
+		applyBulkChange(&after, ops)
+		if statusOnly {
+			// 只修改状态时只更新相关字段, 按读取时的版本号更新, 成功后数据库中的版本号即为 before.Version+1
+			updates := map[string]interface{}{"status": after.Status, "update_at": after.UpdateAt}
+			if err := tx.UpdateScheduleByFields(before.ID, before.Version, updates); err != nil {
+				return fail(before.ID, err)
+			}
+			after.Version = before.Version + 1
+		} else if err := tx.UpdateSchedule(&after); err != nil {
+			return fail(before.ID, err)
+		}
+		if err := tx.RecordHistory(schedule.HistoryActionUpdate, actorID, &before, &after); err != nil {
+			return err
+		}
+		if ops.ShiftMinutes != 0 {
+			shifted = append(shifted, [2]*schedule.Schedule{&rows[i], &after})
+		}
+	}
+
+	// 全部平移完成后再校验依赖, 同时平移的前后置日程之间不会误报冲突; 批量操作不自动顺延其他日程
+	for _, pair := range shifted {
+		if err := rescheduleDependents(tx, actorID, pair[0], pair[1], false); err != nil {
+			return fail(pair[0].ID, err)
+		}
+	}
+	return nil
+}
+
+// applyBulkChange 将批量修改应用到单个日程上
+func applyBulkChange(s *schedule.Schedule, ops schedule.BulkOps) {
+	if ops.Status != nil {
+		s.Status = *ops.Status
+	}
+	if ops.Priority != nil {
+		s.Priority = int8(*ops.Priority)
+	}
+	if len(ops.AddTags) > 0 || len(ops.RemoveTags) > 0 {
+		removed := make(map[string]bool, len(ops.RemoveTags))
+		for _, tag := range ops.RemoveTags {
+			removed[tag] = true
+		}
+		tags := make([]string, 0)
+		for _, tag := range append(s.TagList(), ops.AddTags...) {
+			if !removed[tag] {
+				tags = append(tags, tag)
+			}
+		}
+		s.SetTags(tags)
+	}
+	if ops.ShiftMinutes != 0 {
+		offset := time.Duration(ops.ShiftMinutes) * time.Minute
+		s.StartTime = s.StartTime.Add(offset)
+		s.EndTime = s.EndTime.Add(offset)
+		s.SyncDate()
+	}
+	s.UpdateAt = time.Now()
+}
+
+// bulkFilterVo 将批量筛选条件转换为查询参数, 始终限定为当前用户
+func bulkFilterVo(userID int64, f *schedule.BulkFilter) (dao.ScheduleRequestVo, error) {
+	vo := dao.ScheduleRequestVo{
+		UserID:   userID,
+		Year:     int16(f.Year),
+		Month:    int8(f.Month),
+		Day:      int8(f.Day),
+		Content:  f.Content,
+		Priority: int8(f.Priority),
+		Status:   f.Status,
+	}
+	if f.Begin != "" || f.End != "" {
+		var err error
+		if vo.BeginTime, err = stringToTimeStandard(f.Begin); err != nil {
+			return vo, err
+		}
+		if vo.EndTime, err = stringToTimeStandard(f.End); err != nil {
+			return vo, err
+		}
+	}
+	return vo, nil
+}
+
+// uniqueIDs ID去重并保持原有顺序
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	list := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			list = append(list, id)
+		}
+	}
+	return list
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"go-film-demo/config"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// conflictLookback 向前多取的时间范围, 用于检测跨越起始时间的日程
+const conflictLookback = 24 * time.Hour
+
+// Conflicts 检测日程冲突
+// @Summary      检测日程冲突
+// @Description  检测时间范围内的日程冲突: overlap 为时间重叠, travel 为相邻日程在不同地点且间隔小于路程时间;
+// @Description  路程时间按两地直线距离与 TRAVEL_SPEED_KMH 估算, 缺少坐标时使用 TRAVEL_FALLBACK_MINUTES, 线上会议不计路程
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.ConflictReq  true  "检测参数"
+// @Success      200      {object}  system.Response{data=[]schedule.Conflict}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/conflicts [post]
+func Conflicts(c *gin.Context) {
+	req := schedule.ConflictReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	begin, err := stringToTimeStandard(req.Begin)
+	if err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	end, err := stringToTimeStandard(req.End)
+	if err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if end.Before(begin) {
+		system.Failed("结束时间不能早于开始时间", c)
+		return
+	}
+
+	conflicts := scheduleConflicts(req.UserID, begin, end)
+	if req.ID > 0 {
+		related := make([]schedule.Conflict, 0)
+		for _, conflict := range conflicts {
+			if conflict.Previous.ID == req.ID || conflict.Next.ID == req.ID {
+				related = append(related, conflict)
+			}
+		}
+		conflicts = related
+	}
+	system.Success(conflicts, "ok", c)
+}
+
+// scheduleConflicts 返回后一日程在 [begin, end] 内开始的冲突
+func scheduleConflicts(userID int64, begin, end time.Time) []schedule.Conflict {
+	vo := dao.ScheduleRequestVo{UserID: userID, BeginTime: begin.Add(-conflictLookback), EndTime: end}
+	list := ScheduleDao.ScheduleList(vo)
+
+	fallback := time.Duration(config.TravelFallbackMinutes) * time.Minute
+	all := schedule.DetectConflicts(list, float64(config.TravelSpeedKmh), fallback)
+	conflicts := make([]schedule.Conflict, 0, len(all))
+	for _, conflict := range all {
+		if !conflict.Next.StartTime.Before(begin) {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
@@ -1,12 +1,19 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"go-film-demo/dao"
+	"go-film-demo/model/countdown"
 	"go-film-demo/model/schedule"
 	"go-film-demo/model/system"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/middleware"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,13 +22,14 @@ var ScheduleDao = dao.NewScheduleDao()
 
 // Query 查询指定日期的日程列表
 // @Summary      查询日程
-// @Description  根据用户ID和日期查询日程列表
+// @Description  根据用户ID和日期查询日程列表, with_countdowns 为 true 时额外返回当日的倒数日与纪念日
 // @Tags         日程管理
 // @Accept       json
 // @Produce      json
 // @Param        request  body      schedule.QueryReq  true  "查询参数"
 // @Success      200      {object}  system.Response{data=[]schedule.Schedule}
 // @Failure      500      {object}  system.Response
+// @Security     BearerAuth
 // @Router       /schedule/query [post]
 func Query(c *gin.Context) {
 	req := schedule.QueryReq{}
@@ -29,6 +37,7 @@ func Query(c *gin.Context) {
 		system.Failed("非法查询参数", c)
 		return
 	}
+	req.UserID = middleware.UserID(c)
 
 	vo := dao.ScheduleRequestVo{
 		UserID: req.UserID,
@@ -38,7 +47,27 @@ func Query(c *gin.Context) {
 	}
 
 	scheduleList := ScheduleDao.ScheduleList(vo)
-	system.Success(scheduleList, "ok", c)
+	if !req.WithCountdowns {
+		system.Success(scheduleList, "ok", c)
+		return
+	}
+
+	resp := schedule.QueryResp{Schedules: scheduleList, Countdowns: []countdown.Occurrence{}}
+	if req.Year > 0 && req.Month > 0 {
+		from := time.Date(int(req.Year), time.Month(req.Month), 1, 0, 0, 0, 0, time.Local)
+		to := from.AddDate(0, 1, 0)
+		if req.Day > 0 {
+			from = from.AddDate(0, 0, int(req.Day)-1)
+			to = from.AddDate(0, 0, 1)
+		}
+		list, err := countdownsBetween(req.UserID, from, to)
+		if err != nil {
+			system.Failed(err.Error(), c)
+			return
+		}
+		resp.Countdowns = list
+	}
+	system.Success(resp, "ok", c)
 }
 
 // QueryMonth 查询指定月份的日程列表
@@ -50,6 +79,7 @@ func Query(c *gin.Context) {
 // @Param        request  body      schedule.QueryReq  true  "查询参数"
 // @Success      200      {object}  system.Response{data=[]schedule.Schedule}
 // @Failure      500      {object}  system.Response
+// @Security     BearerAuth
 // @Router       /schedule/queryMonth [post]
 func QueryMonth(c *gin.Context) {
 	req := schedule.QueryReq{}
@@ -57,6 +87,7 @@ func QueryMonth(c *gin.Context) {
 		system.Failed("非法查询参数", c)
 		return
 	}
+	req.UserID = middleware.UserID(c)
 
 	vo := dao.ScheduleRequestVo{
 		UserID: req.UserID,
@@ -68,6 +99,75 @@ func QueryMonth(c *gin.Context) {
 	system.Success(scheduleList, "ok", c)
 }
 
+// List 分页查询日程列表
+// @Summary      分页查询日程
+// @Description  按条件分页查询日程, 支持页码(page/size)与游标(cursor)两种分页方式以及排序字段选择
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.PageReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/list [post]
+func List(c *gin.Context) {
+	req := schedule.PageReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+
+	vo := dao.ScheduleRequestVo{
+		UserID:   req.UserID,
+		Year:     int16(req.Year),
+		Month:    int8(req.Month),
+		Day:      int8(req.Day),
+		Content:  req.Content,
+		Priority: int8(req.Priority),
+		Status:   req.Status,
+		Paging:   pageInfo(req.Page, req.Size, req.Cursor, req.Sort, req.Order),
+	}
+
+	list, page, err := ScheduleDao.SchedulePage(vo)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
+
+// Detail 查询单个日程
+// @Summary      日程详情
+// @Description  根据ID查询日程, 响应头携带 ETag, 请求头 If-None-Match 命中时返回 304
+// @Tags         日程管理
+// @Produce      json
+// @Param        id   path      int  true  "日程ID"
+// @Success      200  {object}  system.Response{data=schedule.Schedule}
+// @Failure      500  {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/{id} [get]
+func Detail(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	s, err := ScheduleDao.GetScheduleByID(id)
+	if err != nil || s == nil || !canViewSchedule(c, s.UserID) {
+		system.Failed("日程不存在", c)
+		return
+	}
+
+	etag := scheduleETag(s)
+	c.Header("ETag", etag)
+	if etagMatched(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	system.Success(s, "ok", c)
+}
+
 // Store 创建新日程
 // @Summary      创建日程
 // @Description  创建一个新的日程安排
@@ -77,6 +177,7 @@ func QueryMonth(c *gin.Context) {
 // @Param        request  body      schedule.StoreReq  true  "日程信息"
 // @Success      200      {object}  system.Response
 // @Failure      500      {object}  system.Response
+// @Security     BearerAuth
 // @Router       /schedule/store [post]
 func Store(c *gin.Context) {
 	req := schedule.StoreReq{}
@@ -84,20 +185,31 @@ func Store(c *gin.Context) {
 		system.Failed("非法参数", c)
 		return
 	}
+	req.UserID = middleware.UserID(c)
+	if req.Kind == "" {
+		req.Kind = schedule.KindEvent
+	}
 
-	start, err := stringToTimeStandard(req.Start)
+	due, err := parseDue(req.Due)
 	if err != nil {
-		system.Failed("非法参数", c)
+		system.Failed(err.Error(), c)
 		return
 	}
-
-	end, err := stringToTimeStandard(req.End)
+	start, end, unscheduled, err := scheduleTimes(req.Kind, req.Start, req.End, due, time.Now())
 	if err != nil {
 		system.Failed("非法参数", c)
 		return
 	}
+	if req.EffortMinutes < 0 || req.EffortMinutes > schedule.EffortMaxMinutes {
+		system.Failed(fmt.Sprintf("预计耗时取值范围为 0-%d 分钟", schedule.EffortMaxMinutes), c)
+		return
+	}
+	if utf8.RuneCountInString(req.Notes) > schedule.NotesMaxLen {
+		system.Failed(fmt.Sprintf("备注长度不能超过 %d", schedule.NotesMaxLen), c)
+		return
+	}
 
-	err = ScheduleDao.CreateSchedule(&schedule.Schedule{
+	s := &schedule.Schedule{
 		UserID:    req.UserID,
 		Year:      int16(req.Year),
 		Month:     int8(req.Month),
@@ -105,7 +217,34 @@ func Store(c *gin.Context) {
 		StartTime: start,
 		EndTime:   end,
 		Content:   req.Content,
+		Notes:     req.Notes,
 		Priority:  int8(req.Priority),
+		Kind:      req.Kind,
+		Version:   1,
+
+		DueAt:         due,
+		EffortMinutes: req.EffortMinutes,
+		Unscheduled:   unscheduled,
+	}
+	if unscheduled || req.Year == 0 {
+		s.SyncDate()
+	}
+	if req.Location != nil {
+		req.Location.ApplyTo(s)
+	}
+	if !schedule.ValidKind(s.Kind) {
+		system.Failed("kind 取值为 event 或 task", c)
+		return
+	}
+	if err = s.ValidateLocation(); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	err = ScheduleDao.OperationTransaction(req.UserID, schedule.OpCreate, func(tx *dao.ScheduleDao) error {
+		if err := tx.CreateSchedule(s); err != nil {
+			return err
+		}
+		return tx.RecordHistory(schedule.HistoryActionCreate, req.UserID, nil, s)
 	})
 	if err != nil {
 		system.Failed(err.Error(), c)
@@ -117,13 +256,14 @@ func Store(c *gin.Context) {
 
 // Update 更新日程
 // @Summary      更新日程
-// @Description  更新已存在的日程信息
+// @Description  更新已存在的日程信息, 开始时间不能早于前置日程的结束时间; cascade 为 true 时自动顺延后置日程
 // @Tags         日程管理
 // @Accept       json
 // @Produce      json
 // @Param        request  body      schedule.UpdateReq  true  "更新信息"
 // @Success      200      {object}  system.Response
 // @Failure      500      {object}  system.Response
+// @Security     BearerAuth
 // @Router       /schedule/update [post]
 func Update(c *gin.Context) {
 	req := schedule.UpdateReq{}
@@ -131,47 +271,143 @@ func Update(c *gin.Context) {
 		system.Failed("非法参数", c)
 		return
 	}
-	start, err := stringToTimeStandard(req.Start)
+	req.UserID = middleware.UserID(c)
+	s, err := ScheduleDao.GetScheduleByID(int64(req.ID))
 	if err != nil {
-		system.Failed("非法参数", c)
+		system.Failed("日程不存在", c)
+		return
+	}
+	if s == nil || s.UserID != req.UserID {
+		system.Failed("日程不存在", c)
+		return
+	}
+	if !versionMatched(c, s, req.Version) {
+		scheduleConflict(s, c)
+		return
+	}
+	updated, err := buildScheduleUpdate(s, req)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	err = ScheduleDao.OperationTransaction(req.UserID, schedule.OpUpdate, func(tx *dao.ScheduleDao) error {
+		if err := tx.UpdateSchedule(updated); err != nil {
+			return err
+		}
+		if err := tx.RecordHistory(schedule.HistoryActionUpdate, req.UserID, s, updated); err != nil {
+			return err
+		}
+		return rescheduleDependents(tx, req.UserID, s, updated, req.Cascade)
+	})
+	if errors.Is(err, dao.ErrVersionConflict) {
+		scheduleConflictByID(updated.ID, c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
 		return
 	}
 
-	end, err := stringToTimeStandard(req.End)
+	c.Header("ETag", scheduleETag(updated))
+	system.Success(updated, "ok", c)
+
+}
+
+// buildScheduleUpdate 根据更新请求生成新的日程数据, 以当前日程为基础, 请求中未包含的字段(标签、顺延次数等)保持不变
+func buildScheduleUpdate(s *schedule.Schedule, req schedule.UpdateReq) (*schedule.Schedule, error) {
+	if req.Kind == "" {
+		req.Kind = s.Kind
+	}
+	if !schedule.ValidKind(req.Kind) {
+		return nil, errors.New("kind 取值为 event 或 task")
+	}
+	due := s.DueAt
+	if req.Due != nil {
+		var err error
+		if due, err = parseDue(*req.Due); err != nil {
+			return nil, err
+		}
+	}
+	start, end, unscheduled, err := scheduleTimes(req.Kind, req.Start, req.End, due, s.StartTime)
 	if err != nil {
+		return nil, errors.New("非法参数")
+	}
+	effort := s.EffortMinutes
+	if req.EffortMinutes != nil {
+		if effort = *req.EffortMinutes; effort < 0 || effort > schedule.EffortMaxMinutes {
+			return nil, fmt.Errorf("预计耗时取值范围为 0-%d 分钟", schedule.EffortMaxMinutes)
+		}
+	}
+	notes := s.Notes
+	if req.Notes != nil {
+		if utf8.RuneCountInString(*req.Notes) > schedule.NotesMaxLen {
+			return nil, fmt.Errorf("备注长度不能超过 %d", schedule.NotesMaxLen)
+		}
+		notes = *req.Notes
+	}
+
+	updated := *s
+	updated.Year, updated.Month, updated.Day = int16(req.Year), int8(req.Month), int8(req.Day)
+	updated.StartTime, updated.EndTime = start, end
+	updated.Content, updated.Notes = req.Content, notes
+	updated.Priority, updated.Status = int8(req.Priority), req.Status
+	updated.UpdateAt = time.Now()
+	updated.Kind, updated.DueAt, updated.EffortMinutes, updated.Unscheduled = req.Kind, due, effort, unscheduled
+	if unscheduled {
+		updated.SyncDate()
+	}
+	if req.Location != nil {
+		req.Location.ApplyTo(&updated)
+	}
+	if err = updated.ValidateLocation(); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete 删除日程
+// @Summary      删除日程
+// @Description  删除指定日程, 删除前的数据会保留在变更历史中
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.DeleteReq  true  "删除参数"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/delete [post]
+func Delete(c *gin.Context) {
+	req := schedule.DeleteReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		system.Failed("非法参数", c)
 		return
 	}
-	s, err := ScheduleDao.GetScheduleByID(int64(req.ID))
-	if err != nil {
+	req.UserID = middleware.UserID(c)
+	s, err := ScheduleDao.GetScheduleByID(req.ID)
+	if err != nil || s == nil || s.UserID != req.UserID {
 		system.Failed("日程不存在", c)
 		return
 	}
-	if s == nil {
-		system.Failed("日程不存在", c)
+	if !versionMatched(c, s, req.Version) {
+		scheduleConflict(s, c)
 		return
 	}
-	err = ScheduleDao.UpdateSchedule(&schedule.Schedule{
-		ID:        int64(req.ID),
-		UserID:    req.UserID,
-		Year:      int16(req.Year),
-		Month:     int8(req.Month),
-		Day:       int8(req.Day),
-		StartTime: start,
-		EndTime:   end,
-		Content:   req.Content,
-		Priority:  int8(req.Priority),
-		Status:    req.Status,
-		CreateAt:  s.CreateAt,
-		UpdateAt:  time.Now(),
+	err = ScheduleDao.OperationTransaction(req.UserID, schedule.OpDelete, func(tx *dao.ScheduleDao) error {
+		if err := tx.DeleteScheduleVersion(s.ID, s.Version); err != nil {
+			return err
+		}
+		return tx.RecordHistory(schedule.HistoryActionDelete, req.UserID, s, nil)
 	})
+	if errors.Is(err, dao.ErrVersionConflict) {
+		scheduleConflictByID(s.ID, c)
+		return
+	}
 	if err != nil {
 		system.Failed(err.Error(), c)
 		return
 	}
 
 	system.Success(nil, "ok", c)
-
 }
 
 func stringToTimeStandard(timeStr string) (time.Time, error) {
@@ -188,3 +424,63 @@ func stringToTimeStandard(timeStr string) (time.Time, error) {
 
 	return t, nil
 }
+
+// pageInfo 将请求中的分页参数转换为分页信息
+func pageInfo(page, size int, cursor, sort, order string) dao.PageInfo {
+	return dao.PageInfo{
+		Current:  page,
+		PageSize: size,
+		Cursor:   cursor,
+		SortBy:   sort,
+		Desc:     strings.EqualFold(order, "desc"),
+	}
+}
+
+// scheduleETag 根据日程ID与版本号生成 ETag
+func scheduleETag(s *schedule.Schedule) string {
+	return fmt.Sprintf(`"%d-%d"`, s.ID, s.Version)
+}
+
+// etagMatched 判断 If-Match / If-None-Match 头中是否包含指定 ETag
+func etagMatched(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// versionMatched 校验客户端持有的版本, 优先使用 If-Match 头, 其次为请求体中的版本号, 均未提供时不做校验
+func versionMatched(c *gin.Context, s *schedule.Schedule, version int) bool {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		return etagMatched(ifMatch, scheduleETag(s))
+	}
+	if version > 0 {
+		return version == s.Version
+	}
+	return true
+}
+
+// canViewSchedule 当前用户是否可以查看 ownerID 的日程: 本人、管理员或所在团队的负责人
+func canViewSchedule(c *gin.Context, ownerID int64) bool {
+	ok, err := auth.CanViewSchedulesOf(middleware.UserID(c), ownerID)
+	return err == nil && ok
+}
+
+// scheduleConflict 返回 409 以及服务端当前的日程数据
+func scheduleConflict(current *schedule.Schedule, c *gin.Context) {
+	c.Header("ETag", scheduleETag(current))
+	system.Conflict(current, dao.ErrVersionConflict.Error(), c)
+}
+
+// scheduleConflictByID 重新读取日程后返回 409, 日程已被删除时返回失败
+func scheduleConflictByID(id int64, c *gin.Context) {
+	current, err := ScheduleDao.GetScheduleByID(id)
+	if err != nil || current == nil {
+		system.Failed("日程不存在", c)
+		return
+	}
+	scheduleConflict(current, c)
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"go-film-demo/model/schedule"
+	"testing"
+	"time"
+)
+
+func TestBuildScheduleUpdateKeepsBulkTags(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	s := schedule.Schedule{
+		ID: 7, UserID: 1, Version: 2, Content: "评审", Status: schedule.StatusNotStarted,
+		StartTime: start, EndTime: start.Add(time.Hour), Kind: schedule.KindTask, RolloverCount: 3,
+	}
+	s.SyncDate()
+
+	// 先通过批量操作添加标签, 再通过更新接口修改内容
+	applyBulkChange(&s, schedule.BulkOps{AddTags: []string{"工作", "重要"}})
+	updated, err := buildScheduleUpdate(&s, schedule.UpdateReq{
+		ID: 7, Year: 2026, Month: 5, Day: 1, Status: schedule.StatusNotStarted,
+		Start: "2026-05-01 10:00:00", End: "2026-05-01 11:00:00", Content: "评审(改期)", UserID: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Tags != "工作,重要" {
+		t.Errorf("更新后标签 = %q, 批量添加的标签不应被清空", updated.Tags)
+	}
+	if updated.ID != 7 || updated.UserID != 1 || updated.Version != 2 || updated.RolloverCount != 3 ||
+		updated.Kind != schedule.KindTask || updated.Content != "评审(改期)" {
+		t.Errorf("updated = %+v", updated)
+	}
+	if _, changed := schedule.Diff(&s, updated)["tags"]; changed {
+		t.Error("历史差异不应包含标签变化")
+	}
+	if s.Content != "评审" {
+		t.Error("不应修改原日程")
+	}
+}
@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dateTimeFormat 错误提示中使用的时间格式
+const dateTimeFormat = "2006-01-02 15:04:05"
+
+// AddDependency 添加日程依赖
+// @Summary      添加日程依赖
+// @Description  添加完成-开始依赖: 后置日程必须在前置日程结束后开始, 会拒绝循环依赖以及当前已冲突的时间安排
+// @Tags         日程依赖
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.DependencyReq  true  "依赖参数"
+// @Success      200      {object}  system.Response{data=schedule.ScheduleDependency}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/dependency/add [post]
+func AddDependency(c *gin.Context) {
+	req := schedule.DependencyReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.PredecessorID == req.SuccessorID {
+		system.Failed("日程不能依赖自身", c)
+		return
+	}
+
+	predecessor, err := ScheduleDao.GetScheduleByID(req.PredecessorID)
+	if err != nil || predecessor == nil || predecessor.UserID != req.UserID {
+		system.Failed("前置日程不存在", c)
+		return
+	}
+	successor, err := ScheduleDao.GetScheduleByID(req.SuccessorID)
+	if err != nil || successor == nil || successor.UserID != req.UserID {
+		system.Failed("后置日程不存在", c)
+		return
+	}
+	if successor.StartTime.Before(predecessor.EndTime) {
+		system.Failed(fmt.Sprintf("后置日程开始时间 %s 早于前置日程结束时间 %s",
+			successor.StartTime.Format(dateTimeFormat), predecessor.EndTime.Format(dateTimeFormat)), c)
+		return
+	}
+
+	dep := &schedule.ScheduleDependency{
+		PredecessorID: req.PredecessorID,
+		SuccessorID:   req.SuccessorID,
+		UserID:        req.UserID,
+	}
+	err = ScheduleDao.Transaction(func(tx *dao.ScheduleDao) error {
+		// 锁定两端的日程, 同时添加 A→B 与 B→A 的请求依次检测, 后执行的请求能看到先提交的依赖;
+		// 更长的环由 DependencyReachable 的共享锁阻止, 并发冲突时数据库回滚其中一个请求
+		locked, err := tx.GetSchedulesByIDs([]int64{req.PredecessorID, req.SuccessorID})
+		if err != nil {
+			return err
+		}
+		if len(locked) != 2 {
+			return fmt.Errorf("日程不存在")
+		}
+		cycle, err := tx.DependencyReachable(req.SuccessorID, req.PredecessorID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return fmt.Errorf("添加该依赖会形成循环依赖")
+		}
+		return tx.CreateDependency(dep)
+	})
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dep, "ok", c)
+}
+
+// RemoveDependency 删除日程依赖
+// @Summary      删除日程依赖
+// @Description  删除两个日程之间的依赖关系
+// @Tags         日程依赖
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.DependencyReq  true  "依赖参数"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/dependency/remove [post]
+func RemoveDependency(c *gin.Context) {
+	req := schedule.DependencyReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	successor, err := ScheduleDao.GetScheduleByID(req.SuccessorID)
+	if err != nil || successor == nil || successor.UserID != req.UserID {
+		system.Failed("后置日程不存在", c)
+		return
+	}
+	if err = ScheduleDao.DeleteDependency(req.PredecessorID, req.SuccessorID); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(nil, "ok", c)
+}
+
+// Dependencies 查询日程的前置与后置日程
+// @Summary      查询日程依赖
+// @Description  查询指定日程的直接前置日程与直接后置日程
+// @Tags         日程依赖
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.DependencyListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=schedule.DependencyListResp}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/dependency/list [post]
+func Dependencies(c *gin.Context) {
+	req := schedule.DependencyListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	s, err := ScheduleDao.GetScheduleByID(req.ID)
+	if err != nil || s == nil || !canViewSchedule(c, s.UserID) {
+		system.Failed("日程不存在", c)
+		return
+	}
+	predecessors, err := ScheduleDao.ListPredecessors(req.ID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	successors, err := ScheduleDao.ListSuccessors(req.ID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(schedule.DependencyListResp{Predecessors: predecessors, Successors: successors}, "ok", c)
+}
+
+// rescheduleDependents 日程时间变更后校验依赖, cascade 为 true 时顺延全部下游日程, 否则下游冲突时返回错误
+func rescheduleDependents(tx *dao.ScheduleDao, actorID int64, before, after *schedule.Schedule, cascade bool) error {
+	if before.StartTime.Equal(after.StartTime) && before.EndTime.Equal(after.EndTime) {
+		return nil
+	}
+
+	predecessors, err := tx.ListPredecessors(after.ID)
+	if err != nil {
+		return err
+	}
+	for _, p := range predecessors {
+		if after.StartTime.Before(p.EndTime) {
+			return fmt.Errorf("开始时间不能早于前置日程 %d 的结束时间 %s", p.ID, p.EndTime.Format(dateTimeFormat))
+		}
+	}
+
+	if !cascade {
+		successors, err := tx.ListSuccessors(after.ID)
+		if err != nil {
+			return err
+		}
+		for _, s := range successors {
+			if s.StartTime.Before(after.EndTime) {
+				return fmt.Errorf("结束时间晚于后置日程 %d 的开始时间 %s, 可开启 cascade 自动顺延后置日程",
+					s.ID, s.StartTime.Format(dateTimeFormat))
+			}
+		}
+		return nil
+	}
+	return cascadeReschedule(tx, actorID, after, after.EndTime.Sub(before.EndTime))
+}
+
+// cascadeReschedule 将下游日程整体平移 delta, 再按拓扑顺序把仍早于前置结束时间的日程推迟到前置结束之后, 保持各日程时长不变
+func cascadeReschedule(tx *dao.ScheduleDao, actorID int64, root *schedule.Schedule, delta time.Duration) error {
+	// 收集下游日程以及子图中的入度
+	nodes := map[int64]*schedule.Schedule{root.ID: root}
+	successorsOf := map[int64][]int64{}
+	inDegree := map[int64]int{}
+	queue := []int64{root.ID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		successors, err := tx.ListSuccessors(id)
+		if err != nil {
+			return err
+		}
+		for i := range successors {
+			s := successors[i]
+			successorsOf[id] = append(successorsOf[id], s.ID)
+			inDegree[s.ID]++
+			if _, ok := nodes[s.ID]; !ok {
+				nodes[s.ID] = &s
+				queue = append(queue, s.ID)
+			}
+		}
+	}
+
+	// 拓扑顺序处理, 依赖关系在添加时已保证无环
+	ready := []int64{root.ID}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		for _, next := range successorsOf[id] {
+			inDegree[next]--
+			if inDegree[next] > 0 {
+				continue
+			}
+			ready = append(ready, next)
+			if err := shiftDependent(tx, actorID, nodes, next, delta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shiftDependent 平移单个下游日程并保证其不早于任何前置日程的结束时间
+func shiftDependent(tx *dao.ScheduleDao, actorID int64, nodes map[int64]*schedule.Schedule, id int64, delta time.Duration) error {
+	before := *nodes[id]
+	newStart := before.StartTime.Add(delta)
+
+	predecessors, err := tx.ListPredecessors(id)
+	if err != nil {
+		return err
+	}
+	for _, p := range predecessors {
+		end := p.EndTime
+		if moved, ok := nodes[p.ID]; ok {
+			end = moved.EndTime
+		}
+		if newStart.Before(end) {
+			newStart = end
+		}
+	}
+
+	shift := newStart.Sub(before.StartTime)
+	if shift == 0 {
+		return nil
+	}
+	after := before
+	after.StartTime = before.StartTime.Add(shift)
+	after.EndTime = before.EndTime.Add(shift)
+	after.SyncDate()
+	after.UpdateAt = time.Now()
+	if err = tx.UpdateSchedule(&after); err != nil {
+		return err
+	}
+	nodes[id] = &after
+	return tx.RecordHistory(schedule.HistoryActionUpdate, actorID, &before, &after)
+}
@@ -0,0 +1,514 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"go-film-demo/plugin/sheet"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	exportMaxRows  = 10000   // 单次导出的最大日程数
+	importMaxRows  = 5000    // 单次导入的最大数据行数
+	importMaxBytes = 5 << 20 // 导入文件大小上限
+	xlsxMIME       = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// scheduleColumns 导出/导入的列, 导入时填写 id 与 version 的行更新已有日程
+var scheduleColumns = []string{"id", "version", "start_time", "end_time", "content", "priority", "status", "tags", "notes",
+	"location", "address", "latitude", "longitude", "meeting_url", "kind", "due", "effort_minutes"}
+
+// Export 导出日程
+// @Summary      导出日程
+// @Description  按筛选条件导出日程为 CSV 或 XLSX, 列为 id,version,start_time,end_time,content,priority,status,tags,notes,
+// @Description  location,address,latitude,longitude,meeting_url,kind,due,effort_minutes; 未安排时间段的任务开始/结束时间为空;
+// @Description  以 = + - @ 开头的文本前会加单引号, 防止在表格软件中被当作公式执行
+// @Tags         日程管理
+// @Accept       json
+// @Produce      text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        request  body      schedule.ExportReq  true  "导出参数"
+// @Success      200      {file}    file
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/export [post]
+func Export(c *gin.Context) {
+	req := schedule.ExportReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if req.Format != "csv" && req.Format != "xlsx" {
+		system.Failed("format 取值为 csv 或 xlsx", c)
+		return
+	}
+	vo, err := bulkFilterVo(req.UserID, &req.Filter)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	list, err := ScheduleDao.ScheduleListLimit(vo, exportMaxRows+1, false)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if len(list) > exportMaxRows {
+		system.Failed(fmt.Sprintf("导出结果超过 %d 条, 请缩小筛选范围", exportMaxRows), c)
+		return
+	}
+
+	rows := make([][]string, 0, len(list))
+	for _, s := range list {
+		rows = append(rows, scheduleRow(&s))
+	}
+	var buf bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == "xlsx" {
+		contentType = xlsxMIME
+		err = sheet.WriteXLSX(&buf, "schedule", scheduleColumns, rows)
+	} else {
+		err = sheet.WriteCSV(&buf, scheduleColumns, rows)
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	filename := fmt.Sprintf("schedule-%s.%s", time.Now().Format("20060102150405"), req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// scheduleRow 将日程转换为导出行
+func scheduleRow(s *schedule.Schedule) []string {
+	start, end, due := s.StartTime.Format(dateTimeFormat), s.EndTime.Format(dateTimeFormat), ""
+	if s.Unscheduled {
+		start, end = "", ""
+	}
+	if s.DueAt != nil {
+		due = s.DueAt.Format(dateTimeFormat)
+	}
+	return []string{
+		strconv.FormatInt(s.ID, 10),
+		strconv.Itoa(s.Version),
+		start,
+		end,
+		sheet.EscapeFormula(s.Content),
+		strconv.Itoa(int(s.Priority)),
+		strconv.Itoa(s.Status),
+		sheet.EscapeFormula(s.Tags),
+		sheet.EscapeFormula(s.Notes),
+		sheet.EscapeFormula(s.Location),
+		sheet.EscapeFormula(s.Address),
+		formatCoordinate(s.Latitude),
+		formatCoordinate(s.Longitude),
+		sheet.EscapeFormula(s.MeetingURL),
+		s.Kind,
+		due,
+		strconv.Itoa(s.EffortMinutes),
+	}
+}
+
+// formatCoordinate 格式化经纬度, 未设置时为空
+func formatCoordinate(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+// Import 导入日程
+// @Summary      导入日程
+// @Description  上传 CSV 或 XLSX 文件批量创建或更新日程, 第一行为表头(start_time,end_time,content 必填, id,version,priority,status,tags,notes,kind,due,effort_minutes 与地点列可选);
+// @Description  kind 为 task 且开始/结束时间都为空时导入为未安排时间段的任务;
+// @Description  id 为空的行创建新日程, 填写 id 的行更新自己的日程, 需同时填写导出时的 version, 版本不一致时报错;
+// @Description  逐行校验并返回行级错误, 任意一行有误则不写入; dry_run=true 时只校验不写入; 全部日程在同一事务中写入
+// @Tags         日程管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "CSV 或 XLSX 文件"
+// @Param        dry_run  formData  bool    false  "是否只校验"
+// @Success      200      {object}  system.Response{data=schedule.ImportResult}
+// @Failure      500      {object}  system.Response{data=schedule.ImportResult}
+// @Security     BearerAuth
+// @Router       /schedule/import [post]
+func Import(c *gin.Context) {
+	userID := middleware.UserID(c)
+	dryRun := c.PostForm("dry_run") == "true"
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		system.Failed("请上传文件", c)
+		return
+	}
+	if header.Size > importMaxBytes {
+		system.Failed(fmt.Sprintf("文件大小不能超过 %dMB", importMaxBytes>>20), c)
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, importMaxBytes))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	// 表头加上数据行, 超过上限时停止解析
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		rows, err = sheet.ReadCSV(data, importMaxRows+1)
+	case ".xlsx":
+		rows, err = sheet.ReadXLSX(data, importMaxRows+1)
+	default:
+		system.Failed("只支持 .csv 或 .xlsx 文件", c)
+		return
+	}
+	if errors.Is(err, sheet.ErrTooManyRows) {
+		system.Failed(fmt.Sprintf("单次最多导入 %d 行", importMaxRows), c)
+		return
+	}
+	if err != nil {
+		system.Failed(fmt.Sprintf("文件解析失败: %v", err), c)
+		return
+	}
+
+	columns, err := importColumns(rows)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	// 在同一事务中锁定要更新的日程, 校验归属与版本后再写入; 校验失败或试运行时回滚
+	var result *schedule.ImportResult
+	err = ScheduleDao.OperationTransaction(userID, schedule.OpImport, func(tx *dao.ScheduleDao) error {
+		current := make(map[int64]*schedule.Schedule)
+		if ids := importIDs(rows, columns); len(ids) > 0 {
+			locked, err := tx.GetSchedulesByIDs(ids)
+			if err != nil {
+				return err
+			}
+			for i := range locked {
+				current[locked[i].ID] = &locked[i]
+			}
+		}
+		list, parsed, err := parseImportRows(userID, rows, columns, current)
+		if err != nil {
+			return err
+		}
+		if result = parsed; len(result.Errors) > 0 || dryRun {
+			return errImportRollback
+		}
+
+		for i := range list {
+			s := &list[i]
+			if s.ID == 0 {
+				if err := tx.CreateSchedule(s); err != nil {
+					return err
+				}
+				if err := tx.RecordHistory(schedule.HistoryActionCreate, userID, nil, s); err != nil {
+					return err
+				}
+				result.Created++
+				continue
+			}
+			before := current[s.ID]
+			if err := tx.UpdateSchedule(s); err != nil {
+				return err
+			}
+			if err := tx.RecordHistory(schedule.HistoryActionUpdate, userID, before, s); err != nil {
+				return err
+			}
+			if err := rescheduleDependents(tx, userID, before, s, false); err != nil {
+				return fmt.Errorf("第 %d 行: %w", importLine(rows, s.ID, columns), err)
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		if errors.Is(err, dao.ErrVersionConflict) {
+			err = errors.New("日程已被修改, 请重新导出后再导入")
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	result.DryRun = dryRun
+	if len(result.Errors) > 0 {
+		system.FailedWithData(result, "导入数据校验失败", c)
+		return
+	}
+	if dryRun {
+		system.Success(result, "校验通过", c)
+		return
+	}
+	system.Success(result, "ok", c)
+}
+
+// errImportRollback 校验失败或试运行时回滚导入事务
+var errImportRollback = errors.New("import rollback")
+
+// importColumns 解析表头, 返回列名到列序号的映射
+func importColumns(rows [][]string) (map[string]int, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("文件为空")
+	}
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"start_time", "end_time", "content"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("缺少必填列: %s", required)
+		}
+	}
+	return columns, nil
+}
+
+// importCell 读取行中指定列的值, 列不存在时返回空字符串
+func importCell(row []string, columns map[string]int, name string) string {
+	if idx, ok := columns[name]; ok && idx < len(row) {
+		return strings.TrimSpace(row[idx])
+	}
+	return ""
+}
+
+// importIDs 收集数据行中填写的日程ID
+func importIDs(rows [][]string, columns map[string]int) []int64 {
+	var ids []int64
+	for _, row := range rows[1:] {
+		if id, err := strconv.ParseInt(importCell(row, columns, "id"), 10, 64); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// importLine 返回日程ID所在的行号
+func importLine(rows [][]string, id int64, columns map[string]int) int {
+	v := strconv.FormatInt(id, 10)
+	for i, row := range rows[1:] {
+		if importCell(row, columns, "id") == v {
+			return i + 2
+		}
+	}
+	return 0
+}
+
+// parseImportRows 逐行校验导入数据; id 为空的行创建新日程, 填写 id 的行更新 current 中的日程,
+// 要求日程属于当前用户且 version 与当前版本一致, 未出现在表头中的列保留原值
+func parseImportRows(userID int64, rows [][]string, columns map[string]int, current map[int64]*schedule.Schedule) ([]schedule.Schedule, *schedule.ImportResult, error) {
+	has := func(name string) bool {
+		_, ok := columns[name]
+		return ok
+	}
+	result := &schedule.ImportResult{Errors: []schedule.ImportError{}}
+	list := make([]schedule.Schedule, 0, len(rows)-1)
+	seen := make(map[int64]bool)
+	for i, row := range rows[1:] {
+		if isBlankRow(row) {
+			continue
+		}
+		result.Total++
+		if result.Total > importMaxRows {
+			return nil, nil, fmt.Errorf("单次最多导入 %d 行", importMaxRows)
+		}
+		line := i + 2
+		cell := func(name string) string {
+			return importCell(row, columns, name)
+		}
+		fail := func(column, message string) {
+			result.Errors = append(result.Errors, schedule.ImportError{Line: line, Column: column, Message: message})
+		}
+
+		s := schedule.Schedule{UserID: userID, Status: schedule.StatusNotStarted, Kind: schedule.KindEvent, Version: 1, CreateAt: time.Now()}
+		if v := cell("id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			cur := current[id]
+			switch {
+			case err != nil || id <= 0:
+				fail("id", "日程ID格式错误")
+				continue
+			case cur == nil || cur.UserID != userID:
+				fail("id", "日程不存在")
+				continue
+			case seen[id]:
+				fail("id", "日程ID重复")
+				continue
+			}
+			seen[id] = true
+			if version, err := strconv.Atoi(cell("version")); err != nil {
+				fail("version", "更新已有日程时必须填写导出时的 version")
+			} else if version != cur.Version {
+				fail("version", fmt.Sprintf("日程已被修改, 当前版本为 %d, 请重新导出", cur.Version))
+			}
+			s = *cur
+		}
+
+		if v := cell("kind"); v != "" {
+			if !schedule.ValidKind(v) {
+				fail("kind", "取值为 event 或 task")
+			} else {
+				s.Kind = v
+			}
+		}
+		if has("due") {
+			if due, err := parseDue(cell("due")); err != nil {
+				fail("due", err.Error())
+			} else {
+				s.DueAt = due
+			}
+		}
+		if has("effort_minutes") {
+			s.EffortMinutes = 0
+			if v := cell("effort_minutes"); v != "" {
+				if effort, err := strconv.Atoi(v); err != nil || effort < 0 || effort > schedule.EffortMaxMinutes {
+					fail("effort_minutes", fmt.Sprintf("取值范围为 0-%d 分钟", schedule.EffortMaxMinutes))
+				} else {
+					s.EffortMinutes = effort
+				}
+			}
+		}
+
+		if startStr, endStr := cell("start_time"), cell("end_time"); s.Kind == schedule.KindTask && startStr == "" && endStr == "" {
+			// 未安排时间段的任务以截止时间占位, 没有截止时间时新任务使用当前时间, 已有任务沿用原开始时间
+			placeholder := s.StartTime
+			if s.ID == 0 {
+				placeholder = s.CreateAt
+			}
+			if s.DueAt != nil {
+				placeholder = *s.DueAt
+			}
+			s.StartTime, s.EndTime, s.Unscheduled = placeholder, placeholder, true
+		} else {
+			var startOK, endOK bool
+			if s.StartTime, startOK = parseImportTime(startStr); !startOK {
+				fail("start_time", "时间格式错误, 期望格式: YYYY-MM-DD HH:MM:SS")
+			}
+			if s.EndTime, endOK = parseImportTime(endStr); !endOK {
+				fail("end_time", "时间格式错误, 期望格式: YYYY-MM-DD HH:MM:SS")
+			}
+			if startOK && endOK && s.EndTime.Before(s.StartTime) {
+				fail("end_time", "结束时间不能早于开始时间")
+			}
+			s.Unscheduled = false
+		}
+		s.Content = sheet.UnescapeFormula(cell("content"))
+		if s.Content == "" {
+			fail("content", "内容不能为空")
+		} else if utf8.RuneCountInString(s.Content) > schedule.ContentMaxLen {
+			fail("content", fmt.Sprintf("长度不能超过 %d", schedule.ContentMaxLen))
+		}
+		if v := cell("priority"); v != "" {
+			if p, err := strconv.Atoi(v); err != nil || !schedule.ValidPriority(p) {
+				fail("priority", "取值范围为 0-2")
+			} else {
+				s.Priority = int8(p)
+			}
+		}
+		if v := cell("status"); v != "" {
+			if st, err := strconv.Atoi(v); err != nil || !schedule.ValidStatus(st) {
+				fail("status", "取值范围为 1-4")
+			} else {
+				s.Status = st
+			}
+		}
+		if has("notes") {
+			s.Notes = sheet.UnescapeFormula(cell("notes"))
+			if utf8.RuneCountInString(s.Notes) > schedule.NotesMaxLen {
+				fail("notes", fmt.Sprintf("长度不能超过 %d", schedule.NotesMaxLen))
+			}
+		}
+		if has("tags") {
+			s.SetTags(strings.FieldsFunc(sheet.UnescapeFormula(cell("tags")), func(r rune) bool { return r == ',' || r == '，' || r == ';' }))
+		}
+		if has("location") || has("address") || has("latitude") || has("longitude") || has("meeting_url") {
+			loc := schedule.LocationReq{Name: s.Location, Address: s.Address, Latitude: s.Latitude, Longitude: s.Longitude, MeetingURL: s.MeetingURL}
+			if has("location") {
+				loc.Name = sheet.UnescapeFormula(cell("location"))
+			}
+			if has("address") {
+				loc.Address = sheet.UnescapeFormula(cell("address"))
+			}
+			if has("meeting_url") {
+				loc.MeetingURL = sheet.UnescapeFormula(cell("meeting_url"))
+			}
+			coordinatesOK := true
+			if has("latitude") {
+				if loc.Latitude, coordinatesOK = parseCoordinate(cell("latitude")); !coordinatesOK {
+					fail("latitude", "必须为数字")
+				}
+			}
+			if has("longitude") {
+				var ok bool
+				if loc.Longitude, ok = parseCoordinate(cell("longitude")); !ok {
+					fail("longitude", "必须为数字")
+					coordinatesOK = false
+				}
+			}
+			loc.ApplyTo(&s)
+			if err := s.ValidateLocation(); err != nil && coordinatesOK {
+				fail("location", err.Error())
+			}
+		}
+		s.SyncDate()
+		s.UpdateAt = time.Now()
+		list = append(list, s)
+	}
+	return list, result, nil
+}
+
+// parseCoordinate 解析导入的经纬度, 为空时返回 nil
+func parseCoordinate(value string) (*float64, bool) {
+	if value == "" {
+		return nil, true
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &f, true
+}
+
+// parseImportTime 解析导入的时间, 支持 YYYY-MM-DD HH:MM[:SS]、RFC3339 以及 Excel 日期序列号
+func parseImportTime(value string) (time.Time, bool) {
+	for _, layout := range []string{dateTimeFormat, "2006-01-02 15:04", "2006/01/02 15:04:05", "2006/01/02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(time.Local), true
+	}
+	return sheet.ParseExcelTime(value, time.Local)
+}
+
+// isBlankRow 判断是否为空行
+func isBlankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"go-film-demo/model/schedule"
+	"go-film-demo/plugin/sheet"
+	"testing"
+	"time"
+)
+
+func TestParseImportRowsUpdatesByID(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	current := map[int64]*schedule.Schedule{
+		7: {ID: 7, UserID: 1, Version: 3, Kind: schedule.KindTask, Content: "旧内容", Notes: "保留", StartTime: start, EndTime: start.Add(time.Hour)},
+		8: {ID: 8, UserID: 2, Version: 1, Content: "别人的日程"},
+	}
+	rows := [][]string{
+		{"id", "version", "start_time", "end_time", "content"},
+		{"", "", "2026-05-02 09:00:00", "2026-05-02 10:00:00", "新建"},
+		{"7", "3", "2026-05-03 09:00:00", "2026-05-03 10:00:00", "'=1+1"},
+		{"7", "3", "2026-05-03 09:00:00", "2026-05-03 10:00:00", "重复"},
+		{"8", "1", "2026-05-03 09:00:00", "2026-05-03 10:00:00", "越权"},
+		{"9", "1", "2026-05-03 09:00:00", "2026-05-03 10:00:00", "不存在"},
+	}
+	columns, err := importColumns(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, result, err := parseImportRows(1, rows, columns, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 || list[0].ID != 0 || list[1].ID != 7 {
+		t.Fatalf("list = %+v", list)
+	}
+	updated := list[1]
+	if updated.Content != "=1+1" || updated.Notes != "保留" || updated.Kind != schedule.KindTask || updated.Version != 3 {
+		t.Errorf("updated = %+v, want escaped content restored and other fields kept", updated)
+	}
+	if current[7].Content != "旧内容" {
+		t.Errorf("current schedule modified while parsing")
+	}
+
+	want := map[int]string{4: "id", 5: "id", 6: "id"}
+	if len(result.Errors) != len(want) {
+		t.Fatalf("errors = %+v", result.Errors)
+	}
+	for _, e := range result.Errors {
+		if want[e.Line] != e.Column {
+			t.Errorf("unexpected error %+v", e)
+		}
+	}
+}
+
+func TestParseImportRowsVersionMismatch(t *testing.T) {
+	current := map[int64]*schedule.Schedule{7: {ID: 7, UserID: 1, Version: 4}}
+	for _, version := range []string{"3", ""} {
+		rows := [][]string{
+			{"id", "version", "start_time", "end_time", "content"},
+			{"7", version, "2026-05-03 09:00:00", "2026-05-03 10:00:00", "内容"},
+		}
+		columns, _ := importColumns(rows)
+		_, result, err := parseImportRows(1, rows, columns, current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Column != "version" {
+			t.Errorf("version %q: errors = %+v, want a version error", version, result.Errors)
+		}
+	}
+}
+
+func TestScheduleRowEscapesFormulas(t *testing.T) {
+	s := &schedule.Schedule{Content: "=HYPERLINK(\"http://x\")", Tags: "+a", Notes: "@b"}
+	row := scheduleRow(s)
+	for _, name := range []string{"content", "tags", "notes"} {
+		v := exportCell(row, name)
+		if v[0] != '\'' {
+			t.Errorf("%s = %q, want a quote prefix", name, v)
+		}
+		if sheet.UnescapeFormula(v)[0] == '\'' {
+			t.Errorf("%s does not round trip", name)
+		}
+	}
+}
+
+func TestParseImportRowsLocation(t *testing.T) {
+	lat := 31.2
+	current := map[int64]*schedule.Schedule{
+		7: {ID: 7, UserID: 1, Version: 1, Location: "旧地点", Latitude: &lat, Longitude: &lat},
+	}
+	rows := [][]string{
+		{"id", "version", "start_time", "end_time", "content", "location", "address", "latitude", "longitude", "meeting_url"},
+		{"", "", "2026-05-02 09:00:00", "2026-05-02 10:00:00", "会议", "会议室A", "'=地址", "39.9", "116.4", "https://meet.example.com/x"},
+		{"7", "1", "2026-05-02 09:00:00", "2026-05-02 10:00:00", "线上", "", "", "", "", ""},
+		{"", "", "2026-05-02 09:00:00", "2026-05-02 10:00:00", "坐标不全", "", "", "39.9", "", ""},
+		{"", "", "2026-05-02 09:00:00", "2026-05-02 10:00:00", "坐标错误", "", "", "abc", "1", ""},
+	}
+	columns, _ := importColumns(rows)
+	list, result, err := parseImportRows(1, rows, columns, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := list[0]
+	if created.Location != "会议室A" || created.Address != "=地址" || created.Latitude == nil || *created.Longitude != 116.4 ||
+		created.MeetingURL != "https://meet.example.com/x" {
+		t.Errorf("created = %+v", created)
+	}
+	if updated := list[1]; updated.Location != "" || updated.Latitude != nil || updated.Longitude != nil {
+		t.Errorf("updated location not cleared: %+v", updated)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Column != "location" || result.Errors[1].Column != "latitude" {
+		t.Errorf("errors = %+v", result.Errors)
+	}
+
+	lat, lng := 39.9, 116.4
+	row := scheduleRow(&schedule.Schedule{Location: "@home", Latitude: &lat, Longitude: &lng})
+	if exportCell(row, "location") != "'@home" || exportCell(row, "latitude") != "39.9" || exportCell(row, "longitude") != "116.4" {
+		t.Errorf("row = %q", row)
+	}
+}
+
+// exportCell 按列名读取导出行中的值
+func exportCell(row []string, name string) string {
+	for i, column := range scheduleColumns {
+		if column == name {
+			return row[i]
+		}
+	}
+	return ""
+}
+
+func TestParseImportRowsTasks(t *testing.T) {
+	rows := [][]string{
+		{"start_time", "end_time", "content", "kind", "due", "effort_minutes"},
+		{"", "", "写周报", "task", "2026-05-08", "90"},
+		{"2026-05-02 09:00:00", "2026-05-02 10:00:00", "评审", "task", "", ""},
+		{"", "", "缺时间的日程", "event", "", ""},
+		{"", "", "错误", "todo", "5月8日", "-1"},
+	}
+	columns, _ := importColumns(rows)
+	list, result, err := parseImportRows(1, rows, columns, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := list[0]
+	if task.Kind != schedule.KindTask || !task.Unscheduled || task.DueAt == nil || task.EffortMinutes != 90 ||
+		!task.StartTime.Equal(*task.DueAt) || task.DueAt.Format(dateTimeFormat) != "2026-05-08 23:59:59" {
+		t.Errorf("unscheduled task = %+v", task)
+	}
+	if scheduled := list[1]; scheduled.Unscheduled || scheduled.StartTime.Hour() != 9 {
+		t.Errorf("scheduled task = %+v", scheduled)
+	}
+
+	got := map[string]bool{}
+	for _, e := range result.Errors {
+		got[e.Column] = true
+	}
+	for _, column := range []string{"start_time", "end_time", "kind", "due", "effort_minutes"} {
+		if !got[column] {
+			t.Errorf("missing error for %s: %+v", column, result.Errors)
+		}
+	}
+
+	row := scheduleRow(&task)
+	if exportCell(row, "start_time") != "" || exportCell(row, "due") != "2026-05-08 23:59:59" ||
+		exportCell(row, "kind") != "task" || exportCell(row, "effort_minutes") != "90" {
+		t.Errorf("row = %q", row)
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// History 查询日程变更历史
+// @Summary      日程变更历史
+// @Description  查询指定日程的全部历史版本, 包含操作人、操作时间以及字段级变更
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.HistoryReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=[]schedule.ScheduleHistory}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/history [post]
+func History(c *gin.Context) {
+	req := schedule.HistoryReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+
+	list, err := ScheduleDao.ListHistory(req.ID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if owner := historyOwner(list); owner > 0 && !canViewSchedule(c, owner) {
+		system.Failed("日程不存在", c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// Revert 回滚日程到指定历史版本
+// @Summary      回滚日程
+// @Description  将日程恢复为指定历史版本的内容, 已删除的日程会被重新创建, 回滚本身也会记录为新版本
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.RevertReq  true  "回滚参数"
+// @Success      200      {object}  system.Response{data=schedule.Schedule}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/revert [post]
+func Revert(c *gin.Context) {
+	req := schedule.RevertReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+
+	h, err := ScheduleDao.GetHistoryVersion(req.ID, req.Version)
+	if err != nil || h == nil || h.Snapshot == nil || h.Snapshot.UserID != req.UserID {
+		system.Failed(fmt.Sprintf("日程历史版本不存在: %d", req.Version), c)
+		return
+	}
+	if h.Action == schedule.HistoryActionDelete {
+		system.Failed("该版本为删除记录, 请选择删除之前的版本", c)
+		return
+	}
+
+	current, err := ScheduleDao.GetScheduleByID(req.ID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if current != nil && !versionMatched(c, current, 0) {
+		scheduleConflict(current, c)
+		return
+	}
+
+	target := *h.Snapshot
+	target.UpdateAt = time.Now()
+	err = ScheduleDao.OperationTransaction(req.UserID, schedule.OpRevert, func(tx *dao.ScheduleDao) error {
+		if current == nil {
+			if err := tx.CreateSchedule(&target); err != nil {
+				return err
+			}
+		} else {
+			target.CreateAt = current.CreateAt
+			target.Version = current.Version
+			if err := tx.UpdateSchedule(&target); err != nil {
+				return err
+			}
+		}
+		return tx.RecordHistory(schedule.HistoryActionRevert, req.UserID, current, &target)
+	})
+	if errors.Is(err, dao.ErrVersionConflict) {
+		scheduleConflictByID(req.ID, c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	c.Header("ETag", scheduleETag(&target))
+	system.Success(target, "ok", c)
+}
+
+// historyOwner 从历史记录快照中取日程所属用户, 没有历史记录时为0
+func historyOwner(list []schedule.ScheduleHistory) int64 {
+	for _, h := range list {
+		if h.Snapshot != nil {
+			return h.Snapshot.UserID
+		}
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/markdown"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Notes 查询日程备注
+// @Summary      日程备注
+// @Description  返回日程的 Markdown 备注以及服务端渲染并净化后的 HTML (原始 HTML 会被转义, 只保留安全链接);
+// @Description  format=html 时直接返回 HTML 片段
+// @Tags         日程管理
+// @Produce      json,html
+// @Param        id      path      int     true   "日程ID"
+// @Param        format  query     string  false  "json/html, 默认 json"
+// @Success      200     {object}  system.Response{data=schedule.NotesResp}
+// @Failure      500     {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/{id}/notes [get]
+func Notes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	s, err := ScheduleDao.GetScheduleByID(id)
+	if err != nil || s == nil || !canViewSchedule(c, s.UserID) {
+		system.Failed("日程不存在", c)
+		return
+	}
+
+	rendered := markdown.Render(s.Notes)
+	if c.Query("format") == "html" {
+		c.Header("Content-Security-Policy", "default-src 'none'; img-src http: https:; style-src 'unsafe-inline'")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered))
+		return
+	}
+	system.Success(schedule.NotesResp{ID: s.ID, Version: s.Version, Notes: s.Notes, HTML: rendered}, "ok", c)
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// operationMaxSteps 单次撤销/重做的最大操作数
+const operationMaxSteps = 20
+
+// Undo 撤销最近的日程操作
+// @Summary      撤销操作
+// @Description  按操作日志撤销当前用户最近的 N 个创建/更新/删除/批量操作, 涉及的日程在之后被修改过时撤销失败且不产生任何变更
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.OperationReq  true  "撤销参数"
+// @Success      200      {object}  system.Response{data=[]schedule.ScheduleOperation}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/undo [post]
+func Undo(c *gin.Context) {
+	replayOperations(c, true)
+}
+
+// Redo 重做最近撤销的日程操作
+// @Summary      重做操作
+// @Description  重做当前用户最近撤销的 N 个操作, 撤销后产生了新操作时不能再重做
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.OperationReq  true  "重做参数"
+// @Success      200      {object}  system.Response{data=[]schedule.ScheduleOperation}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/redo [post]
+func Redo(c *gin.Context) {
+	replayOperations(c, false)
+}
+
+// replayOperations 处理撤销/重做请求
+func replayOperations(c *gin.Context, undo bool) {
+	req := schedule.OperationReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.Steps <= 0 {
+		req.Steps = 1
+	}
+	if req.Steps > operationMaxSteps {
+		req.Steps = operationMaxSteps
+	}
+
+	var (
+		ops []schedule.ScheduleOperation
+		err error
+	)
+	if undo {
+		ops, err = ScheduleDao.UndoOperations(req.UserID, req.Steps)
+	} else {
+		ops, err = ScheduleDao.RedoOperations(req.UserID, req.Steps)
+	}
+	if errors.Is(err, dao.ErrOperationStale) || errors.Is(err, dao.ErrVersionConflict) {
+		system.Conflict(nil, err.Error(), c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(ops, "ok", c)
+}
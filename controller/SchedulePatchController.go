@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Patch 局部更新日程
+// @Summary      局部更新日程
+// @Description  按 JSON Merge Patch (RFC 7386) 语义只更新请求中出现的字段, 字段为 null 时恢复默认值;
+// @Description  year/month/day 由 start_time 推导, 不允许直接修改; 支持 If-Match 头做版本校验;
+// @Description  时间变化时校验日程依赖, cascade=true 时自动顺延后置日程
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true  "日程ID"
+// @Param        cascade  query     bool               false "是否顺延后置日程"
+// @Param        request  body      map[string]any     true  "需要修改的字段"
+// @Success      200      {object}  system.Response{data=schedule.Schedule}
+// @Failure      409      {object}  system.Response{data=schedule.Schedule}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/{id} [patch]
+func Patch(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	patch := map[string]json.RawMessage{}
+	if err = c.ShouldBindJSON(&patch); err != nil {
+		system.Failed("非法参数, 请求体必须为 JSON 对象", c)
+		return
+	}
+	if len(patch) == 0 {
+		system.Failed("没有需要更新的字段", c)
+		return
+	}
+
+	s, err := ScheduleDao.GetScheduleByID(id)
+	if err != nil || s == nil || s.UserID != middleware.UserID(c) {
+		system.Failed("日程不存在", c)
+		return
+	}
+	if !versionMatched(c, s, 0) {
+		scheduleConflict(s, c)
+		return
+	}
+
+	updates, err := mergeSchedulePatch(s, patch)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	var updated *schedule.Schedule
+	err = ScheduleDao.OperationTransaction(s.UserID, schedule.OpPatch, func(tx *dao.ScheduleDao) error {
+		if err := tx.UpdateScheduleByFields(id, s.Version, updates); err != nil {
+			return err
+		}
+		var err error
+		if updated, err = tx.GetScheduleByID(id); err != nil {
+			return err
+		}
+		if err := tx.RecordHistory(schedule.HistoryActionUpdate, s.UserID, s, updated); err != nil {
+			return err
+		}
+		return rescheduleDependents(tx, s.UserID, s, updated, c.Query("cascade") == "true")
+	})
+	if errors.Is(err, dao.ErrVersionConflict) {
+		scheduleConflictByID(id, c)
+		return
+	}
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	c.Header("ETag", scheduleETag(updated))
+	system.Success(updated, "ok", c)
+}
+
+// mergeSchedulePatch 将 merge patch 合并到日程副本上并校验, 返回需要写入的字段
+func mergeSchedulePatch(s *schedule.Schedule, patch map[string]json.RawMessage) (map[string]interface{}, error) {
+	merged := *s
+	updates := make(map[string]interface{})
+
+	for field, raw := range patch {
+		isNull := string(raw) == "null"
+		switch field {
+		case "start_time", "end_time":
+			if isNull {
+				return nil, fmt.Errorf("%s 不能为空", field)
+			}
+			var str string
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return nil, fmt.Errorf("%s 必须为时间字符串", field)
+			}
+			t, err := parsePatchTime(str)
+			if err != nil {
+				return nil, err
+			}
+			if field == "start_time" {
+				merged.StartTime = t
+			} else {
+				merged.EndTime = t
+			}
+			updates[field] = t
+		case "content":
+			merged.Content = ""
+			if !isNull {
+				if err := json.Unmarshal(raw, &merged.Content); err != nil {
+					return nil, errors.New("content 必须为字符串")
+				}
+			}
+			if utf8.RuneCountInString(merged.Content) > schedule.ContentMaxLen {
+				return nil, fmt.Errorf("content 长度不能超过 %d", schedule.ContentMaxLen)
+			}
+			updates[field] = merged.Content
+		case "notes":
+			merged.Notes = ""
+			if !isNull {
+				if err := json.Unmarshal(raw, &merged.Notes); err != nil {
+					return nil, errors.New("notes 必须为字符串")
+				}
+			}
+			if utf8.RuneCountInString(merged.Notes) > schedule.NotesMaxLen {
+				return nil, fmt.Errorf("notes 长度不能超过 %d", schedule.NotesMaxLen)
+			}
+			updates[field] = merged.Notes
+		case "location", "address", "meeting_url":
+			var value string
+			if !isNull {
+				if err := json.Unmarshal(raw, &value); err != nil {
+					return nil, fmt.Errorf("%s 必须为字符串", field)
+				}
+			}
+			value = strings.TrimSpace(value)
+			switch field {
+			case "location":
+				merged.Location = value
+			case "address":
+				merged.Address = value
+			default:
+				merged.MeetingURL = value
+			}
+			updates[field] = value
+		case "latitude", "longitude":
+			var value *float64
+			if !isNull {
+				if err := json.Unmarshal(raw, &value); err != nil {
+					return nil, fmt.Errorf("%s 必须为数字", field)
+				}
+			}
+			if field == "latitude" {
+				merged.Latitude = value
+			} else {
+				merged.Longitude = value
+			}
+			updates[field] = value
+		case "kind":
+			merged.Kind = schedule.KindEvent
+			if !isNull {
+				if err := json.Unmarshal(raw, &merged.Kind); err != nil || !schedule.ValidKind(merged.Kind) {
+					return nil, errors.New("kind 取值为 event 或 task")
+				}
+			}
+			updates[field] = merged.Kind
+		case "due_at":
+			merged.DueAt = nil
+			if !isNull {
+				var str string
+				if err := json.Unmarshal(raw, &str); err != nil {
+					return nil, errors.New("due_at 必须为时间字符串")
+				}
+				due, err := parseDue(str)
+				if err != nil {
+					return nil, err
+				}
+				merged.DueAt = due
+			}
+			updates[field] = merged.DueAt
+		case "effort_minutes":
+			merged.EffortMinutes = 0
+			if !isNull {
+				if err := json.Unmarshal(raw, &merged.EffortMinutes); err != nil ||
+					merged.EffortMinutes < 0 || merged.EffortMinutes > schedule.EffortMaxMinutes {
+					return nil, fmt.Errorf("effort_minutes 取值范围为 0-%d", schedule.EffortMaxMinutes)
+				}
+			}
+			updates[field] = merged.EffortMinutes
+		case "priority":
+			priority := schedule.PriorityLow
+			if !isNull {
+				if err := json.Unmarshal(raw, &priority); err != nil || !schedule.ValidPriority(priority) {
+					return nil, errors.New("priority 取值范围为 0-2")
+				}
+			}
+			merged.Priority = int8(priority)
+			updates[field] = merged.Priority
+		case "status":
+			status := schedule.StatusNotStarted
+			if !isNull {
+				if err := json.Unmarshal(raw, &status); err != nil || !schedule.ValidStatus(status) {
+					return nil, errors.New("status 取值范围为 1-4")
+				}
+			}
+			merged.Status = status
+			updates[field] = merged.Status
+		case "year", "month", "day":
+			return nil, fmt.Errorf("%s 由 start_time 推导, 不能直接修改", field)
+		default:
+			return nil, fmt.Errorf("不支持修改字段: %s", field)
+		}
+	}
+
+	// 安排了时间段或改为普通日程后不再是未安排的任务, 未安排的任务以截止时间占位
+	_, startChanged := updates["start_time"]
+	_, endChanged := updates["end_time"]
+	if merged.Unscheduled && (startChanged || endChanged || merged.Kind != schedule.KindTask) {
+		merged.Unscheduled = false
+		updates["unscheduled"] = false
+	} else if _, ok := updates["due_at"]; ok && merged.Unscheduled && merged.DueAt != nil {
+		merged.StartTime, merged.EndTime = *merged.DueAt, *merged.DueAt
+		updates["start_time"], updates["end_time"] = merged.StartTime, merged.EndTime
+	}
+
+	if merged.EndTime.Before(merged.StartTime) {
+		return nil, errors.New("结束时间不能早于开始时间")
+	}
+	if err := merged.ValidateLocation(); err != nil {
+		return nil, err
+	}
+	if _, ok := updates["start_time"]; ok {
+		merged.SyncDate()
+		updates["year"] = merged.Year
+		updates["month"] = merged.Month
+		updates["day"] = merged.Day
+	}
+	updates["update_at"] = time.Now()
+	return updates, nil
+}
+
+// parsePatchTime 解析时间字符串, 支持 YYYY-MM-DD HH:MM:SS 以及 RFC3339 格式
+func parsePatchTime(timeStr string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
+		return t.In(time.Local), nil
+	}
+	return stringToTimeStandard(timeStr)
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultChronicCount 默认的长期拖延阈值
+const defaultChronicCount = 3
+
+// Chronic 查询长期拖延的任务
+// @Summary      长期拖延的任务
+// @Description  分页查询顺延次数不少于 min_count (默认3) 且仍未完成的任务, 按顺延次数倒序
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.ChronicReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/rollover/chronic [post]
+func Chronic(c *gin.Context) {
+	req := schedule.ChronicReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.MinCount <= 0 {
+		req.MinCount = defaultChronicCount
+	}
+
+	vo := dao.ScheduleRequestVo{
+		UserID:    req.UserID,
+		Kind:      schedule.KindTask,
+		Rollovers: req.MinCount,
+		Paging:    pageInfo(req.Page, req.Size, "", "rollover_count", "desc"),
+	}
+	list, page, err := ScheduleDao.SchedulePage(vo)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Todo 待办任务列表
+// @Summary      待办任务
+// @Description  分页查询任务(kind=task), 按截止时间升序(无截止时间的排在最后)、优先级降序排列, 默认不包含已完成的任务
+// @Tags         日程管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      schedule.TodoReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /schedule/todo [post]
+func Todo(c *gin.Context) {
+	req := schedule.TodoReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	list, page, err := ScheduleDao.TodoPage(req.UserID, req.IncludeCompleted, dao.PageInfo{Current: req.Page, PageSize: req.Size})
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
+
+// parseDue 解析截止时间, 只有日期时截止到当天结束
+func parseDue(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if day, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		due := day.Add(24*time.Hour - time.Second)
+		return &due, nil
+	}
+	due, err := parsePatchTime(value)
+	if err != nil {
+		return nil, errors.New("截止时间格式错误, 期望格式: YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS")
+	}
+	return &due, nil
+}
+
+// scheduleTimes 解析开始/结束时间; 任务可以不安排时间段, 此时以截止时间(或 fallback)占位并标记为未安排
+func scheduleTimes(kind, startStr, endStr string, due *time.Time, fallback time.Time) (time.Time, time.Time, bool, error) {
+	if kind == schedule.KindTask && startStr == "" && endStr == "" {
+		if due != nil {
+			fallback = *due
+		}
+		return fallback, fallback, true, nil
+	}
+	start, err := stringToTimeStandard(startStr)
+	if err != nil {
+		return start, start, false, err
+	}
+	end, err := stringToTimeStandard(endStr)
+	if err != nil {
+		return start, end, false, err
+	}
+	return start, end, false, nil
+}
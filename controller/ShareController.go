@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/share"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/middleware"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ShareDao = dao.NewShareDao()
+
+// CreateShare 创建分享链接
+// @Summary      创建分享链接
+// @Description  为单个日程(kind=schedule)或按条件筛选的日历视图(kind=calendar)生成只读分享令牌;
+// @Description  redact=true 时公开页面的内容显示为 busy 且不展示优先级; expire_hours 为 0 表示永久有效
+// @Tags         分享链接
+// @Accept       json
+// @Produce      json
+// @Param        request  body      share.StoreReq  true  "分享参数"
+// @Success      200      {object}  system.Response{data=share.ShareLink}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /share/store [post]
+func CreateShare(c *gin.Context) {
+	req := share.StoreReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if req.ExpireHours < 0 {
+		system.Failed("expire_hours 不能为负数", c)
+		return
+	}
+
+	link := &share.ShareLink{UserID: req.UserID, Kind: req.Kind, Redact: req.Redact}
+	switch req.Kind {
+	case share.KindSchedule:
+		s, err := ScheduleDao.GetScheduleByID(req.ScheduleID)
+		if err != nil || s == nil || s.UserID != req.UserID {
+			system.Failed("日程不存在", c)
+			return
+		}
+		link.ScheduleID = s.ID
+	case share.KindCalendar:
+		filter := req.Filter
+		if filter == nil {
+			filter = &share.Filter{}
+		}
+		if _, _, err := calendarRange(filter, time.Now()); err != nil {
+			system.Failed(err.Error(), c)
+			return
+		}
+		if filter.Priority != 0 && !schedule.ValidPriority(filter.Priority) {
+			system.Failed("priority 取值范围为 0-2", c)
+			return
+		}
+		if filter.Status != 0 && !schedule.ValidStatus(filter.Status) {
+			system.Failed("status 取值范围为 1-4", c)
+			return
+		}
+		link.Filter = filter
+	default:
+		system.Failed("kind 取值为 schedule 或 calendar", c)
+		return
+	}
+	if req.ExpireHours > 0 {
+		expireAt := time.Now().Add(time.Duration(req.ExpireHours) * time.Hour)
+		link.ExpireAt = &expireAt
+	}
+	link.Token = newShareToken()
+	link.CreateAt = time.Now()
+	link.UpdateAt = link.CreateAt
+
+	if err := ShareDao.CreateLink(link); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(link, "ok", c)
+}
+
+// RevokeShare 撤销分享链接
+// @Summary      撤销分享链接
+// @Description  撤销后公开链接立即失效
+// @Tags         分享链接
+// @Accept       json
+// @Produce      json
+// @Param        request  body      share.IDReq  true  "分享ID"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /share/revoke [post]
+func RevokeShare(c *gin.Context) {
+	req := share.IDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if err := ShareDao.RevokeLink(req.ID, req.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("分享链接不存在或已撤销", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(nil, "ok", c)
+}
+
+// ListShares 查询分享链接
+// @Summary      查询分享链接
+// @Description  查询用户创建的全部分享链接, 包含已过期与已撤销的链接
+// @Tags         分享链接
+// @Accept       json
+// @Produce      json
+// @Param        request  body      share.ListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=[]share.ShareLink}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /share/list [post]
+func ListShares(c *gin.Context) {
+	req := share.ListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	list, err := ShareDao.ListLinks(req.UserID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// PublicShare 公开访问分享链接
+// @Summary      访问分享链接
+// @Description  无需登录的只读页面, 返回分享的日程或日历视图; 链接过期或撤销后不可访问
+// @Tags         分享链接
+// @Produce      json,text/html
+// @Param        token   path      string  true   "分享令牌"
+// @Param        format  query     string  false  "输出格式 json/html"
+// @Success      200     {object}  system.Response{data=share.PublicView}
+// @Failure      500     {object}  system.Response
+// @Router       /share/{token} [get]
+func PublicShare(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+
+	link, err := ShareDao.GetByToken(c.Param("token"))
+	if err != nil || link == nil || !link.Active(time.Now()) {
+		system.Failed("分享链接不存在或已失效", c)
+		return
+	}
+	view, err := buildPublicView(link, time.Now())
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+
+	if c.Query("format") == "html" {
+		var buf bytes.Buffer
+		if err = shareTemplate.Execute(&buf, view); err != nil {
+			system.Failed(err.Error(), c)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+		return
+	}
+	system.Success(view, "ok", c)
+}
+
+// buildPublicView 查询分享的日程并按隐藏设置转换为公开数据
+func buildPublicView(link *share.ShareLink, now time.Time) (*share.PublicView, error) {
+	view := &share.PublicView{Kind: link.Kind, Redacted: link.Redact, Schedules: []share.PublicSchedule{}}
+	var list []schedule.Schedule
+	switch link.Kind {
+	case share.KindSchedule:
+		s, err := ScheduleDao.GetScheduleByID(link.ScheduleID)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil || s.UserID != link.UserID {
+			return nil, errors.New("分享的日程已删除")
+		}
+		list = append(list, *s)
+	default:
+		filter := link.Filter
+		if filter == nil {
+			filter = &share.Filter{}
+		}
+		begin, end, err := calendarRange(filter, now)
+		if err != nil {
+			return nil, err
+		}
+		view.Begin = begin.Format("2006-01-02")
+		view.End = end.AddDate(0, 0, -1).Format("2006-01-02")
+		list = ScheduleDao.ScheduleList(dao.ScheduleRequestVo{
+			UserID:    link.UserID,
+			BeginTime: begin,
+			EndTime:   end.Add(-time.Second),
+			Priority:  int8(filter.Priority),
+			Status:    filter.Status,
+		})
+	}
+
+	for _, s := range list {
+		item := share.PublicSchedule{StartTime: s.StartTime, EndTime: s.EndTime, Content: share.BusyLabel}
+		if !link.Redact {
+			priority := s.Priority
+			item.Content = s.Content
+			item.Priority = &priority
+		}
+		view.Schedules = append(view.Schedules, item)
+	}
+	return view, nil
+}
+
+// calendarRange 计算日历视图的时间范围 [begin, end)
+func calendarRange(f *share.Filter, now time.Time) (time.Time, time.Time, error) {
+	if f.Begin != "" || f.End != "" {
+		begin, err := time.ParseInLocation("2006-01-02", f.Begin, time.Local)
+		if err != nil {
+			return begin, begin, errors.New("begin 日期格式错误, 期望格式: YYYY-MM-DD")
+		}
+		end, err := time.ParseInLocation("2006-01-02", f.End, time.Local)
+		if err != nil {
+			return begin, end, errors.New("end 日期格式错误, 期望格式: YYYY-MM-DD")
+		}
+		end = end.AddDate(0, 0, 1)
+		if !end.After(begin) || end.Sub(begin) > share.MaxDays*24*time.Hour {
+			return begin, end, fmt.Errorf("日期范围必须在 1-%d 天之间", share.MaxDays)
+		}
+		return begin, end, nil
+	}
+
+	days := f.Days
+	if days == 0 {
+		days = share.DefaultDays
+	}
+	if days < 0 || days > share.MaxDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("days 取值范围为 1-%d", share.MaxDays)
+	}
+	begin := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return begin, begin.AddDate(0, 0, days), nil
+}
+
+// newShareToken 生成不可猜测的分享令牌
+func newShareToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+var shareTemplate = template.Must(template.New("share").Funcs(template.FuncMap{
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"clock":    func(t time.Time) string { return t.Format("15:04") },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>日程分享</title></head>
+<body style="font-family: -apple-system, 'PingFang SC', 'Microsoft YaHei', sans-serif; max-width: 720px; margin: 0 auto; padding: 16px;">
+<h1>{{if eq .Kind "calendar"}}日程安排 {{.Begin}} ~ {{.End}}{{else}}日程分享{{end}}</h1>
+{{if .Schedules}}<table cellpadding="6" style="border-collapse: collapse;">
+<tr><th align="left">时间</th><th align="left">内容</th></tr>{{range .Schedules}}
+<tr><td>{{datetime .StartTime}} - {{clock .EndTime}}</td><td>{{.Content}}</td></tr>{{end}}
+</table>{{else}}<p>该时间段没有日程安排。</p>{{end}}
+</body>
+</html>
+`))
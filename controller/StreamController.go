@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/hub"
+	"go-film-demo/plugin/middleware"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat 心跳间隔, 防止代理因空闲断开连接
+const streamHeartbeat = 25 * time.Second
+
+// StreamTicket 获取 SSE 票据
+// @Summary      获取 SSE 票据
+// @Description  签发有效期 1 分钟、只能用于 GET /stream 的票据, 供无法设置 Authorization 请求头的 EventSource 通过 ticket 查询参数建立连接;
+// @Description  断线后票据过期时需要重新获取
+// @Tags         实时推送
+// @Produce      json
+// @Success      200  {object}  system.Response{data=user.StreamTicketResp}
+// @Failure      401  {object}  system.Response
+// @Security     BearerAuth
+// @Router       /stream/ticket [post]
+func StreamTicket(c *gin.Context) {
+	ticket, err := auth.IssueStreamTicket(middleware.UserID(c))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(ticket, "ok", c)
+}
+
+// Stream 实时推送日程变更与新闻入库事件
+// @Summary      实时事件推送
+// @Description  Server-Sent Events 长连接: 推送用户的日程变更(event: schedule)与新闻入库(event: news)事件;
+// @Description  断线重连时浏览器会携带 Last-Event-ID, 服务端补发之后仍在缓存中的事件
+// @Tags         实时推送
+// @Produce      text/event-stream
+// @Param        ticket  query     string  false  "SSE 票据, EventSource 无法设置请求头时使用, 通过 /stream/ticket 获取"
+// @Param        topics  query     string  false  "订阅主题, 逗号分隔: schedule,news, 默认全部"
+// @Success      200     {string}  string  "事件流"
+// @Failure      500     {object}  system.Response
+// @Security     BearerAuth
+// @Router       /stream [get]
+func Stream(c *gin.Context) {
+	userID := middleware.UserID(c)
+	var topics []string
+	if t := c.Query("topics"); t != "" {
+		for _, topic := range strings.Split(t, ",") {
+			if topic != hub.TopicSchedule && topic != hub.TopicNews {
+				system.Failed(fmt.Sprintf("不支持的主题: %s", topic), c)
+				return
+			}
+			topics = append(topics, topic)
+		}
+	}
+	lastID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+
+	sub := hub.Default.Subscribe(userID, topics, lastID)
+	defer hub.Default.Unsubscribe(sub)
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	w.Flush()
+
+	ticker := time.NewTicker(streamHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e := <-sub.C:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Topic, data)
+			w.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/model/system"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFA 两步验证
+// @Summary      两步验证
+// @Description  登录返回 mfa_required 时, 使用 mfa_token 与验证器中的验证码(或恢复码)换取访问令牌与刷新令牌;
+// @Description  mfa_token 有效期 5 分钟, 同一个 mfa_token 验证失败 5 次后失效
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.MFAVerifyReq  true  "验证参数"
+// @Success      200      {object}  system.Response{data=user.TokenResp}
+// @Failure      401      {object}  system.Response
+// @Router       /auth/2fa/verify [post]
+func VerifyMFA(c *gin.Context) {
+	req := user.MFAVerifyReq{}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		system.Failed("非法参数", c)
+		return
+	}
+	tokens, err := auth.VerifyMFA(req)
+	if err != nil {
+		if errors.Is(err, auth.ErrTOTPInvalid) || errors.Is(err, auth.ErrMFATooManyTries) ||
+			errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrTokenExpired) {
+			system.Unauthorized(err.Error(), c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(tokens, "登录成功", c)
+}
+
+// TOTPStatus 两步验证状态
+// @Summary      两步验证状态
+// @Description  查询当前用户是否启用两步验证、角色是否要求启用以及剩余恢复码数量
+// @Tags         用户认证
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  system.Response{data=user.TOTPStatusResp}
+// @Failure      401  {object}  system.Response
+// @Router       /auth/2fa/status [get]
+func TOTPStatus(c *gin.Context) {
+	u, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	resp := user.TOTPStatusResp{Enabled: u.TOTPEnabled, Required: auth.TOTPRequired(u.Role)}
+	if u.TOTPEnabled {
+		count, err := UserDao.CountRecoveryCodes(u.ID)
+		if err != nil {
+			system.Failed(err.Error(), c)
+			return
+		}
+		resp.RecoveryCodes = count
+	}
+	system.Success(resp, "ok", c)
+}
+
+// SetupTOTP 获取两步验证密钥
+// @Summary      获取两步验证密钥
+// @Description  生成新的 TOTP 密钥(RFC 6238, SHA1/6位/30秒), uri 为 otpauth:// 地址, 由前端渲染为二维码供验证器应用扫描;
+// @Description  扫码后调用 /auth/2fa/enable 提交验证码确认, 确认前重复调用会生成新的密钥
+// @Tags         用户认证
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  system.Response{data=user.TOTPSetupResp}
+// @Failure      500  {object}  system.Response
+// @Router       /auth/2fa/setup [post]
+func SetupTOTP(c *gin.Context) {
+	u, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	resp, err := auth.SetupTOTP(u)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(resp, "ok", c)
+}
+
+// EnableTOTP 启用两步验证
+// @Summary      启用两步验证
+// @Description  提交验证器中的验证码确认绑定, 返回 10 个恢复码(只返回这一次)与新的令牌, 其他设备的刷新令牌全部撤销
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      user.TOTPCodeReq  true  "验证码"
+// @Success      200      {object}  system.Response{data=user.TOTPEnableResp}
+// @Failure      500      {object}  system.Response
+// @Router       /auth/2fa/enable [post]
+func EnableTOTP(c *gin.Context) {
+	req := user.TOTPCodeReq{}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		system.Failed("非法参数", c)
+		return
+	}
+	u, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	resp, err := auth.EnableTOTP(u, req.Code)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(resp, "已启用两步验证, 请妥善保存恢复码", c)
+}
+
+// DisableTOTP 关闭两步验证
+// @Summary      关闭两步验证
+// @Description  校验验证码或恢复码后关闭两步验证并删除恢复码; TOTP_REQUIRED_ROLES 中的角色不能关闭
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      user.TOTPCodeReq  true  "验证码或恢复码"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Router       /auth/2fa/disable [post]
+func DisableTOTP(c *gin.Context) {
+	req := user.TOTPCodeReq{}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		system.Failed("非法参数", c)
+		return
+	}
+	u, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := auth.DisableTOTP(u, req); err != nil {
+		if errors.Is(err, auth.ErrTOTPRequired) {
+			system.Forbidden(err.Error(), c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("已关闭两步验证", c)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary      重新生成恢复码
+// @Description  校验验证码后重新生成 10 个恢复码, 旧的恢复码全部失效
+// @Tags         用户认证
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      user.TOTPCodeReq  true  "验证码"
+// @Success      200      {object}  system.Response{data=[]string}
+// @Failure      500      {object}  system.Response
+// @Router       /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	req := user.TOTPCodeReq{}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		system.Failed("非法参数", c)
+		return
+	}
+	u, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	codes, err := auth.RegenerateRecoveryCodes(u, req.Code)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(codes, "请妥善保存恢复码", c)
+}
+
+// ResetUserTOTP 重置用户两步验证
+// @Summary      重置用户两步验证
+// @Description  为丢失验证器与恢复码的用户关闭两步验证并撤销其全部刷新令牌, 用户下次登录时重新绑定; 需要 user:manage 权限
+// @Tags         系统管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      user.UserIDReq  true  "用户ID"
+// @Success      200      {object}  system.Response
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /admin/user/2fa/reset [post]
+func ResetUserTOTP(c *gin.Context) {
+	req := user.UserIDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if req.UserID == middleware.UserID(c) {
+		system.Failed("不能重置自己的两步验证", c)
+		return
+	}
+	u, err := UserDao.GetUserByID(req.UserID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if u == nil {
+		system.Failed("用户不存在", c)
+		return
+	}
+	if err = auth.ResetTOTP(u.ID); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("ok", c)
+}
+
+// currentUser 查询当前登录用户, 不存在时写入 401 响应
+func currentUser(c *gin.Context) (*user.User, bool) {
+	u, err := UserDao.GetUserByID(middleware.UserID(c))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return nil, false
+	}
+	if u == nil {
+		system.Unauthorized("用户不存在", c)
+		return nil, false
+	}
+	return u, true
+}
@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"errors"
+	"go-film-demo/dao"
+	"go-film-demo/model/system"
+	"go-film-demo/model/team"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/auth"
+	"go-film-demo/plugin/middleware"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var TeamDao = dao.NewTeamDao()
+
+// teamScheduleMaxDays 团队日程单次查询的最大天数
+const teamScheduleMaxDays = 93
+
+// StoreTeam 创建或修改团队
+// @Summary      创建/修改团队
+// @Description  id 为 0 时创建团队, 否则修改团队名称与描述, 需要 team:manage 权限
+// @Tags         团队管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      team.StoreReq  true  "团队信息"
+// @Success      200      {object}  system.Response{data=team.Team}
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/store [post]
+func StoreTeam(c *gin.Context) {
+	req := team.StoreReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > 50 {
+		system.Failed("团队名称长度必须为 1-50", c)
+		return
+	}
+	if utf8.RuneCountInString(req.Description) > 255 {
+		system.Failed("团队描述长度不能超过 255", c)
+		return
+	}
+
+	t := &team.Team{ID: req.ID, Name: req.Name, Description: req.Description, UpdateAt: time.Now()}
+	if err := TeamDao.SaveTeam(t); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("团队不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(t, "ok", c)
+}
+
+// DeleteTeam 删除团队
+// @Summary      删除团队
+// @Description  删除团队及其成员关系, 成员的日程不受影响, 需要 team:manage 权限
+// @Tags         团队管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      team.IDReq  true  "团队ID"
+// @Success      200      {object}  system.Response
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/delete [post]
+func DeleteTeam(c *gin.Context) {
+	req := team.IDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if err := TeamDao.DeleteTeam(req.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("团队不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("ok", c)
+}
+
+// ListTeams 查询团队
+// @Summary      查询团队
+// @Description  查询当前用户加入的团队及在团队中的角色, 拥有 team:manage 权限时返回全部团队
+// @Tags         团队管理
+// @Produce      json
+// @Success      200  {object}  system.Response{data=[]team.TeamInfo}
+// @Failure      500  {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/list [post]
+func ListTeams(c *gin.Context) {
+	userID := middleware.UserID(c)
+	all, err := auth.Can(userID, user.PermTeamManage)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	list, err := TeamDao.ListTeams(userID, all)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// TeamMembers 查询团队成员
+// @Summary      查询团队成员
+// @Description  团队成员与拥有 team:manage 权限的管理员可以查询
+// @Tags         团队管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      team.IDReq  true  "团队ID"
+// @Success      200      {object}  system.Response{data=[]team.MemberInfo}
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/member/list [post]
+func TeamMembers(c *gin.Context) {
+	req := team.IDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	userID := middleware.UserID(c)
+	role, err := auth.TeamRole(req.ID, userID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if role == "" {
+		if ok, err := auth.Can(userID, user.PermTeamManage); err != nil || !ok {
+			system.Forbidden("不是该团队成员", c)
+			return
+		}
+	}
+	list, err := TeamDao.ListMembers(req.ID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// AddTeamMember 添加团队成员
+// @Summary      添加团队成员
+// @Description  需要 team:manage 权限; 已是成员时修改其团队角色;
+// @Description  团队负责人可以查看成员的日程, 因此负责人不能自行拉人入队, 以免借此查看任意用户的日程
+// @Tags         团队管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      team.MemberReq  true  "成员参数"
+// @Success      200      {object}  system.Response{data=team.Member}
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/member/add [post]
+func AddTeamMember(c *gin.Context) {
+	req := team.MemberReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	if req.Role == "" {
+		req.Role = team.RoleMember
+	}
+	if !team.ValidRole(req.Role) {
+		system.Failed("团队角色取值为 lead 或 member", c)
+		return
+	}
+	if !authorizeTeam(c, req.TeamID, true) {
+		return
+	}
+
+	u, err := UserDao.GetUserByUsername(strings.TrimSpace(req.Username))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if u == nil {
+		system.Failed("用户不存在", c)
+		return
+	}
+	m := &team.Member{TeamID: req.TeamID, UserID: u.ID, Role: req.Role, CreateAt: time.Now()}
+	if err = TeamDao.SaveMember(m); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(m, "ok", c)
+}
+
+// RemoveTeamMember 移除团队成员
+// @Summary      移除团队成员
+// @Description  团队负责人可以移除普通成员, 移除负责人需要 team:manage 权限
+// @Tags         团队管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      team.MemberRemoveReq  true  "成员参数"
+// @Success      200      {object}  system.Response
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/member/remove [post]
+func RemoveTeamMember(c *gin.Context) {
+	req := team.MemberRemoveReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	m, err := TeamDao.GetMember(req.TeamID, req.UserID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if m == nil {
+		system.Failed("团队成员不存在", c)
+		return
+	}
+	if !authorizeTeam(c, req.TeamID, m.Role == team.RoleLead) {
+		return
+	}
+	if err = TeamDao.RemoveMember(req.TeamID, req.UserID); err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.SuccessOnlyMsg("ok", c)
+}
+
+// TeamSchedules 查询团队日程
+// @Summary      查询团队日程
+// @Description  团队负责人与拥有 team:manage 权限的管理员查看团队成员在时间范围内的日程, 单次最多查询 93 天
+// @Tags         团队管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      team.ScheduleReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=[]schedule.Schedule}
+// @Failure      403      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /team/schedules [post]
+func TeamSchedules(c *gin.Context) {
+	req := team.ScheduleReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	begin, err := stringToTimeStandard(req.Begin)
+	if err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	end, err := stringToTimeStandard(req.End)
+	if err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	if end.Before(begin) || end.Sub(begin) > teamScheduleMaxDays*24*time.Hour {
+		system.Failed("查询时间范围不能超过 93 天", c)
+		return
+	}
+	if !authorizeTeam(c, req.TeamID, false) {
+		return
+	}
+
+	members, err := TeamDao.MemberUserIDs(req.TeamID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	if len(members) == 0 {
+		// UserIDs 为空时不按用户过滤, 必须提前返回
+		system.Success(make([]any, 0), "ok", c)
+		return
+	}
+	vo := dao.ScheduleRequestVo{UserIDs: members, BeginTime: begin, EndTime: end}
+	if req.UserID > 0 {
+		vo.UserIDs = nil
+		for _, id := range members {
+			if id == req.UserID {
+				vo.UserID = id
+			}
+		}
+		if vo.UserID == 0 {
+			system.Failed("团队成员不存在", c)
+			return
+		}
+	}
+	system.Success(ScheduleDao.ScheduleList(vo), "ok", c)
+}
+
+// authorizeTeam 校验当前用户可以管理团队, adminOnly 为 true 时团队负责人也不能操作; 校验失败时已写入响应
+func authorizeTeam(c *gin.Context, teamID int64, adminOnly bool) bool {
+	t, err := TeamDao.GetTeam(teamID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return false
+	}
+	if t == nil {
+		system.Failed("团队不存在", c)
+		return false
+	}
+	if adminOnly {
+		if !authorizedAsAdmin(c) {
+			system.Forbidden("只有管理员可以设置或移除团队负责人", c)
+			return false
+		}
+		return true
+	}
+	ok, err := auth.CanManageTeam(middleware.UserID(c), teamID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return false
+	}
+	if !ok {
+		system.Forbidden("只有团队负责人或管理员可以操作", c)
+		return false
+	}
+	return true
+}
+
+// authorizedAsAdmin 当前用户是否拥有 team:manage 权限
+func authorizedAsAdmin(c *gin.Context) bool {
+	ok, _ := auth.Can(middleware.UserID(c), user.PermTeamManage)
+	return ok
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"go-film-demo/dao"
+	"go-film-demo/model/system"
+	"go-film-demo/model/webhook"
+	"go-film-demo/plugin/middleware"
+	"go-film-demo/plugin/netguard"
+	"go-film-demo/plugin/webhooks"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var WebhookDao = dao.NewWebhookDao()
+
+// StoreWebhook 创建或修改 Webhook 订阅
+// @Summary      保存 Webhook 订阅
+// @Description  id 为 0 时新建, 未提供 secret 时自动生成; 密钥只在保存时返回; url 必须指向公网地址, 投递时不跟随重定向;
+// @Description  事件: schedule.created/schedule.updated/schedule.completed/schedule.deleted/news.created;
+// @Description  请求头 X-Webhook-Signature 为 sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + "." + 请求体) 的十六进制
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      webhook.StoreReq  true  "订阅参数"
+// @Success      200      {object}  system.Response{data=webhook.StoreResp}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /webhook/store [post]
+func StoreWebhook(c *gin.Context) {
+	req := webhook.StoreReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if err := netguard.CheckURL(c.Request.Context(), req.URL); err != nil {
+		system.Failed("url 无效: "+err.Error(), c)
+		return
+	}
+	if len(req.Events) == 0 {
+		system.Failed("请至少订阅一个事件", c)
+		return
+	}
+	for _, e := range req.Events {
+		if !slices.Contains(webhook.Events, e) {
+			system.Failed(fmt.Sprintf("不支持的事件: %s", e), c)
+			return
+		}
+	}
+
+	sub := &webhook.Subscription{
+		ID:       req.ID,
+		UserID:   req.UserID,
+		URL:      req.URL,
+		Secret:   req.Secret,
+		Events:   strings.Join(req.Events, ","),
+		Enabled:  req.Enabled,
+		UpdateAt: time.Now(),
+	}
+	if sub.ID == 0 && sub.Secret == "" {
+		sub.Secret = webhooks.NewSecret()
+	}
+	if err := WebhookDao.SaveSubscription(sub); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("Webhook 订阅不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(webhook.StoreResp{Subscription: *sub, Secret: sub.Secret}, "ok", c)
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+// @Summary      删除 Webhook 订阅
+// @Description  删除订阅, 未完成的投递将不再重试
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      webhook.IDReq  true  "订阅ID"
+// @Success      200      {object}  system.Response
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /webhook/delete [post]
+func DeleteWebhook(c *gin.Context) {
+	req := webhook.IDReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	if err := WebhookDao.DeleteSubscription(req.ID, req.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			system.Failed("Webhook 订阅不存在", c)
+			return
+		}
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(nil, "ok", c)
+}
+
+// ListWebhooks 查询 Webhook 订阅
+// @Summary      查询 Webhook 订阅
+// @Description  查询用户的全部 Webhook 订阅, 不返回密钥
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      webhook.ListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=[]webhook.Subscription}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /webhook/list [post]
+func ListWebhooks(c *gin.Context) {
+	req := webhook.ListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	list, err := WebhookDao.ListSubscriptions(req.UserID)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(list, "ok", c)
+}
+
+// WebhookDeliveries 查询 Webhook 投递日志
+// @Summary      Webhook 投递日志
+// @Description  分页查询订阅的投递记录, 包含请求体、响应状态码与响应内容
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      webhook.DeliveryListReq  true  "查询参数"
+// @Success      200      {object}  system.Response{data=system.PagingData}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /webhook/deliveries [post]
+func WebhookDeliveries(c *gin.Context) {
+	req := webhook.DeliveryListReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法查询参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	sub, err := WebhookDao.GetSubscription(req.SubscriptionID)
+	if err != nil || sub == nil || sub.UserID != req.UserID {
+		system.Failed("Webhook 订阅不存在", c)
+		return
+	}
+	list, page, err := WebhookDao.DeliveryPage(req.SubscriptionID, req.Status, pageInfo(req.Page, req.Size, "", "", ""))
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(dao.PagingData(list, page), "ok", c)
+}
+
+// RedeliverWebhook 重新投递
+// @Summary      重新投递 Webhook
+// @Description  以原请求体(相同事件ID)生成一条新的投递记录并立即投递
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body      webhook.RedeliverReq  true  "投递ID"
+// @Success      200      {object}  system.Response{data=webhook.Delivery}
+// @Failure      500      {object}  system.Response
+// @Security     BearerAuth
+// @Router       /webhook/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	req := webhook.RedeliverReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		system.Failed("非法参数", c)
+		return
+	}
+	req.UserID = middleware.UserID(c)
+	d, err := WebhookDao.GetDelivery(req.DeliveryID)
+	if err != nil || d == nil {
+		system.Failed("投递记录不存在", c)
+		return
+	}
+	sub, err := WebhookDao.GetSubscription(d.SubscriptionID)
+	if err != nil || sub == nil || sub.UserID != req.UserID {
+		system.Failed("投递记录不存在", c)
+		return
+	}
+	redelivery, err := webhooks.Redeliver(d)
+	if err != nil {
+		system.Failed(err.Error(), c)
+		return
+	}
+	system.Success(redelivery, "已加入投递队列", c)
+}
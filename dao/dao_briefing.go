@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/briefing"
+	"go-film-demo/plugin/db"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BriefingDao 每日简报设置数据访问对象
+type BriefingDao struct {
+}
+
+// NewBriefingDao 创建简报DAO实例
+func NewBriefingDao() *BriefingDao {
+	return &BriefingDao{}
+}
+
+// GetSetting 获取用户的简报设置, 不存在时返回 nil
+func (dao *BriefingDao) GetSetting(userID int64) (*briefing.BriefingSetting, error) {
+	var setting briefing.BriefingSetting
+	result := db.Mdb.Where("user_id = ?", userID).First(&setting)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询简报设置失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &setting, nil
+}
+
+// SaveSetting 保存用户的简报设置, 已存在时覆盖
+func (dao *BriefingDao) SaveSetting(setting *briefing.BriefingSetting) error {
+	result := db.Mdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"send_time", "enabled"}),
+	}).Create(setting)
+	if result.Error != nil {
+		log.Printf("保存简报设置失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// ListEnabledSettings 获取全部启用的简报设置
+func (dao *BriefingDao) ListEnabledSettings() ([]briefing.BriefingSetting, error) {
+	var list []briefing.BriefingSetting
+	result := db.Mdb.Where("enabled = ?", true).Find(&list)
+	if result.Error != nil {
+		log.Printf("查询简报设置失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
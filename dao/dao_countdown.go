@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/countdown"
+	"go-film-demo/plugin/db"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CountdownDao 倒数日/纪念日数据访问对象
+type CountdownDao struct {
+}
+
+// NewCountdownDao 创建倒数日DAO实例
+func NewCountdownDao() *CountdownDao {
+	return &CountdownDao{}
+}
+
+// CreateCountdown 创建倒数日
+func (dao *CountdownDao) CreateCountdown(c *countdown.Countdown) error {
+	result := db.Mdb.Create(c)
+	if result.Error != nil {
+		log.Printf("创建倒数日失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// UpdateCountdown 更新倒数日
+func (dao *CountdownDao) UpdateCountdown(c *countdown.Countdown) error {
+	result := db.Mdb.Save(c)
+	if result.Error != nil {
+		log.Printf("更新倒数日失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteCountdown 删除倒数日
+func (dao *CountdownDao) DeleteCountdown(id int64) error {
+	result := db.Mdb.Delete(&countdown.Countdown{}, id)
+	if result.Error != nil {
+		log.Printf("删除倒数日失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// GetCountdownByID 根据ID获取倒数日
+func (dao *CountdownDao) GetCountdownByID(id int64) (*countdown.Countdown, error) {
+	var c countdown.Countdown
+	result := db.Mdb.First(&c, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询倒数日失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &c, nil
+}
+
+// ListByUser 获取用户的全部倒数日
+func (dao *CountdownDao) ListByUser(userID int64) ([]countdown.Countdown, error) {
+	var list []countdown.Countdown
+	result := db.Mdb.Where("user_id = ?", userID).Order("id ASC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询用户倒数日失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
@@ -4,12 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"go-film-demo/model/news"
+	"go-film-demo/model/system"
 	"go-film-demo/plugin/db"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// NewsRequestVo 新闻分页查询参数
+type NewsRequestVo struct {
+	Date    time.Time // 发布日期, 为零值时不限制
+	Keyword string
+	Source  string
+	Creator string
+	Paging  PageInfo
+}
+
+// NewsSortFields 新闻列表可排序字段
+var NewsSortFields = map[string]SortField{
+	"id":           {Column: "id"},
+	"publish_time": {Column: "publish_time", IsTime: true},
+	"created_at":   {Column: "created_at", IsTime: true},
+	"read_num":     {Column: "read_num"},
+	"comment_num":  {Column: "comment_num"},
+	"like_num":     {Column: "like_num"},
+}
+
 // NewsRepository 新闻仓储层
 type NewsRepository struct {
 }
@@ -28,9 +48,17 @@ func (r *NewsRepository) Create(news *news.News) error {
 	return nil
 }
 
-// CreateBatch 批量创建新闻
+// newsListeners 新闻入库后的回调
+var newsListeners []func(list []*news.News)
+
+// OnNewsCreated 注册新闻批量入库后的回调(如实时推送), 需在启动阶段调用
+func OnNewsCreated(fn func(list []*news.News)) {
+	newsListeners = append(newsListeners, fn)
+}
+
+// CreateBatch 批量创建新闻, 提交成功后通知监听者
 func (r *NewsRepository) CreateBatch(newsList []*news.News) error {
-	return db.Mdb.Transaction(func(tx *gorm.DB) error {
+	err := db.Mdb.Transaction(func(tx *gorm.DB) error {
 		for _, news := range newsList {
 			if err := tx.Create(news).Error; err != nil {
 				return fmt.Errorf("failed to batch create news: %w", err)
@@ -38,6 +66,29 @@ func (r *NewsRepository) CreateBatch(newsList []*news.News) error {
 		}
 		return nil
 	})
+	if err != nil || len(newsList) == 0 {
+		return err
+	}
+	for _, fn := range newsListeners {
+		fn(newsList)
+	}
+	return nil
+}
+
+// ExistingNewsIDs 返回已入库的新闻唯一标识集合
+func (r *NewsRepository) ExistingNewsIDs(newsIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(newsIDs))
+	if len(newsIDs) == 0 {
+		return existing, nil
+	}
+	var ids []string
+	if err := db.Mdb.Model(&news.News{}).Where("news_id IN ?", newsIDs).Pluck("news_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to query existing news: %w", err)
+	}
+	for _, id := range ids {
+		existing[id] = true
+	}
+	return existing, nil
 }
 
 // GetByID 根据ID获取新闻
@@ -144,6 +195,30 @@ func (r *NewsRepository) List(page, pageSize int, conditions map[string]interfac
 	return newsList, total, nil
 }
 
+// Page 分页查询新闻, 支持页码与游标两种模式
+func (r *NewsRepository) Page(vo NewsRequestVo) ([]news.News, *system.Page, error) {
+	query := db.Mdb.Model(&news.News{})
+	if !vo.Date.IsZero() {
+		startOfDay := time.Date(vo.Date.Year(), vo.Date.Month(), vo.Date.Day(), 0, 0, 0, 0, vo.Date.Location())
+		query = query.Where("publish_time >= ? AND publish_time < ?", startOfDay, startOfDay.Add(24*time.Hour))
+	}
+	if vo.Keyword != "" {
+		query = query.Where("title LIKE ?", "%"+vo.Keyword+"%")
+	}
+	if vo.Source != "" {
+		query = query.Where("source = ?", vo.Source)
+	}
+	if vo.Creator != "" {
+		query = query.Where("creator = ?", vo.Creator)
+	}
+
+	list, page, err := Paginate[news.News](query, vo.Paging, NewsSortFields, "publish_time", true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to page news: %w", err)
+	}
+	return list, page, nil
+}
+
 // ListByCreator 根据作者查询新闻
 func (r *NewsRepository) ListByCreator(creator string, page, pageSize int) ([]*news.News, int64, error) {
 	conditions := map[string]interface{}{
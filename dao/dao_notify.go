@@ -0,0 +1,152 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/notify"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/db"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotifyDao 通知渠道与投递记录数据访问对象
+type NotifyDao struct {
+}
+
+// NewNotifyDao 创建通知DAO实例
+func NewNotifyDao() *NotifyDao {
+	return &NotifyDao{}
+}
+
+// SaveChannel 创建或更新通知渠道
+func (dao *NotifyDao) SaveChannel(ch *notify.Channel) error {
+	var result *gorm.DB
+	if ch.ID == 0 {
+		result = db.Mdb.Create(ch)
+	} else {
+		result = db.Mdb.Model(ch).Where("user_id = ?", ch.UserID).
+			Select("type", "name", "target", "secret", "events", "enabled", "update_at").Updates(ch)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	if result.Error != nil {
+		log.Printf("保存通知渠道失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteChannel 删除用户的通知渠道
+func (dao *NotifyDao) DeleteChannel(id, userID int64) error {
+	result := db.Mdb.Where("id = ? AND user_id = ?", id, userID).Delete(&notify.Channel{})
+	if result.Error != nil {
+		log.Printf("删除通知渠道失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetChannel 根据ID获取通知渠道, 不存在时返回 nil
+func (dao *NotifyDao) GetChannel(id int64) (*notify.Channel, error) {
+	var ch notify.Channel
+	result := db.Mdb.First(&ch, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询通知渠道失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &ch, nil
+}
+
+// ListChannels 获取用户的全部通知渠道
+func (dao *NotifyDao) ListChannels(userID int64) ([]notify.Channel, error) {
+	var list []notify.Channel
+	result := db.Mdb.Where("user_id = ?", userID).Order("id ASC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询通知渠道失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// ListEnabledChannels 获取用户已启用的通知渠道
+func (dao *NotifyDao) ListEnabledChannels(userID int64) ([]notify.Channel, error) {
+	var list []notify.Channel
+	result := db.Mdb.Where("user_id = ? AND enabled = ?", userID, true).Order("id ASC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询通知渠道失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// ListEnabledChannelsByEvent 获取全部订阅了事件的已启用渠道, 用于广播事件
+func (dao *NotifyDao) ListEnabledChannelsByEvent(event string) ([]notify.Channel, error) {
+	var all []notify.Channel
+	result := db.Mdb.Where("enabled = ?", true).Order("id ASC").Find(&all)
+	if result.Error != nil {
+		log.Printf("查询通知渠道失败: %v", result.Error)
+		return nil, result.Error
+	}
+	list := make([]notify.Channel, 0, len(all))
+	for _, ch := range all {
+		if ch.Subscribes(event) {
+			list = append(list, ch)
+		}
+	}
+	return list, nil
+}
+
+// Enqueue 写入待投递记录, 去重键已存在时忽略并返回 false
+func (dao *NotifyDao) Enqueue(d *notify.Delivery) (bool, error) {
+	result := db.Mdb.Clauses(clause.OnConflict{DoNothing: true}).Create(d)
+	if result.Error != nil {
+		log.Printf("写入通知投递记录失败: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDueDeliveries 获取已到投递时间的待投递记录
+func (dao *NotifyDao) ListDueDeliveries(now time.Time, limit int) ([]notify.Delivery, error) {
+	var list []notify.Delivery
+	result := db.Mdb.Where("status = ? AND next_attempt_at <= ?", notify.DeliveryPending, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&list)
+	if result.Error != nil {
+		log.Printf("查询待投递通知失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// UpdateDeliveryResult 更新投递结果
+func (dao *NotifyDao) UpdateDeliveryResult(d *notify.Delivery) error {
+	result := db.Mdb.Model(d).
+		Select("status", "attempts", "next_attempt_at", "last_error", "sent_at", "update_at").Updates(d)
+	if result.Error != nil {
+		log.Printf("更新通知投递结果失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// DeliveryPage 分页查询投递记录, 按ID倒序
+func (dao *NotifyDao) DeliveryPage(userID int64, status string, paging PageInfo) ([]notify.Delivery, *system.Page, error) {
+	query := db.Mdb.Model(&notify.Delivery{})
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return Paginate[notify.Delivery](query, paging, map[string]SortField{"id": {Column: "id"}}, "id", true)
+}
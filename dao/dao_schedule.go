@@ -1,16 +1,33 @@
 package dao
 
 import (
+	"errors"
 	"go-film-demo/model/schedule"
+	"go-film-demo/model/system"
 	"go-film-demo/plugin/db"
 	"log"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict 日程已被他人修改, 提交的版本号已过期
+var ErrVersionConflict = errors.New("日程已被修改, 请刷新后重试")
+
 // ScheduleDao 日程数据访问对象
 type ScheduleDao struct {
+	tx        *gorm.DB                   // 事务句柄, 为空时使用全局连接
+	changes   []schedule.OperationChange // 事务内记录的日程变更, 用于写入操作日志
+	committed []schedule.OperationChange // 事务内全部日程变更, 提交后通知监听者
+}
+
+// changeListeners 日程变更提交后的回调
+var changeListeners []func(changes []schedule.OperationChange)
+
+// OnScheduleChange 注册日程变更提交后的回调(如 Webhook、实时推送), 需在启动阶段调用
+func OnScheduleChange(fn func(changes []schedule.OperationChange)) {
+	changeListeners = append(changeListeners, fn)
 }
 
 // NewScheduleDao 创建日程DAO实例
@@ -18,9 +35,39 @@ func NewScheduleDao() *ScheduleDao {
 	return &ScheduleDao{}
 }
 
+// conn 获取当前使用的数据库连接
+func (dao *ScheduleDao) conn() *gorm.DB {
+	if dao.tx != nil {
+		return dao.tx
+	}
+	return db.Mdb
+}
+
+// Transaction 在同一事务中执行多个日程操作, 提交成功后通知日程变更监听者
+func (dao *ScheduleDao) Transaction(fn func(txDao *ScheduleDao) error) error {
+	var txDao *ScheduleDao
+	err := dao.conn().Transaction(func(tx *gorm.DB) error {
+		txDao = &ScheduleDao{tx: tx}
+		return fn(txDao)
+	})
+	if err != nil || len(txDao.committed) == 0 {
+		return err
+	}
+	if dao.tx != nil {
+		// 嵌套事务由最外层事务提交后统一通知
+		dao.committed = append(dao.committed, txDao.committed...)
+		return nil
+	}
+	for _, fn := range changeListeners {
+		fn(txDao.committed)
+	}
+	return nil
+}
+
 // ScheduleRequestVo 日程查询请求参数
 type ScheduleRequestVo struct {
 	UserID    int64
+	UserIDs   []int64 // 查询多个用户的日程, 如团队日程
 	Year      int16
 	Month     int8
 	Day       int8
@@ -29,29 +76,94 @@ type ScheduleRequestVo struct {
 	Content   string
 	Priority  int8
 	Status    int
-	Paging    PageInfo // 假设有分页结构体
+	Kind      string
+	Rollovers int // 最少顺延次数, 大于0时只查询未完成的日程
+	Paging    PageInfo
 }
 
 // PageInfo 分页信息
 type PageInfo struct {
-	Current  int // 当前页
-	PageSize int // 每页大小
+	Current  int    // 当前页
+	PageSize int    // 每页大小
+	Cursor   string // 游标, 非空时使用游标分页并忽略 Current
+	SortBy   string // 排序字段, 为空时使用默认排序
+	Desc     bool   // 是否倒序
 }
 
-// GetPage 分页处理（假设已有实现）
+// GetPage 按页码对查询追加 offset/limit, PageSize 为0时不分页
 func GetPage(query *gorm.DB, paging PageInfo) {
-	// 这里应该是原有的分页逻辑
+	if paging.PageSize <= 0 {
+		return
+	}
+	paging.normalize()
+	query.Offset((paging.Current - 1) * paging.PageSize).Limit(paging.PageSize)
+}
+
+// ScheduleSortFields 日程列表可排序字段
+var ScheduleSortFields = map[string]SortField{
+	"id":             {Column: "id"},
+	"start_time":     {Column: "start_time", IsTime: true},
+	"end_time":       {Column: "end_time", IsTime: true},
+	"create_at":      {Column: "create_at", IsTime: true},
+	"update_at":      {Column: "update_at", IsTime: true},
+	"priority":       {Column: "priority"},
+	"status":         {Column: "status"},
+	"rollover_count": {Column: "rollover_count"},
 }
 
 // ScheduleList 获取日程列表
 func (dao *ScheduleDao) ScheduleList(vo ScheduleRequestVo) []schedule.Schedule {
+	qw := dao.scheduleQuery(vo)
+
+	// 获取分页数据
+	GetPage(qw, vo.Paging)
+
+	// 执行查询
+	var list []schedule.Schedule
+	if err := qw.Order("start_time ASC").Find(&list).Error; err != nil {
+		log.Println(err)
+		return nil
+	}
+	return list
+}
+
+// ScheduleListLimit 获取最多 limit 条日程, 不受分页大小上限限制, 用于导出、批量操作等需要完整结果的场景;
+// lock 为 true 时对查询到的日程加行锁, 需在事务中使用
+func (dao *ScheduleDao) ScheduleListLimit(vo ScheduleRequestVo, limit int, lock bool) ([]schedule.Schedule, error) {
+	qw := dao.scheduleQuery(vo).Order("start_time ASC, id ASC").Limit(limit)
+	if lock {
+		qw = qw.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var list []schedule.Schedule
+	if err := qw.Find(&list).Error; err != nil {
+		log.Printf("查询日程失败: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
+
+// SchedulePage 分页获取日程列表, 返回总数以及下一页游标
+func (dao *ScheduleDao) SchedulePage(vo ScheduleRequestVo) ([]schedule.Schedule, *system.Page, error) {
+	list, page, err := Paginate[schedule.Schedule](dao.scheduleQuery(vo), vo.Paging, ScheduleSortFields, "start_time", false)
+	if err != nil {
+		log.Printf("分页查询日程失败: %v", err)
+		return nil, nil, err
+	}
+	return list, page, nil
+}
+
+// scheduleQuery 根据查询参数构建日程查询条件
+func (dao *ScheduleDao) scheduleQuery(vo ScheduleRequestVo) *gorm.DB {
 	// 构建查询条件
-	qw := db.Mdb.Model(&schedule.Schedule{})
+	qw := dao.conn().Model(&schedule.Schedule{})
 
 	// 用户ID查询
 	if vo.UserID > 0 {
 		qw.Where("user_id = ?", vo.UserID)
 	}
+	if len(vo.UserIDs) > 0 {
+		qw.Where("user_id IN ?", vo.UserIDs)
+	}
 
 	// 年月日查询
 	if vo.Year > 0 {
@@ -69,9 +181,10 @@ func (dao *ScheduleDao) ScheduleList(vo ScheduleRequestVo) []schedule.Schedule {
 		qw.Where("start_time BETWEEN ? AND ?", vo.BeginTime, vo.EndTime)
 	}
 
-	// 内容模糊查询
+	// 内容模糊查询, 同时匹配备注
 	if vo.Content != "" {
-		qw.Where("content LIKE ?", "%"+vo.Content+"%")
+		keyword := "%" + vo.Content + "%"
+		qw.Where("(content LIKE ? OR notes LIKE ?)", keyword, keyword)
 	}
 
 	// 优先级查询
@@ -84,65 +197,107 @@ func (dao *ScheduleDao) ScheduleList(vo ScheduleRequestVo) []schedule.Schedule {
 		qw.Where("status = ?", vo.Status)
 	}
 
-	// 获取分页数据
-	GetPage(qw, vo.Paging)
-
-	// 执行查询
-	var list []schedule.Schedule
-	if err := qw.Order("start_time ASC").Find(&list).Error; err != nil {
-		log.Println(err)
-		return nil
+	if vo.Kind != "" {
+		qw.Where("kind = ?", vo.Kind)
 	}
-	return list
+	if vo.Rollovers > 0 {
+		qw.Where("rollover_count >= ? AND status <> ?", vo.Rollovers, schedule.StatusCompleted)
+	}
+
+	return qw
 }
 
-// CreateSchedule 创建日程
-func (dao *ScheduleDao) CreateSchedule(schedule *schedule.Schedule) error {
-	result := db.Mdb.Create(schedule)
+// CreateSchedule 创建日程; 指定ID时为恢复已删除的日程(撤销删除、回滚到历史版本),
+// 版本号接在该日程最新的历史版本之后, 使历史版本号与日程版本号保持一致
+func (dao *ScheduleDao) CreateSchedule(s *schedule.Schedule) error {
+	if s.ID > 0 {
+		var latest int
+		err := dao.conn().Model(&schedule.ScheduleHistory{}).Where("schedule_id = ?", s.ID).
+			Select("COALESCE(MAX(version), 0)").Find(&latest).Error
+		if err != nil {
+			log.Printf("查询日程历史版本失败: %v", err)
+			return err
+		}
+		s.Version = latest + 1
+	}
+	result := dao.conn().Create(s)
 	if result.Error != nil {
 		log.Printf("创建日程失败: %v", result.Error)
 		return result.Error
 	}
-	log.Printf("创建日程成功, ID: %d", schedule.ID)
+	log.Printf("创建日程成功, ID: %d", s.ID)
 	return nil
 }
 
-// UpdateSchedule 更新日程
+// UpdateSchedule 更新日程, s.Version 为读取时的版本号, 版本不一致时返回 ErrVersionConflict
 func (dao *ScheduleDao) UpdateSchedule(s *schedule.Schedule) error {
-	result := db.Mdb.Save(s)
+	expected := s.Version
+	s.Version = expected + 1
+	result := dao.conn().Model(s).Where("version = ?", expected).Select("*").Omit("id").Updates(s)
 	if result.Error != nil {
+		s.Version = expected
 		log.Printf("更新日程失败: %v", result.Error)
 		return result.Error
 	}
-	log.Printf("更新日程成功, ID: %d", s.ID)
+	if result.RowsAffected == 0 {
+		s.Version = expected
+		log.Printf("更新日程版本冲突, ID: %d, 版本: %d", s.ID, expected)
+		return ErrVersionConflict
+	}
+	log.Printf("更新日程成功, ID: %d, 版本: %d", s.ID, s.Version)
 	return nil
 }
 
-// UpdateScheduleByFields 更新日程部分字段
-func (dao *ScheduleDao) UpdateScheduleByFields(id int64, updates map[string]interface{}) error {
-	result := db.Mdb.Model(&schedule.Schedule{}).Where("id = ?", id).Updates(updates)
+// UpdateScheduleByFields 更新日程部分字段, version 大于0时按版本号更新, 版本不一致时返回 ErrVersionConflict
+func (dao *ScheduleDao) UpdateScheduleByFields(id int64, version int, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+	qw := dao.conn().Model(&schedule.Schedule{}).Where("id = ?", id)
+	if version > 0 {
+		qw.Where("version = ?", version)
+	}
+	result := qw.Updates(updates)
 	if result.Error != nil {
 		log.Printf("更新日程字段失败: %v", result.Error)
 		return result.Error
 	}
+	if version > 0 && result.RowsAffected == 0 {
+		log.Printf("更新日程字段版本冲突, ID: %d, 版本: %d", id, version)
+		return ErrVersionConflict
+	}
 	log.Printf("更新日程字段成功, ID: %d, 影响行数: %d", id, result.RowsAffected)
 	return nil
 }
 
 // DeleteSchedule 删除日程（物理删除）
 func (dao *ScheduleDao) DeleteSchedule(id int64) error {
-	result := db.Mdb.Delete(&schedule.Schedule{}, id)
+	result := dao.conn().Delete(&schedule.Schedule{}, id)
+	if result.Error != nil {
+		log.Printf("删除日程失败: %v", result.Error)
+		return result.Error
+	}
+	log.Printf("删除日程成功, ID: %d", id)
+	return nil
+}
+
+// DeleteScheduleVersion 按版本号删除日程, 版本不一致时返回 ErrVersionConflict
+func (dao *ScheduleDao) DeleteScheduleVersion(id int64, version int) error {
+	result := dao.conn().Where("version = ?", version).Delete(&schedule.Schedule{}, id)
 	if result.Error != nil {
 		log.Printf("删除日程失败: %v", result.Error)
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		log.Printf("删除日程版本冲突, ID: %d, 版本: %d", id, version)
+		return ErrVersionConflict
+	}
 	log.Printf("删除日程成功, ID: %d", id)
 	return nil
 }
 
 // SoftDeleteSchedule 软删除日程（如果表中支持deleted_at字段）
 func (dao *ScheduleDao) SoftDeleteSchedule(id int64) error {
-	result := db.Mdb.Model(&schedule.Schedule{}).Where("id = ?", id).Update("status", schedule.StatusEnded) // 假设用状态表示删除
+	result := dao.conn().Model(&schedule.Schedule{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": schedule.StatusEnded, "version": gorm.Expr("version + 1")}) // 假设用状态表示删除
 	if result.Error != nil {
 		log.Printf("软删除日程失败: %v", result.Error)
 		return result.Error
@@ -154,7 +309,7 @@ func (dao *ScheduleDao) SoftDeleteSchedule(id int64) error {
 // GetScheduleByID 根据ID获取日程详情
 func (dao *ScheduleDao) GetScheduleByID(id int64) (*schedule.Schedule, error) {
 	var schedule schedule.Schedule
-	result := db.Mdb.First(&schedule, id)
+	result := dao.conn().First(&schedule, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			log.Printf("日程不存在, ID: %d", id)
@@ -166,10 +321,22 @@ func (dao *ScheduleDao) GetScheduleByID(id int64) (*schedule.Schedule, error) {
 	return &schedule, nil
 }
 
+// GetSchedulesByIDs 根据ID列表获取日程并加行锁, 需在事务中使用
+func (dao *ScheduleDao) GetSchedulesByIDs(ids []int64) ([]schedule.Schedule, error) {
+	var schedules []schedule.Schedule
+	result := dao.conn().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).Order("id ASC").Find(&schedules)
+	if result.Error != nil {
+		log.Printf("批量查询日程失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return schedules, nil
+}
+
 // GetSchedulesByUserAndDate 根据用户ID和日期获取日程
 func (dao *ScheduleDao) GetSchedulesByUserAndDate(userID int64, year int16, month int8, day int8) ([]schedule.Schedule, error) {
 	var schedules []schedule.Schedule
-	result := db.Mdb.Where("user_id = ? AND year = ? AND month = ? AND day = ?",
+	result := dao.conn().Where("user_id = ? AND year = ? AND month = ? AND day = ?",
 		userID, year, month, day).Order("start_time ASC").Find(&schedules)
 	if result.Error != nil {
 		log.Printf("查询用户日程失败: %v", result.Error)
@@ -181,7 +348,7 @@ func (dao *ScheduleDao) GetSchedulesByUserAndDate(userID int64, year int16, mont
 // GetSchedulesByUserAndPriority 根据用户ID和优先级获取日程
 func (dao *ScheduleDao) GetSchedulesByUserAndPriority(userID int64, priority int8) ([]schedule.Schedule, error) {
 	var schedules []schedule.Schedule
-	result := db.Mdb.Where("user_id = ? AND priority = ?", userID, priority).
+	result := dao.conn().Where("user_id = ? AND priority = ?", userID, priority).
 		Order("start_time ASC").Find(&schedules)
 	if result.Error != nil {
 		log.Printf("查询用户优先级日程失败: %v", result.Error)
@@ -192,7 +359,8 @@ func (dao *ScheduleDao) GetSchedulesByUserAndPriority(userID int64, priority int
 
 // BatchUpdateScheduleStatus 批量更新日程状态
 func (dao *ScheduleDao) BatchUpdateScheduleStatus(ids []int64, status int) error {
-	result := db.Mdb.Model(&schedule.Schedule{}).Where("id IN ?", ids).Update("status", status)
+	result := dao.conn().Model(&schedule.Schedule{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": status, "version": gorm.Expr("version + 1")})
 	if result.Error != nil {
 		log.Printf("批量更新日程状态失败: %v", result.Error)
 		return result.Error
@@ -200,3 +368,57 @@ func (dao *ScheduleDao) BatchUpdateScheduleStatus(ids []int64, status int) error
 	log.Printf("批量更新日程状态成功, 影响行数: %d", result.RowsAffected)
 	return nil
 }
+
+// ListStartingBetween 获取开始时间位于 [begin, end) 且尚未开始的日程, 用于发送提醒
+func (dao *ScheduleDao) ListStartingBetween(begin, end time.Time) ([]schedule.Schedule, error) {
+	var schedules []schedule.Schedule
+	result := dao.conn().Where("start_time >= ? AND start_time < ? AND status = ? AND unscheduled = ?", begin, end, schedule.StatusNotStarted, false).
+		Order("start_time ASC").Find(&schedules)
+	if result.Error != nil {
+		log.Printf("查询即将开始的日程失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return schedules, nil
+}
+
+// ListOverdueTasks 获取在 before 之前结束且未完成的任务, 未安排时间段的任务只有截止时间, 不做顺延
+func (dao *ScheduleDao) ListOverdueTasks(before time.Time) ([]schedule.Schedule, error) {
+	var schedules []schedule.Schedule
+	result := dao.conn().Where("kind = ? AND unscheduled = ? AND end_time < ? AND status <> ?", schedule.KindTask, false, before, schedule.StatusCompleted).
+		Order("user_id ASC, start_time ASC").Find(&schedules)
+	if result.Error != nil {
+		log.Printf("查询逾期任务失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return schedules, nil
+}
+
+// TodoPage 分页获取用户的任务, 按截止时间(为空的排在最后)、优先级、开始时间排序
+func (dao *ScheduleDao) TodoPage(userID int64, includeCompleted bool, paging PageInfo) ([]schedule.Schedule, *system.Page, error) {
+	paging.normalize()
+	qw := dao.conn().Model(&schedule.Schedule{}).Where("user_id = ? AND kind = ?", userID, schedule.KindTask)
+	if !includeCompleted {
+		qw.Where("status <> ?", schedule.StatusCompleted)
+	}
+
+	base := qw.Session(&gorm.Session{})
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		log.Printf("统计待办任务失败: %v", err)
+		return nil, nil, err
+	}
+	var list []schedule.Schedule
+	err := base.Order("due_at IS NULL ASC, due_at ASC, priority DESC, start_time ASC, id ASC").
+		Offset((paging.Current - 1) * paging.PageSize).Limit(paging.PageSize).Find(&list).Error
+	if err != nil {
+		log.Printf("查询待办任务失败: %v", err)
+		return nil, nil, err
+	}
+	page := &system.Page{
+		PageSize:  paging.PageSize,
+		Current:   paging.Current,
+		Total:     int(total),
+		PageCount: int((total + int64(paging.PageSize) - 1) / int64(paging.PageSize)),
+	}
+	return list, page, nil
+}
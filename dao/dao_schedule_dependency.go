@@ -0,0 +1,120 @@
+package dao
+
+import (
+	"go-film-demo/model/schedule"
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// CreateDependency 创建日程依赖
+func (dao *ScheduleDao) CreateDependency(dep *schedule.ScheduleDependency) error {
+	if dep.CreateAt.IsZero() {
+		dep.CreateAt = time.Now()
+	}
+	if err := dao.conn().Create(dep).Error; err != nil {
+		log.Printf("创建日程依赖失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteDependency 删除日程依赖
+func (dao *ScheduleDao) DeleteDependency(predecessorID, successorID int64) error {
+	result := dao.conn().Where("predecessor_id = ? AND successor_id = ?", predecessorID, successorID).
+		Delete(&schedule.ScheduleDependency{})
+	if result.Error != nil {
+		log.Printf("删除日程依赖失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// ListDependencies 获取日程作为前置或后置的全部依赖
+func (dao *ScheduleDao) ListDependencies(scheduleID int64) ([]schedule.ScheduleDependency, error) {
+	var list []schedule.ScheduleDependency
+	result := dao.conn().Where("predecessor_id = ? OR successor_id = ?", scheduleID, scheduleID).
+		Order("id ASC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询日程依赖失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// ListPredecessors 获取日程的全部前置日程(已删除的日程会被忽略)
+func (dao *ScheduleDao) ListPredecessors(scheduleID int64) ([]schedule.Schedule, error) {
+	var list []schedule.Schedule
+	result := dao.conn().Joins("JOIN schedule_dependency d ON d.predecessor_id = schedule.id").
+		Where("d.successor_id = ?", scheduleID).Order("schedule.end_time DESC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询前置日程失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// ListSuccessors 获取日程的全部后置日程(已删除的日程会被忽略)
+func (dao *ScheduleDao) ListSuccessors(scheduleID int64) ([]schedule.Schedule, error) {
+	var list []schedule.Schedule
+	result := dao.conn().Joins("JOIN schedule_dependency d ON d.successor_id = schedule.id").
+		Where("d.predecessor_id = ?", scheduleID).Order("schedule.start_time ASC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询后置日程失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// DependentScheduleIDs 返回 ids 中作为前置或后置参与了依赖关系的日程ID
+func (dao *ScheduleDao) DependentScheduleIDs(ids []int64) (map[int64]bool, error) {
+	result := make(map[int64]bool)
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var deps []schedule.ScheduleDependency
+	if err := dao.conn().Where("predecessor_id IN ? OR successor_id IN ?", ids, ids).Find(&deps).Error; err != nil {
+		log.Printf("查询日程依赖失败: %v", err)
+		return nil, err
+	}
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	for _, d := range deps {
+		for _, id := range []int64{d.PredecessorID, d.SuccessorID} {
+			if wanted[id] {
+				result[id] = true
+			}
+		}
+	}
+	return result, nil
+}
+
+// DependencyReachable 判断沿依赖方向能否从 from 到达 to, 用于检测循环依赖;
+// 遍历时对依赖记录加共享锁, 在事务中使用时并发添加的依赖不会绕过检测形成循环
+func (dao *ScheduleDao) DependencyReachable(from, to int64) (bool, error) {
+	visited := map[int64]bool{from: true}
+	queue := []int64{from}
+	for len(queue) > 0 {
+		var next []int64
+		result := dao.conn().Model(&schedule.ScheduleDependency{}).Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("predecessor_id IN ?", queue).Pluck("successor_id", &next)
+		if result.Error != nil {
+			log.Printf("查询日程依赖失败: %v", result.Error)
+			return false, result.Error
+		}
+		queue = queue[:0]
+		for _, id := range next {
+			if id == to {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/schedule"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecordHistory 记录日程变更历史, before 为空表示创建, after 为空表示删除;
+// 需在写入日程之后调用, 历史版本号使用写入后日程行的版本号(删除时为删除前版本号加1),
+// 写入时已持有该日程的行锁, 并发修改同一日程不会产生相同的历史版本号
+func (dao *ScheduleDao) RecordHistory(action string, actorID int64, before, after *schedule.Schedule) error {
+	var scheduleID int64
+	var version int
+	snapshot := after
+	if after != nil {
+		scheduleID, version = after.ID, after.Version
+	} else if before != nil {
+		scheduleID, version = before.ID, before.Version+1
+		snapshot = before
+	}
+	if scheduleID == 0 {
+		return errors.New("无法记录历史: 缺少日程ID")
+	}
+	if version <= 0 {
+		return errors.New("无法记录历史: 缺少日程版本号")
+	}
+
+	history := &schedule.ScheduleHistory{
+		ScheduleID: scheduleID,
+		Version:    version,
+		Action:     action,
+		ActorID:    actorID,
+		Snapshot:   snapshot,
+		Diff:       schedule.Diff(before, after),
+		CreateAt:   time.Now(),
+	}
+	if err := dao.conn().Create(history).Error; err != nil {
+		log.Printf("记录日程历史失败: %v", err)
+		return err
+	}
+	dao.trackChange(before, after)
+	return nil
+}
+
+// ListHistory 获取日程的全部历史版本, 按版本号倒序
+func (dao *ScheduleDao) ListHistory(scheduleID int64) ([]schedule.ScheduleHistory, error) {
+	var list []schedule.ScheduleHistory
+	result := dao.conn().Where("schedule_id = ?", scheduleID).Order("version DESC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询日程历史失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// GetHistoryVersion 获取日程的指定历史版本
+func (dao *ScheduleDao) GetHistoryVersion(scheduleID int64, version int) (*schedule.ScheduleHistory, error) {
+	var history schedule.ScheduleHistory
+	result := dao.conn().Where("schedule_id = ? AND version = ?", scheduleID, version).First(&history)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询日程历史版本失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &history, nil
+}
@@ -0,0 +1,221 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"go-film-demo/model/schedule"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrOperationStale 操作涉及的日程在之后又被修改, 无法撤销/重做
+var ErrOperationStale = errors.New("日程已被修改, 无法撤销或重做该操作")
+
+// ErrNoOperation 没有可撤销/重做的操作
+var ErrNoOperation = errors.New("没有可撤销或重做的操作")
+
+// OperationTransaction 在事务中执行日程写操作, 并将事务中记录的全部变更写入用户操作日志
+func (dao *ScheduleDao) OperationTransaction(userID int64, opType string, fn func(txDao *ScheduleDao) error) error {
+	return dao.operationTransaction(userID, opType, true, fn)
+}
+
+// SystemOperationTransaction 与 OperationTransaction 相同, 但保留用户已撤销的操作:
+// 定时顺延等系统操作不是用户发起的, 不应清空用户的重做记录
+func (dao *ScheduleDao) SystemOperationTransaction(userID int64, opType string, fn func(txDao *ScheduleDao) error) error {
+	return dao.operationTransaction(userID, opType, false, fn)
+}
+
+func (dao *ScheduleDao) operationTransaction(userID int64, opType string, discardUndone bool, fn func(txDao *ScheduleDao) error) error {
+	return dao.Transaction(func(txDao *ScheduleDao) error {
+		if err := fn(txDao); err != nil {
+			return err
+		}
+		return txDao.journal(userID, opType, discardUndone)
+	})
+}
+
+// trackChange 记录事务内的日程变更, 保存副本以免调用方后续修改
+func (dao *ScheduleDao) trackChange(before, after *schedule.Schedule) {
+	if dao.tx == nil {
+		return
+	}
+	change := schedule.OperationChange{}
+	if before != nil {
+		b := *before
+		change.Before = &b
+		change.ScheduleID = b.ID
+	}
+	if after != nil {
+		a := *after
+		change.After = &a
+		change.ScheduleID = a.ID
+	}
+	dao.changes = append(dao.changes, change)
+	dao.committed = append(dao.committed, change)
+}
+
+// journal 写入操作日志, discardUndone 时同时作废该用户已撤销的操作(产生新操作后不能再重做)
+func (dao *ScheduleDao) journal(userID int64, opType string, discardUndone bool) error {
+	if len(dao.changes) == 0 {
+		return nil
+	}
+	if discardUndone {
+		err := dao.conn().Model(&schedule.ScheduleOperation{}).
+			Where("user_id = ? AND state = ?", userID, schedule.OpStateUndone).
+			Update("state", schedule.OpStateDiscarded).Error
+		if err != nil {
+			log.Printf("作废已撤销操作失败: %v", err)
+			return err
+		}
+	}
+
+	op := &schedule.ScheduleOperation{
+		UserID:   userID,
+		OpType:   opType,
+		State:    schedule.OpStateDone,
+		Changes:  dao.changes,
+		CreateAt: time.Now(),
+		UpdateAt: time.Now(),
+	}
+	if err := dao.conn().Create(op).Error; err != nil {
+		log.Printf("记录操作日志失败: %v", err)
+		return err
+	}
+	dao.changes = nil
+	return nil
+}
+
+// UndoOperations 撤销用户最近的 steps 个操作, 任意一步失败则全部回滚
+func (dao *ScheduleDao) UndoOperations(userID int64, steps int) ([]schedule.ScheduleOperation, error) {
+	return dao.replayOperations(userID, steps, true)
+}
+
+// RedoOperations 重做用户最近撤销的 steps 个操作, 任意一步失败则全部回滚
+func (dao *ScheduleDao) RedoOperations(userID int64, steps int) ([]schedule.ScheduleOperation, error) {
+	return dao.replayOperations(userID, steps, false)
+}
+
+// replayOperations 按撤销/重做方向依次处理操作日志
+func (dao *ScheduleDao) replayOperations(userID int64, steps int, undo bool) ([]schedule.ScheduleOperation, error) {
+	var done []schedule.ScheduleOperation
+	err := dao.Transaction(func(tx *ScheduleDao) error {
+		for i := 0; i < steps; i++ {
+			op, err := tx.nextOperation(userID, undo)
+			if err != nil {
+				return err
+			}
+			if op == nil {
+				if i == 0 {
+					return ErrNoOperation
+				}
+				break
+			}
+			if err = tx.replayOperation(userID, op, undo); err != nil {
+				return fmt.Errorf("操作 %d: %w", op.ID, err)
+			}
+			done = append(done, *op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// nextOperation 获取下一个可撤销(最新的已执行操作)或可重做(最近撤销的操作)的操作
+func (dao *ScheduleDao) nextOperation(userID int64, undo bool) (*schedule.ScheduleOperation, error) {
+	var op schedule.ScheduleOperation
+	qw := dao.conn().Where("user_id = ?", userID)
+	if undo {
+		qw = qw.Where("state = ?", schedule.OpStateDone).Order("id DESC")
+	} else {
+		qw = qw.Where("state = ?", schedule.OpStateUndone).Order("id ASC")
+	}
+	if err := qw.First(&op).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询操作日志失败: %v", err)
+		return nil, err
+	}
+	return &op, nil
+}
+
+// replayOperation 撤销或重做单个操作, 执行后以实际写入的数据更新日志, 以便反向操作时校验版本
+func (dao *ScheduleDao) replayOperation(actorID int64, op *schedule.ScheduleOperation, undo bool) error {
+	n := len(op.Changes)
+	for i := 0; i < n; i++ {
+		idx := i
+		if undo {
+			idx = n - 1 - i
+		}
+		change := &op.Changes[idx]
+		expected, target := change.After, change.Before
+		if !undo {
+			expected, target = change.Before, change.After
+		}
+
+		result, err := dao.applyOperationChange(actorID, change.ScheduleID, expected, target)
+		if err != nil {
+			return err
+		}
+		if undo {
+			change.Before = result
+		} else {
+			change.After = result
+		}
+	}
+
+	op.State = schedule.OpStateUndone
+	if !undo {
+		op.State = schedule.OpStateDone
+	}
+	op.UpdateAt = time.Now()
+	if err := dao.conn().Select("state", "changes", "update_at").Updates(op).Error; err != nil {
+		log.Printf("更新操作日志失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyOperationChange 校验日程当前状态与 expected 一致后将其恢复为 target, 返回实际写入的数据
+func (dao *ScheduleDao) applyOperationChange(actorID, id int64, expected, target *schedule.Schedule) (*schedule.Schedule, error) {
+	rows, err := dao.GetSchedulesByIDs([]int64{id})
+	if err != nil {
+		return nil, err
+	}
+	var current *schedule.Schedule
+	if len(rows) > 0 {
+		current = &rows[0]
+	}
+	if (expected == nil) != (current == nil) || (current != nil && current.Version != expected.Version) {
+		return nil, ErrOperationStale
+	}
+
+	switch {
+	case target == nil:
+		if err = dao.DeleteScheduleVersion(id, current.Version); err != nil {
+			return nil, err
+		}
+		return nil, dao.RecordHistory(schedule.HistoryActionDelete, actorID, current, nil)
+	case current == nil:
+		row := *target
+		row.UpdateAt = time.Now()
+		if err = dao.CreateSchedule(&row); err != nil {
+			return nil, err
+		}
+		return &row, dao.RecordHistory(schedule.HistoryActionCreate, actorID, nil, &row)
+	default:
+		row := *target
+		row.CreateAt = current.CreateAt
+		row.UpdateAt = time.Now()
+		row.Version = current.Version
+		if err = dao.UpdateSchedule(&row); err != nil {
+			return nil, err
+		}
+		return &row, dao.RecordHistory(schedule.HistoryActionUpdate, actorID, current, &row)
+	}
+}
@@ -0,0 +1,170 @@
+package dao
+
+import (
+	"go-film-demo/model/schedule"
+	"go-film-demo/plugin/db"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// useDryRunDB 将全局连接替换为只生成 SQL 不执行的连接, 返回执行过的查询语句
+func useDryRunDB(t *testing.T) *[]string {
+	t.Helper()
+	conn, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=True",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		NamingStrategy:         schema.NamingStrategy{SingularTable: true},
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	capture := func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	_ = conn.Callback().Query().After("gorm:query").Register("test:capture_query", capture)
+	_ = conn.Callback().Update().After("gorm:update").Register("test:capture_update", capture)
+	_ = conn.Callback().Create().After("gorm:create").Register("test:capture_create", capture)
+	_ = conn.Callback().Raw().After("gorm:raw").Register("test:capture_raw", capture)
+
+	previous := db.Mdb
+	db.Mdb = conn
+	t.Cleanup(func() { db.Mdb = previous })
+	return &statements
+}
+
+func TestScheduleListLimitBypassesPageSizeCap(t *testing.T) {
+	statements := useDryRunDB(t)
+	dao := NewScheduleDao()
+
+	if _, err := dao.ScheduleListLimit(ScheduleRequestVo{UserID: 7}, 10001, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.ScheduleListLimit(ScheduleRequestVo{UserID: 7, Status: 2}, 501, true); err != nil {
+		t.Fatal(err)
+	}
+	dao.ScheduleList(ScheduleRequestVo{UserID: 7, Paging: PageInfo{Current: 1, PageSize: 10001}})
+
+	if len(*statements) != 3 {
+		t.Fatalf("执行了 %d 条查询: %v", len(*statements), *statements)
+	}
+	export, bulk, paged := (*statements)[0], (*statements)[1], (*statements)[2]
+	if !strings.Contains(export, "user_id = 7") || !strings.HasSuffix(export, "LIMIT 10001") {
+		t.Errorf("导出查询应当返回 10001 行: %s", export)
+	}
+	if strings.Contains(export, "FOR UPDATE") {
+		t.Errorf("导出查询不应加锁: %s", export)
+	}
+	if !strings.Contains(bulk, "status = 2") || !strings.HasSuffix(bulk, "LIMIT 501 FOR UPDATE") {
+		t.Errorf("批量操作查询应当加行锁并限制行数: %s", bulk)
+	}
+	if !strings.HasSuffix(paged, "LIMIT 100") {
+		t.Errorf("分页查询应当受 MaxPageSize 限制: %s", paged)
+	}
+}
+
+func TestRecordHistoryUsesScheduleVersion(t *testing.T) {
+	statements := useDryRunDB(t)
+	dao := NewScheduleDao()
+
+	created := &schedule.Schedule{UserID: 1, Content: "新日程"}
+	if err := dao.CreateSchedule(created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Version != 1 || len(*statements) != 1 {
+		t.Fatalf("新建日程版本号 = %d, 执行了 %v", created.Version, *statements)
+	}
+
+	// 恢复已删除的日程时版本号接在最新的历史版本之后
+	*statements = nil
+	restored := &schedule.Schedule{ID: 9, UserID: 1, Version: 3}
+	if err := dao.CreateSchedule(restored); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 2 || !strings.Contains((*statements)[0], "MAX(version)") ||
+		!strings.Contains((*statements)[0], "schedule_id = 9") {
+		t.Fatalf("恢复日程时应查询历史版本: %v", *statements)
+	}
+	if restored.Version != 1 {
+		t.Errorf("恢复日程的版本号应为最新历史版本加1, 实际为 %d", restored.Version)
+	}
+
+	*statements = nil
+	before := &schedule.Schedule{ID: 9, UserID: 1, Version: 3, Content: "旧"}
+	after := &schedule.Schedule{ID: 9, UserID: 1, Version: 4, Content: "新"}
+	if err := dao.RecordHistory(schedule.HistoryActionUpdate, 1, before, after); err != nil {
+		t.Fatal(err)
+	}
+	if err := dao.RecordHistory(schedule.HistoryActionDelete, 1, after, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 2 {
+		t.Fatalf("记录历史不应再查询最大版本号: %v", *statements)
+	}
+	if !strings.Contains((*statements)[0], "VALUES (9,4,") {
+		t.Errorf("更新历史应使用日程写入后的版本号 4: %s", (*statements)[0])
+	}
+	if !strings.Contains((*statements)[1], "VALUES (9,5,") {
+		t.Errorf("删除历史应使用删除前版本号加1: %s", (*statements)[1])
+	}
+
+	if err := dao.RecordHistory(schedule.HistoryActionUpdate, 1, nil, &schedule.Schedule{ID: 9}); err == nil {
+		t.Error("缺少版本号时应返回错误")
+	}
+}
+
+func TestJournalKeepsUndoneOperationsForSystemOps(t *testing.T) {
+	statements := useDryRunDB(t)
+	change := schedule.OperationChange{ScheduleID: 9, Before: &schedule.Schedule{ID: 9}, After: &schedule.Schedule{ID: 9}}
+
+	for _, tc := range []struct {
+		name          string
+		discardUndone bool
+	}{{"用户操作", true}, {"系统操作", false}} {
+		*statements = nil
+		tx := &ScheduleDao{tx: db.Mdb, changes: []schedule.OperationChange{change}}
+		if err := tx.journal(3, schedule.OpRollover, tc.discardUndone); err != nil {
+			t.Fatal(err)
+		}
+		var discarded bool
+		for _, sql := range *statements {
+			if strings.HasPrefix(sql, "UPDATE `schedule_operation`") {
+				discarded = true
+			}
+		}
+		if discarded != tc.discardUndone || !strings.HasPrefix((*statements)[len(*statements)-1], "INSERT INTO `schedule_operation`") {
+			t.Errorf("%s: 作废已撤销操作 = %v, 执行了 %v", tc.name, discarded, *statements)
+		}
+	}
+}
+
+func TestDependencyReachableLocksTraversedRows(t *testing.T) {
+	statements := useDryRunDB(t)
+	dao := NewScheduleDao()
+
+	if _, err := dao.DependencyReachable(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.GetSchedulesByIDs([]int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 2 {
+		t.Fatalf("执行了 %v", *statements)
+	}
+	if bfs := (*statements)[0]; !strings.Contains(bfs, "predecessor_id IN (2)") || !strings.HasSuffix(bfs, "FOR SHARE") {
+		t.Errorf("检测循环依赖时应对依赖记录加共享锁: %s", bfs)
+	}
+	if lock := (*statements)[1]; !strings.Contains(lock, "id IN (1,2)") || !strings.HasSuffix(lock, "FOR UPDATE") {
+		t.Errorf("应按ID顺序锁定两端日程: %s", lock)
+	}
+}
@@ -0,0 +1,69 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/share"
+	"go-film-demo/plugin/db"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShareDao 分享链接数据访问对象
+type ShareDao struct {
+}
+
+// NewShareDao 创建分享链接DAO实例
+func NewShareDao() *ShareDao {
+	return &ShareDao{}
+}
+
+// CreateLink 创建分享链接
+func (dao *ShareDao) CreateLink(link *share.ShareLink) error {
+	if err := db.Mdb.Create(link).Error; err != nil {
+		log.Printf("创建分享链接失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeLink 撤销用户的分享链接
+func (dao *ShareDao) RevokeLink(id, userID int64) error {
+	now := time.Now()
+	result := db.Mdb.Model(&share.ShareLink{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Updates(map[string]interface{}{"revoked_at": now, "update_at": now})
+	if result.Error != nil {
+		log.Printf("撤销分享链接失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListLinks 获取用户的全部分享链接
+func (dao *ShareDao) ListLinks(userID int64) ([]share.ShareLink, error) {
+	var list []share.ShareLink
+	result := db.Mdb.Where("user_id = ?", userID).Order("id DESC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询分享链接失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// GetByToken 根据令牌获取分享链接, 不存在时返回 nil
+func (dao *ShareDao) GetByToken(token string) (*share.ShareLink, error) {
+	var link share.ShareLink
+	result := db.Mdb.Where("token = ?", token).First(&link)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询分享链接失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &link, nil
+}
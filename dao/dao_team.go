@@ -0,0 +1,177 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/team"
+	"go-film-demo/plugin/db"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ErrTeamExists 团队名称已被使用
+var ErrTeamExists = errors.New("团队名称已存在")
+
+// TeamDao 团队数据访问对象
+type TeamDao struct {
+}
+
+// NewTeamDao 创建团队DAO实例
+func NewTeamDao() *TeamDao {
+	return &TeamDao{}
+}
+
+// SaveTeam 创建或修改团队, 名称重复时返回 ErrTeamExists
+func (dao *TeamDao) SaveTeam(t *team.Team) error {
+	var count int64
+	if err := db.Mdb.Model(&team.Team{}).Where("name = ? AND id <> ?", t.Name, t.ID).Count(&count).Error; err != nil {
+		log.Printf("查询团队失败: %v", err)
+		return err
+	}
+	if count > 0 {
+		return ErrTeamExists
+	}
+
+	var result *gorm.DB
+	if t.ID == 0 {
+		result = db.Mdb.Create(t)
+	} else {
+		result = db.Mdb.Model(t).Select("name", "description", "update_at").Updates(t)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	if result.Error != nil {
+		log.Printf("保存团队失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteTeam 删除团队及其成员关系
+func (dao *TeamDao) DeleteTeam(id int64) error {
+	return db.Mdb.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&team.Team{}, id)
+		if result.Error != nil {
+			log.Printf("删除团队失败: %v", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("team_id = ?", id).Delete(&team.Member{}).Error
+	})
+}
+
+// GetTeam 根据ID获取团队, 不存在时返回 nil
+func (dao *TeamDao) GetTeam(id int64) (*team.Team, error) {
+	var t team.Team
+	if err := db.Mdb.First(&t, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询团队失败: %v", err)
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTeams 获取用户加入的团队, all 为 true 时返回全部团队
+func (dao *TeamDao) ListTeams(userID int64, all bool) ([]team.TeamInfo, error) {
+	query := db.Mdb.Table("team").
+		Select("team.*, m.role AS my_role, (SELECT COUNT(*) FROM team_member c WHERE c.team_id = team.id) AS member_count").
+		Joins("LEFT JOIN team_member m ON m.team_id = team.id AND m.user_id = ?", userID)
+	if !all {
+		query = query.Where("m.id IS NOT NULL")
+	}
+	var list []team.TeamInfo
+	if err := query.Order("team.id ASC").Scan(&list).Error; err != nil {
+		log.Printf("查询团队列表失败: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetMember 获取团队成员关系, 不是成员时返回 nil
+func (dao *TeamDao) GetMember(teamID, userID int64) (*team.Member, error) {
+	var m team.Member
+	if err := db.Mdb.Where("team_id = ? AND user_id = ?", teamID, userID).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询团队成员失败: %v", err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveMember 添加团队成员, 已是成员时修改团队角色
+func (dao *TeamDao) SaveMember(m *team.Member) error {
+	existing, err := dao.GetMember(m.TeamID, m.UserID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		m.ID, m.CreateAt = existing.ID, existing.CreateAt
+		err = db.Mdb.Model(existing).Update("role", m.Role).Error
+	} else {
+		err = db.Mdb.Create(m).Error
+	}
+	if err != nil {
+		log.Printf("保存团队成员失败: %v", err)
+	}
+	return err
+}
+
+// RemoveMember 移除团队成员
+func (dao *TeamDao) RemoveMember(teamID, userID int64) error {
+	result := db.Mdb.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&team.Member{})
+	if result.Error != nil {
+		log.Printf("移除团队成员失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListMembers 获取团队成员及其用户信息
+func (dao *TeamDao) ListMembers(teamID int64) ([]team.MemberInfo, error) {
+	var list []team.MemberInfo
+	err := db.Mdb.Table("team_member").
+		Select("team_member.*, u.username, u.nickname").
+		Joins("JOIN `user` u ON u.id = team_member.user_id").
+		Where("team_member.team_id = ?", teamID).
+		Order("team_member.role ASC, team_member.id ASC").
+		Scan(&list).Error
+	if err != nil {
+		log.Printf("查询团队成员失败: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
+
+// MemberUserIDs 获取团队全部成员的用户ID
+func (dao *TeamDao) MemberUserIDs(teamID int64) ([]int64, error) {
+	var ids []int64
+	if err := db.Mdb.Model(&team.Member{}).Where("team_id = ?", teamID).Pluck("user_id", &ids).Error; err != nil {
+		log.Printf("查询团队成员失败: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// IsLeadOf 判断 leadID 是否为 memberID 所在任一团队的负责人
+func (dao *TeamDao) IsLeadOf(leadID, memberID int64) (bool, error) {
+	var count int64
+	err := db.Mdb.Table("team_member l").
+		Joins("JOIN team_member m ON m.team_id = l.team_id").
+		Where("l.user_id = ? AND l.role = ? AND m.user_id = ?", leadID, team.RoleLead, memberID).
+		Count(&count).Error
+	if err != nil {
+		log.Printf("查询团队成员失败: %v", err)
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,362 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/system"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/db"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUserExists 用户名已被注册
+var ErrUserExists = errors.New("用户名已存在")
+
+// UserDao 用户数据访问对象
+type UserDao struct {
+}
+
+// NewUserDao 创建用户DAO实例
+func NewUserDao() *UserDao {
+	return &UserDao{}
+}
+
+// CreateUser 创建用户, 用户名重复时返回 ErrUserExists
+func (dao *UserDao) CreateUser(u *user.User) error {
+	existing, err := dao.GetUserByUsername(u.Username)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrUserExists
+	}
+	if err = db.Mdb.Create(u).Error; err != nil {
+		log.Printf("创建用户失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetUserByUsername 根据用户名获取用户, 不存在时返回 nil
+func (dao *UserDao) GetUserByUsername(username string) (*user.User, error) {
+	var u user.User
+	if err := db.Mdb.Where("username = ?", username).First(&u).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询用户失败: %v", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetUserByID 根据ID获取用户, 不存在时返回 nil
+func (dao *UserDao) GetUserByID(id int64) (*user.User, error) {
+	var u user.User
+	if err := db.Mdb.First(&u, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询用户失败: %v", err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+// UpdatePassword 更新用户密码哈希
+func (dao *UserDao) UpdatePassword(id int64, hash string) error {
+	result := db.Mdb.Model(&user.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"password_hash": hash, "update_at": time.Now()})
+	if result.Error != nil {
+		log.Printf("更新用户密码失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// TouchLogin 记录最近登录时间
+func (dao *UserDao) TouchLogin(id int64, at time.Time) {
+	if err := db.Mdb.Model(&user.User{}).Where("id = ?", id).Update("last_login_at", at).Error; err != nil {
+		log.Printf("更新最近登录时间失败: %v", err)
+	}
+}
+
+// SaveRefreshToken 保存已签发的刷新令牌
+func (dao *UserDao) SaveRefreshToken(t *user.RefreshToken) error {
+	if err := db.Mdb.Create(t).Error; err != nil {
+		log.Printf("保存刷新令牌失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRefreshToken 根据令牌ID获取刷新令牌, 不存在时返回 nil
+func (dao *UserDao) GetRefreshToken(tokenID string) (*user.RefreshToken, error) {
+	var t user.RefreshToken
+	if err := db.Mdb.Where("token_id = ?", tokenID).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询刷新令牌失败: %v", err)
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RevokeRefreshToken 撤销刷新令牌, 返回是否由本次调用撤销(并发刷新时只有一个请求成功)
+func (dao *UserDao) RevokeRefreshToken(tokenID string) (bool, error) {
+	result := db.Mdb.Model(&user.RefreshToken{}).Where("token_id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("撤销刷新令牌失败: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeUserRefreshTokens 撤销用户全部未撤销的刷新令牌
+func (dao *UserDao) RevokeUserRefreshTokens(userID int64) error {
+	result := db.Mdb.Model(&user.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("撤销用户刷新令牌失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// CreateAPIToken 保存个人访问令牌
+func (dao *UserDao) CreateAPIToken(t *user.APIToken) error {
+	if err := db.Mdb.Create(t).Error; err != nil {
+		log.Printf("保存个人访问令牌失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetAPITokenByHash 根据令牌哈希获取个人访问令牌, 不存在时返回 nil
+func (dao *UserDao) GetAPITokenByHash(hash string) (*user.APIToken, error) {
+	var t user.APIToken
+	if err := db.Mdb.Where("token_hash = ?", hash).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询个人访问令牌失败: %v", err)
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListAPITokens 获取用户的个人访问令牌, 包含已撤销与已过期的令牌
+func (dao *UserDao) ListAPITokens(userID int64) ([]user.APIToken, error) {
+	var list []user.APIToken
+	if err := db.Mdb.Where("user_id = ?", userID).Order("id DESC").Find(&list).Error; err != nil {
+		log.Printf("查询个人访问令牌失败: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
+
+// CountActiveAPITokens 统计用户未撤销且未过期的个人访问令牌数
+func (dao *UserDao) CountActiveAPITokens(userID int64, now time.Time) (int64, error) {
+	var count int64
+	err := db.Mdb.Model(&user.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expire_at IS NULL OR expire_at > ?)", userID, now).
+		Count(&count).Error
+	if err != nil {
+		log.Printf("统计个人访问令牌失败: %v", err)
+	}
+	return count, err
+}
+
+// RevokeAPIToken 撤销用户的个人访问令牌
+func (dao *UserDao) RevokeAPIToken(id, userID int64) error {
+	result := db.Mdb.Model(&user.APIToken{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("撤销个人访问令牌失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchAPIToken 记录个人访问令牌最近使用时间, 距上次记录不足 interval 时跳过以减少写入
+func (dao *UserDao) TouchAPIToken(id int64, at time.Time, interval time.Duration) {
+	err := db.Mdb.Model(&user.APIToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-interval)).
+		Update("last_used_at", at).Error
+	if err != nil {
+		log.Printf("更新个人访问令牌使用时间失败: %v", err)
+	}
+}
+
+// UserPage 分页查询用户, keyword 非空时按用户名、昵称模糊查询
+func (dao *UserDao) UserPage(keyword string, paging PageInfo) ([]user.User, *system.Page, error) {
+	query := db.Mdb.Model(&user.User{})
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("(username LIKE ? OR nickname LIKE ?)", like, like)
+	}
+	return Paginate[user.User](query, paging, map[string]SortField{"id": {Column: "id"}}, "id", false)
+}
+
+// UpdateRole 修改用户角色
+func (dao *UserDao) UpdateRole(id int64, role string) error {
+	result := db.Mdb.Model(&user.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"role": role, "update_at": time.Now()})
+	if result.Error != nil {
+		log.Printf("修改用户角色失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetIdentity 根据提供方与提供方用户标识获取绑定关系, 不存在时返回 nil
+func (dao *UserDao) GetIdentity(issuer, subject string) (*user.Identity, error) {
+	var identity user.Identity
+	if err := db.Mdb.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询外部身份失败: %v", err)
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateUserWithIdentity 在同一事务中创建用户与外部身份绑定关系
+func (dao *UserDao) CreateUserWithIdentity(u *user.User, identity *user.Identity) error {
+	return db.Mdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			log.Printf("创建用户失败: %v", err)
+			return err
+		}
+		identity.UserID = u.ID
+		if err := tx.Create(identity).Error; err != nil {
+			log.Printf("保存外部身份失败: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// TouchIdentity 更新外部身份最近一次登录时的邮箱
+func (dao *UserDao) TouchIdentity(id int64, email string) {
+	if err := db.Mdb.Model(&user.Identity{}).Where("id = ?", id).Update("email", email).Error; err != nil {
+		log.Printf("更新外部身份失败: %v", err)
+	}
+}
+
+// SaveTOTPSecret 保存待启用的 TOTP 密钥, 已启用两步验证时不修改
+func (dao *UserDao) SaveTOTPSecret(id int64, secret string) error {
+	result := db.Mdb.Model(&user.User{}).Where("id = ? AND totp_enabled = ?", id, false).
+		Updates(map[string]interface{}{"totp_secret": secret, "update_at": time.Now()})
+	if result.Error != nil {
+		log.Printf("保存TOTP密钥失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EnableTOTP 在同一事务中启用两步验证并替换恢复码, step 为本次验证使用的时间步
+func (dao *UserDao) EnableTOTP(id, step int64, codeHashes []string) error {
+	return db.Mdb.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user.User{}).Where("id = ? AND totp_enabled = ?", id, false).
+			Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step, "update_at": time.Now()})
+		if result.Error != nil {
+			log.Printf("启用两步验证失败: %v", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, id, codeHashes)
+	})
+}
+
+// DisableTOTP 在同一事务中关闭两步验证、清除密钥并删除恢复码
+func (dao *UserDao) DisableTOTP(id int64) error {
+	return db.Mdb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&user.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"totp_secret": "", "totp_enabled": false, "totp_last_step": 0, "update_at": time.Now(),
+		}).Error
+		if err != nil {
+			log.Printf("关闭两步验证失败: %v", err)
+			return err
+		}
+		if err = tx.Where("user_id = ?", id).Delete(&user.RecoveryCode{}).Error; err != nil {
+			log.Printf("删除恢复码失败: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// UseTOTPStep 记录已使用的 TOTP 时间步, 返回是否由本次调用记录; 同一时间步或更早的验证码不能再次使用
+func (dao *UserDao) UseTOTPStep(id, step int64) (bool, error) {
+	result := db.Mdb.Model(&user.User{}).Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		log.Printf("记录TOTP时间步失败: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes 删除用户的全部恢复码并保存新的恢复码
+func (dao *UserDao) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	return db.Mdb.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// UseRecoveryCode 使用恢复码, 返回是否由本次调用使用(恢复码不存在或已使用时返回 false)
+func (dao *UserDao) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	result := db.Mdb.Model(&user.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("使用恢复码失败: %v", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes 统计用户未使用的恢复码数量
+func (dao *UserDao) CountRecoveryCodes(userID int64) (int64, error) {
+	var count int64
+	if err := db.Mdb.Model(&user.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		log.Printf("统计恢复码失败: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID int64, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&user.RecoveryCode{}).Error; err != nil {
+		log.Printf("删除恢复码失败: %v", err)
+		return err
+	}
+	now := time.Now()
+	codes := make([]user.RecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, user.RecoveryCode{UserID: userID, CodeHash: h, CreateAt: now})
+	}
+	if err := tx.Create(&codes).Error; err != nil {
+		log.Printf("保存恢复码失败: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package dao
+
+import (
+	"errors"
+	"go-film-demo/model/system"
+	"go-film-demo/model/webhook"
+	"go-film-demo/plugin/db"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookDao Webhook 订阅与投递记录数据访问对象
+type WebhookDao struct {
+}
+
+// NewWebhookDao 创建 Webhook DAO实例
+func NewWebhookDao() *WebhookDao {
+	return &WebhookDao{}
+}
+
+// SaveSubscription 创建或更新订阅, 更新时密钥为空则保留原密钥
+func (dao *WebhookDao) SaveSubscription(sub *webhook.Subscription) error {
+	var result *gorm.DB
+	if sub.ID == 0 {
+		result = db.Mdb.Create(sub)
+	} else {
+		fields := []string{"url", "events", "enabled", "update_at"}
+		if sub.Secret != "" {
+			fields = append(fields, "secret")
+		}
+		result = db.Mdb.Model(sub).Where("user_id = ?", sub.UserID).Select(fields).Updates(sub)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	if result.Error != nil {
+		log.Printf("保存 Webhook 订阅失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteSubscription 删除用户的订阅
+func (dao *WebhookDao) DeleteSubscription(id, userID int64) error {
+	result := db.Mdb.Where("id = ? AND user_id = ?", id, userID).Delete(&webhook.Subscription{})
+	if result.Error != nil {
+		log.Printf("删除 Webhook 订阅失败: %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetSubscription 根据ID获取订阅, 不存在时返回 nil
+func (dao *WebhookDao) GetSubscription(id int64) (*webhook.Subscription, error) {
+	var sub webhook.Subscription
+	result := db.Mdb.First(&sub, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询 Webhook 订阅失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions 获取用户的全部订阅
+func (dao *WebhookDao) ListSubscriptions(userID int64) ([]webhook.Subscription, error) {
+	var list []webhook.Subscription
+	result := db.Mdb.Where("user_id = ?", userID).Order("id ASC").Find(&list)
+	if result.Error != nil {
+		log.Printf("查询 Webhook 订阅失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// ListSubscribers 获取订阅了事件的已启用订阅, userID 为 0 时不限用户
+func (dao *WebhookDao) ListSubscribers(userID int64, event string) ([]webhook.Subscription, error) {
+	var all []webhook.Subscription
+	query := db.Mdb.Where("enabled = ?", true)
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if result := query.Order("id ASC").Find(&all); result.Error != nil {
+		log.Printf("查询 Webhook 订阅失败: %v", result.Error)
+		return nil, result.Error
+	}
+	list := make([]webhook.Subscription, 0, len(all))
+	for _, sub := range all {
+		if sub.Subscribes(event) {
+			list = append(list, sub)
+		}
+	}
+	return list, nil
+}
+
+// CreateDeliveries 批量写入待投递记录
+func (dao *WebhookDao) CreateDeliveries(list []webhook.Delivery) error {
+	if len(list) == 0 {
+		return nil
+	}
+	if err := db.Mdb.Create(&list).Error; err != nil {
+		log.Printf("写入 Webhook 投递记录失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetDelivery 根据ID获取投递记录, 不存在时返回 nil
+func (dao *WebhookDao) GetDelivery(id int64) (*webhook.Delivery, error) {
+	var d webhook.Delivery
+	result := db.Mdb.First(&d, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("查询 Webhook 投递记录失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return &d, nil
+}
+
+// ListDueDeliveries 获取已到投递时间的待投递记录
+func (dao *WebhookDao) ListDueDeliveries(now time.Time, limit int) ([]webhook.Delivery, error) {
+	var list []webhook.Delivery
+	result := db.Mdb.Where("status = ? AND next_attempt_at <= ?", webhook.DeliveryPending, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&list)
+	if result.Error != nil {
+		log.Printf("查询待投递 Webhook 失败: %v", result.Error)
+		return nil, result.Error
+	}
+	return list, nil
+}
+
+// UpdateDeliveryResult 更新投递结果
+func (dao *WebhookDao) UpdateDeliveryResult(d *webhook.Delivery) error {
+	result := db.Mdb.Model(d).Select("status", "attempts", "next_attempt_at", "response_status",
+		"response_body", "last_error", "delivered_at", "update_at").Updates(d)
+	if result.Error != nil {
+		log.Printf("更新 Webhook 投递结果失败: %v", result.Error)
+		return result.Error
+	}
+	return nil
+}
+
+// DeliveryPage 分页查询订阅的投递记录, 按ID倒序
+func (dao *WebhookDao) DeliveryPage(subscriptionID int64, status string, paging PageInfo) ([]webhook.Delivery, *system.Page, error) {
+	query := db.Mdb.Model(&webhook.Delivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return Paginate[webhook.Delivery](query, paging, map[string]SortField{"id": {Column: "id"}}, "id", true)
+}
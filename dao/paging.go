@@ -0,0 +1,170 @@
+package dao
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-film-demo/model/system"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 20  // 默认每页大小
+	MaxPageSize     = 100 // 每页最大数量
+)
+
+// ErrInvalidCursor 游标格式错误或与排序字段不匹配
+var ErrInvalidCursor = errors.New("非法的分页游标")
+
+// SortField 可排序字段
+type SortField struct {
+	Column string // 数据库列名
+	IsTime bool   // 是否为时间类型, 用于游标解析
+}
+
+// pageCursor 游标内容, 记录上一页最后一行的排序值与主键
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// normalize 校正分页参数
+func (p *PageInfo) normalize() {
+	if p.PageSize <= 0 {
+		p.PageSize = DefaultPageSize
+	}
+	if p.PageSize > MaxPageSize {
+		p.PageSize = MaxPageSize
+	}
+	if p.Current <= 0 {
+		p.Current = 1
+	}
+}
+
+// Paginate 分页查询, Cursor 非空时使用游标(keyset)分页, 否则按页码分页; 排序字段相同时按主键排序保证顺序稳定
+func Paginate[T any](query *gorm.DB, paging PageInfo, fields map[string]SortField, defaultSort string, defaultDesc bool) ([]T, *system.Page, error) {
+	paging.normalize()
+	sortBy, desc := paging.SortBy, paging.Desc
+	if sortBy == "" {
+		sortBy, desc = defaultSort, defaultDesc
+	}
+	field, ok := fields[sortBy]
+	if !ok {
+		return nil, nil, fmt.Errorf("不支持的排序字段: %s", sortBy)
+	}
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	base := query.Session(&gorm.Session{})
+	page := &system.Page{PageSize: paging.PageSize, Current: paging.Current}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+	page.Total = int(total)
+	page.PageCount = (page.Total + page.PageSize - 1) / page.PageSize
+
+	qw := base.Order(fmt.Sprintf("%s %s, id %s", field.Column, direction, direction))
+	if paging.Cursor != "" {
+		cursor, err := decodeCursor(paging.Cursor, sortBy, field)
+		if err != nil {
+			return nil, nil, err
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		qw = qw.Where(fmt.Sprintf("(%s %s ?) OR (%s = ? AND id %s ?)", field.Column, op, field.Column, op),
+			cursor.Value, cursor.Value, cursor.ID)
+		page.Current = 0
+	} else {
+		qw = qw.Offset((paging.Current - 1) * paging.PageSize)
+	}
+
+	var list []T
+	if err := qw.Limit(paging.PageSize + 1).Find(&list).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(list) > paging.PageSize {
+		list = list[:paging.PageSize]
+		next, err := encodeCursor(query, &list[len(list)-1], sortBy, field)
+		if err != nil {
+			return nil, nil, err
+		}
+		page.NextCursor = next
+	}
+	return list, page, nil
+}
+
+// PagingData 将分页结果转换为通用分页返回格式
+func PagingData[T any](list []T, page *system.Page) system.PagingData {
+	data := system.PagingData{List: make([]any, 0, len(list))}
+	for _, item := range list {
+		data.List = append(data.List, item)
+	}
+	if page != nil {
+		data.Paging = *page
+	}
+	return data
+}
+
+// encodeCursor 根据行数据生成下一页游标
+func encodeCursor[T any](query *gorm.DB, row *T, sortBy string, field SortField) (string, error) {
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(row); err != nil {
+		return "", err
+	}
+	sortField := stmt.Schema.LookUpField(field.Column)
+	idField := stmt.Schema.PrioritizedPrimaryField
+	if sortField == nil || idField == nil {
+		return "", ErrInvalidCursor
+	}
+	rv := reflect.ValueOf(row).Elem()
+	value, _ := sortField.ValueOf(context.Background(), rv)
+	idValue, _ := idField.ValueOf(context.Background(), rv)
+	id, ok := idValue.(int64)
+	if !ok {
+		return "", ErrInvalidCursor
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+	}
+
+	raw, err := json.Marshal(pageCursor{Sort: sortBy, Value: value, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor 解析游标, 游标必须由相同排序字段生成
+func decodeCursor(raw, sortBy string, field SortField) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor pageCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sortBy {
+		return nil, ErrInvalidCursor
+	}
+	if field.IsTime {
+		str, ok := cursor.Value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Value = t
+	}
+	return &cursor, nil
+}
@@ -15,30 +15,57 @@
 // @BasePath  /
 //
 // @schemes http https
+//
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 访问令牌, 格式为 "Bearer {token}"
 package main
 
 import (
 	"fmt"
 	"go-film-demo/config"
+	"go-film-demo/plugin/auth"
 	"go-film-demo/plugin/cron"
 	"go-film-demo/plugin/db"
+	"go-film-demo/plugin/digest"
+	"go-film-demo/plugin/hub"
+	"go-film-demo/plugin/notifier"
+	"go-film-demo/plugin/rollover"
 	"go-film-demo/plugin/spider"
+	"go-film-demo/plugin/webhooks"
 	"go-film-demo/router"
 	"log"
 )
 
-var cronManager = cron.NewCronManager()
+var cronManager = cron.Default
 
 func init() {
 	err := db.InitMysql()
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = cronManager.AddEvery10SecondsTask("collect-news-task-10s", spider.CollectNews)
+	if err = auth.BootstrapAdmin(); err != nil {
+		log.Fatal(err)
+	}
+	err = cronManager.AddEvery10SecondsTask("collect-news-task-10s", func() { spider.TryCollectNews() })
 	if err != nil {
 		log.Fatal(err)
 	}
 	cronManager.Start()
+	hub.Setup()
+	if err = digest.Setup(cronManager); err != nil {
+		log.Printf("注册每日简报任务失败: %v", err)
+	}
+	if err = notifier.Setup(cronManager); err != nil {
+		log.Printf("注册通知投递任务失败: %v", err)
+	}
+	if err = webhooks.Setup(cronManager); err != nil {
+		log.Printf("注册 Webhook 投递任务失败: %v", err)
+	}
+	if err = rollover.Setup(cronManager); err != nil {
+		log.Printf("注册逾期任务顺延任务失败: %v", err)
+	}
 }
 
 func main() {
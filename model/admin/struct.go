@@ -0,0 +1,5 @@
+package admin
+
+type CronRunReq struct {
+	Name string `json:"name" binding:"required"`
+}
@@ -0,0 +1,38 @@
+package briefing
+
+import (
+	"go-film-demo/model/news"
+	"go-film-demo/model/schedule"
+	"time"
+)
+
+// Briefing 每日简报: 当天日程(按优先级排序)与当天阅读量最高的新闻
+type Briefing struct {
+	UserID      int64               `json:"user_id"`
+	Date        string              `json:"date"` // YYYY-MM-DD
+	GeneratedAt time.Time           `json:"generated_at"`
+	Schedules   []schedule.Schedule `json:"schedules"`
+	News        []news.News         `json:"news"`
+}
+
+// BriefingSetting 用户的每日简报设置, 启用后每天在 SendTime 预生成简报
+type BriefingSetting struct {
+	ID       int64     `gorm:"column:id;primaryKey;autoIncrement;comment:设置ID" json:"id"`
+	UserID   int64     `gorm:"column:user_id;not null;uniqueIndex:uk_user_id;comment:用户ID" json:"user_id"`
+	SendTime string    `gorm:"column:send_time;type:varchar(5);default:'07:30';not null;comment:每日生成时间(HH:MM)" json:"send_time"`
+	Enabled  bool      `gorm:"column:enabled;not null;comment:是否启用" json:"enabled"`
+	CreateAt time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt time.Time `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (BriefingSetting) TableName() string {
+	return "briefing_setting"
+}
+
+// 简报输出格式
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
@@ -0,0 +1,14 @@
+package briefing
+
+type QueryReq struct {
+	UserID  int64  `form:"-" json:"-"`
+	Date    string `form:"date" json:"date"`       // YYYY-MM-DD, 默认今天
+	Format  string `form:"format" json:"format"`   // json/markdown/html, 默认 json
+	Refresh bool   `form:"refresh" json:"refresh"` // 忽略预生成的缓存重新生成
+}
+
+type SettingReq struct {
+	UserID   int64  `json:"-"`
+	SendTime string `json:"send_time"` // HH:MM, 默认使用配置 BRIEFING_TIME
+	Enabled  bool   `json:"enabled"`
+}
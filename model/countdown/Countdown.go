@@ -0,0 +1,162 @@
+package countdown
+
+import (
+	"errors"
+	"go-film-demo/plugin/lunar"
+	"time"
+)
+
+// Countdown 倒数日/纪念日, 不占用时间段, 只关注日期
+type Countdown struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement;comment:倒数日ID" json:"id"`
+	UserID    int64     `gorm:"column:user_id;default:0;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	Title     string    `gorm:"column:title;type:varchar(200);not null;comment:标题" json:"title"`
+	Kind      string    `gorm:"column:kind;type:varchar(20);default:'countdown';not null;comment:类型(countdown-倒数日,anniversary-每年重复的纪念日)" json:"kind"`
+	Calendar  string    `gorm:"column:calendar;type:varchar(10);default:'solar';not null;comment:历法(solar-公历,lunar-农历)" json:"calendar"`
+	Year      int       `gorm:"column:year;default:0;not null;comment:年(纪念日可为0)" json:"year"`
+	Month     int       `gorm:"column:month;not null;comment:月" json:"month"`
+	Day       int       `gorm:"column:day;not null;comment:日" json:"day"`
+	LeapMonth bool      `gorm:"column:leap_month;default:false;not null;comment:是否农历闰月" json:"leap_month"`
+	Remark    string    `gorm:"column:remark;type:varchar(500);default:'';not null;comment:备注" json:"remark"`
+	CreateAt  time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt  time.Time `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (Countdown) TableName() string {
+	return "countdown"
+}
+
+// 常量定义
+const (
+	KindCountdown   = "countdown"   // 倒数日, 只发生一次
+	KindAnniversary = "anniversary" // 纪念日, 每年重复
+
+	CalendarSolar = "solar" // 公历
+	CalendarLunar = "lunar" // 农历
+)
+
+// Occurrence 倒数日的一次发生
+type Occurrence struct {
+	Countdown
+	Date     string `json:"date"`      // 发生日期(公历), YYYY-MM-DD
+	DaysLeft int    `json:"days_left"` // 距今天数, 0 表示今天
+	Years    int    `json:"years"`     // 纪念日周年数, 未设置起始年份时为0
+}
+
+// Validate 校验倒数日数据
+func (c *Countdown) Validate() error {
+	if c.Title == "" {
+		return errors.New("标题不能为空")
+	}
+	if c.Kind != KindCountdown && c.Kind != KindAnniversary {
+		return errors.New("类型只能为 countdown 或 anniversary")
+	}
+	if c.Calendar != CalendarSolar && c.Calendar != CalendarLunar {
+		return errors.New("历法只能为 solar 或 lunar")
+	}
+	if c.Kind == KindCountdown && c.Year == 0 {
+		return errors.New("倒数日必须指定年份")
+	}
+	if c.Calendar == CalendarSolar {
+		if c.LeapMonth {
+			return errors.New("公历日期没有闰月")
+		}
+		year := c.Year
+		if year == 0 {
+			year = 2000 // 闰年, 允许 2月29日
+		}
+		t := time.Date(year, time.Month(c.Month), c.Day, 0, 0, 0, 0, time.Local)
+		if c.Month < 1 || c.Month > 12 || t.Day() != c.Day {
+			return errors.New("公历日期不存在")
+		}
+		return nil
+	}
+	if c.Month < 1 || c.Month > 12 || c.Day < 1 || c.Day > 30 {
+		return lunar.ErrInvalidDate
+	}
+	if c.Year != 0 {
+		_, err := lunar.ToSolar(lunar.Date{Year: c.Year, Month: c.Month, Day: c.Day, Leap: c.LeapMonth}, time.Local)
+		return err
+	}
+	return nil
+}
+
+// NextOccurrence 返回 from 当天及之后的第一次发生日期, 倒数日已过期时返回 false
+func (c *Countdown) NextOccurrence(from time.Time) (time.Time, bool) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	if c.Kind == KindCountdown {
+		date, err := c.dateInYear(c.Year, from.Location())
+		if err != nil || date.Before(from) {
+			return time.Time{}, false
+		}
+		return date, true
+	}
+
+	// 农历年与公历年存在错位, 从上一年开始查找; 起始年份在之后时从起始年份开始
+	start := max(from.Year()-1, c.Year)
+	for year := start; year <= start+2; year++ {
+		date, err := c.dateInYear(year, from.Location())
+		if err == nil && !date.Before(from) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// OccurrencesBetween 返回 [from, to) 内的全部发生日期, 纪念日在跨年的区间内每年各发生一次
+func (c *Countdown) OccurrencesBetween(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for {
+		date, ok := c.NextOccurrence(from)
+		if !ok || !date.Before(to) {
+			return dates
+		}
+		dates = append(dates, date)
+		from = date.AddDate(0, 0, 1)
+	}
+}
+
+// OccurrenceOn 计算相对于 today 的发生信息
+func (c *Countdown) OccurrenceOn(date, today time.Time) Occurrence {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	o := Occurrence{
+		Countdown: *c,
+		Date:      date.Format("2006-01-02"),
+		DaysLeft:  int(date.Sub(today).Hours()/24 + 0.5),
+	}
+	if c.Kind == KindAnniversary && c.Year != 0 {
+		o.Years = date.Year() - c.Year
+		if c.Calendar == CalendarLunar {
+			if l, err := lunar.FromSolar(date); err == nil {
+				o.Years = l.Year - c.Year
+			}
+		}
+	}
+	return o
+}
+
+// dateInYear 计算指定年份(农历为农历年)的公历日期
+// 公历2月29日在平年取2月28日, 农历三十在小月取廿九, 纪念日的闰月在没有该闰月的年份按普通月份计算
+func (c *Countdown) dateInYear(year int, loc *time.Location) (time.Time, error) {
+	if c.Calendar == CalendarSolar {
+		day := c.Day
+		if last := time.Date(year, time.Month(c.Month)+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+			day = last
+		}
+		return time.Date(year, time.Month(c.Month), day, 0, 0, 0, 0, loc), nil
+	}
+
+	if year < lunar.MinYear || year > lunar.MaxYear {
+		return time.Time{}, lunar.ErrOutOfRange
+	}
+	leap := c.LeapMonth && lunar.LeapMonth(year) == c.Month
+	if c.Kind == KindCountdown {
+		leap = c.LeapMonth
+	}
+	d := lunar.Date{Year: year, Month: c.Month, Day: c.Day, Leap: leap}
+	if !leap && d.Day > lunar.MonthDays(year, d.Month) {
+		d.Day = lunar.MonthDays(year, d.Month)
+	}
+	return lunar.ToSolar(d, loc)
+}
@@ -0,0 +1,67 @@
+package countdown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOccurrencesBetween(t *testing.T) {
+	day := func(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local) }
+	format := func(dates []time.Time) []string {
+		list := make([]string, 0, len(dates))
+		for _, d := range dates {
+			list = append(list, d.Format("2006-01-02"))
+		}
+		return list
+	}
+
+	for _, tc := range []struct {
+		name     string
+		c        Countdown
+		from, to time.Time
+		want     []string
+	}{
+		{
+			name: "每年重复的纪念日在多年区间内逐年返回",
+			c:    Countdown{Kind: KindAnniversary, Calendar: CalendarSolar, Month: 3, Day: 8},
+			from: day(2024, 1, 1), to: day(2027, 1, 1),
+			want: []string{"2024-03-08", "2025-03-08", "2026-03-08"},
+		},
+		{
+			name: "区间右端不包含",
+			c:    Countdown{Kind: KindAnniversary, Calendar: CalendarSolar, Month: 3, Day: 8},
+			from: day(2024, 3, 8), to: day(2025, 3, 8),
+			want: []string{"2024-03-08"},
+		},
+		{
+			name: "起始年份之前不发生",
+			c:    Countdown{Kind: KindAnniversary, Calendar: CalendarSolar, Year: 2025, Month: 6, Day: 1},
+			from: day(2023, 1, 1), to: day(2027, 1, 1),
+			want: []string{"2025-06-01", "2026-06-01"},
+		},
+		{
+			name: "倒数日只发生一次",
+			c:    Countdown{Kind: KindCountdown, Calendar: CalendarSolar, Year: 2025, Month: 10, Day: 1},
+			from: day(2024, 1, 1), to: day(2030, 1, 1),
+			want: []string{"2025-10-01"},
+		},
+		{
+			name: "农历纪念日逐年换算",
+			c:    Countdown{Kind: KindAnniversary, Calendar: CalendarLunar, Month: 1, Day: 1},
+			from: day(2024, 1, 1), to: day(2026, 12, 31),
+			want: []string{"2024-02-10", "2025-01-29", "2026-02-17"},
+		},
+	} {
+		got := format(tc.c.OccurrencesBetween(tc.from, tc.to))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
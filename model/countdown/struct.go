@@ -0,0 +1,28 @@
+package countdown
+
+type StoreReq struct {
+	UserID    int64  `json:"-"`
+	Title     string `json:"title" binding:"required"`
+	Kind      string `json:"kind"`     // countdown/anniversary, 默认 countdown
+	Calendar  string `json:"calendar"` // solar/lunar, 默认 solar
+	Year      int    `json:"year"`
+	Month     int    `json:"month" binding:"required"`
+	Day       int    `json:"day" binding:"required"`
+	LeapMonth bool   `json:"leap_month"`
+	Remark    string `json:"remark"`
+}
+
+type UpdateReq struct {
+	ID int64 `json:"id" binding:"required"`
+	StoreReq
+}
+
+type DeleteReq struct {
+	ID     int64 `json:"id" binding:"required"`
+	UserID int64 `json:"-"`
+}
+
+type UpcomingReq struct {
+	UserID int64 `json:"-"`
+	Days   int   `json:"days"` // 查询未来多少天, 默认365, 最大3660
+}
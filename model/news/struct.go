@@ -3,3 +3,15 @@ package news
 type QueryReq struct {
 	Date string `json:"date"`
 }
+
+type PageReq struct {
+	Date    string `json:"date"` // 发布日期, YYYY-MM-DD
+	Keyword string `json:"keyword"`
+	Source  string `json:"source"`
+	Creator string `json:"creator"`
+	Page    int    `json:"page"`   // 页码, 从1开始
+	Size    int    `json:"size"`   // 每页大小, 默认20, 最大100
+	Cursor  string `json:"cursor"` // 游标, 传入上一页返回的 nextCursor 时使用游标分页
+	Sort    string `json:"sort"`   // 排序字段: publish_time/created_at/read_num/comment_num/like_num/id
+	Order   string `json:"order"`  // 排序方向: asc/desc
+}
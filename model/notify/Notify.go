@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"strings"
+	"time"
+)
+
+// Channel 通知渠道, 如邮箱地址或群机器人; UserID 为 0 表示团队共享渠道, 只接收广播事件(如新闻速递)
+type Channel struct {
+	ID       int64     `gorm:"column:id;primaryKey;autoIncrement;comment:渠道ID" json:"id"`
+	UserID   int64     `gorm:"column:user_id;default:0;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	Type     string    `gorm:"column:type;type:varchar(20);not null;comment:渠道类型" json:"type"`
+	Name     string    `gorm:"column:name;type:varchar(100);default:'';not null;comment:渠道名称" json:"name"`
+	Target   string    `gorm:"column:target;type:varchar(500);not null;comment:接收地址" json:"target"`
+	Secret   string    `gorm:"column:secret;type:varchar(255);default:'';not null;comment:签名密钥" json:"-"`
+	Events   string    `gorm:"column:events;type:varchar(255);default:'';not null;comment:订阅事件(逗号分隔)" json:"events"`
+	Enabled  bool      `gorm:"column:enabled;not null;comment:是否启用" json:"enabled"`
+	CreateAt time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt time.Time `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (Channel) TableName() string {
+	return "notify_channel"
+}
+
+// Subscribes 判断渠道是否订阅了事件, 未配置订阅事件时默认接收日程提醒与每日简报
+func (c *Channel) Subscribes(event string) bool {
+	if event == EventTest {
+		return true
+	}
+	if c.Events == "" {
+		return event == EventReminder || event == EventBriefing
+	}
+	for _, e := range strings.Split(c.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery 通知投递记录, 同时作为投递队列与投递日志
+type Delivery struct {
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement;comment:投递ID" json:"id"`
+	UserID        int64      `gorm:"column:user_id;default:0;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	ChannelID     int64      `gorm:"column:channel_id;not null;comment:渠道ID" json:"channel_id"`
+	ChannelType   string     `gorm:"column:channel_type;type:varchar(20);not null;comment:渠道类型" json:"channel_type"`
+	Event         string     `gorm:"column:event;type:varchar(50);not null;comment:通知事件" json:"event"`
+	DedupeKey     string     `gorm:"column:dedupe_key;type:varchar(191);not null;uniqueIndex:uk_dedupe_key;comment:去重键" json:"dedupe_key"`
+	Subject       string     `gorm:"column:subject;type:varchar(255);default:'';not null;comment:标题" json:"subject"`
+	Payload       *Message   `gorm:"column:payload;type:longtext;serializer:json;comment:消息内容" json:"-"`
+	Status        string     `gorm:"column:status;type:varchar(20);not null;index:idx_status_next;comment:状态(pending/sent/failed)" json:"status"`
+	Attempts      int        `gorm:"column:attempts;default:0;not null;comment:已尝试次数" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_status_next;comment:下次尝试时间" json:"next_attempt_at"`
+	LastError     string     `gorm:"column:last_error;type:varchar(1000);default:'';not null;comment:最近一次错误" json:"last_error"`
+	SentAt        *time.Time `gorm:"column:sent_at;comment:发送成功时间" json:"sent_at"`
+	CreateAt      time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt      time.Time  `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (Delivery) TableName() string {
+	return "notify_delivery"
+}
+
+// Message 通知消息, 各渠道按自身能力选择使用的内容
+type Message struct {
+	Subject     string       `json:"subject"`
+	Text        string       `json:"text"`     // 纯文本正文
+	HTML        string       `json:"html"`     // HTML 正文(邮件)
+	Markdown    string       `json:"markdown"` // Markdown 正文(机器人)
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// 渠道类型
+const (
+	ChannelEmail    = "email"
+	ChannelWeCom    = "wecom"    // 企业微信群机器人
+	ChannelDingTalk = "dingtalk" // 钉钉群机器人
+	ChannelFeishu   = "feishu"   // 飞书群机器人
+)
+
+// 通知事件
+const (
+	EventReminder = "schedule.reminder"
+	EventBriefing = "briefing.daily"
+	EventNews     = "news.breaking"
+	EventTest     = "channel.test"
+)
+
+// SubscribableEvents 可订阅的通知事件
+var SubscribableEvents = []string{EventReminder, EventBriefing, EventNews}
+
+// 投递状态
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
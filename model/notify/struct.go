@@ -0,0 +1,31 @@
+package notify
+
+type ChannelReq struct {
+	ID      int64    `json:"id"` // 为0时新建
+	UserID  int64    `json:"-"`
+	Team    bool     `json:"team"` // 团队共享渠道
+	Type    string   `json:"type" binding:"required"`
+	Name    string   `json:"name"`
+	Target  string   `json:"target" binding:"required"` // 邮箱地址, 或机器人 webhook 地址/令牌
+	Secret  string   `json:"secret"`                    // 钉钉/飞书机器人签名密钥
+	Events  []string `json:"events"`                    // 订阅事件, 为空时订阅提醒与简报
+	Enabled bool     `json:"enabled"`
+}
+
+type ChannelIDReq struct {
+	ID     int64 `json:"id" binding:"required"`
+	UserID int64 `json:"-"`
+	Team   bool  `json:"team"`
+}
+
+type ChannelListReq struct {
+	UserID int64 `json:"-"`
+	Team   bool  `json:"team"`
+}
+
+type DeliveryListReq struct {
+	UserID int64  `json:"-"`
+	Status string `json:"status"`
+	Page   int    `json:"page"`
+	Size   int    `json:"size"`
+}
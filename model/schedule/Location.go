@@ -0,0 +1,141 @@
+package schedule
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// earthRadiusKm 地球平均半径
+const earthRadiusKm = 6371.0
+
+// samePlaceKm 坐标距离小于该值时视为同一地点
+const samePlaceKm = 0.05
+
+// 冲突类型
+const (
+	ConflictOverlap = "overlap" // 时间重叠
+	ConflictTravel  = "travel"  // 间隔不足以赶往下一个地点
+)
+
+// Conflict 两个相邻日程之间的冲突
+type Conflict struct {
+	Type          string   `json:"type"`
+	Previous      Schedule `json:"previous"`
+	Next          Schedule `json:"next"`
+	GapMinutes    int      `json:"gap_minutes"`    // 前一日程结束到后一日程开始的间隔, 重叠时为负数
+	TravelMinutes int      `json:"travel_minutes"` // 估算的路程时间
+	DistanceKm    *float64 `json:"distance_km"`    // 两地直线距离, 缺少坐标时为空
+}
+
+// ValidateLocation 校验地点信息, 经纬度必须同时提供
+func (s *Schedule) ValidateLocation() error {
+	if utf8.RuneCountInString(s.Location) > 100 {
+		return errors.New("地点名称长度不能超过 100")
+	}
+	if utf8.RuneCountInString(s.Address) > 255 {
+		return errors.New("地址长度不能超过 255")
+	}
+	if (s.Latitude == nil) != (s.Longitude == nil) {
+		return errors.New("经纬度必须同时提供")
+	}
+	if s.Latitude != nil && (math.Abs(*s.Latitude) > 90 || math.Abs(*s.Longitude) > 180) {
+		return errors.New("经纬度超出范围")
+	}
+	if s.MeetingURL != "" {
+		u, err := url.Parse(s.MeetingURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(s.MeetingURL) > 500 {
+			return errors.New("线上会议地址必须为 http/https 链接")
+		}
+	}
+	return nil
+}
+
+// HasPlace 是否有线下地点, 只有线上会议地址的日程不需要路程时间
+func (s *Schedule) HasPlace() bool {
+	return s.Latitude != nil || strings.TrimSpace(s.Location) != "" || strings.TrimSpace(s.Address) != ""
+}
+
+// samePlace 判断两个日程是否在同一地点, 优先比较坐标
+func samePlace(a, b *Schedule) bool {
+	if a.Latitude != nil && b.Latitude != nil {
+		return DistanceKm(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude) < samePlaceKm
+	}
+	if a.Address != "" && b.Address != "" {
+		return normalizePlace(a.Address) == normalizePlace(b.Address)
+	}
+	return normalizePlace(a.Location) == normalizePlace(b.Location)
+}
+
+func normalizePlace(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// DistanceKm 按半正矢公式计算两点间的球面直线距离
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// TravelBuffer 估算从 from 赶往 to 所需的路程时间:
+// 任一方没有线下地点或两者在同一地点时为0; 都有坐标时按直线距离与 speedKmh 估算;
+// 地点不同但缺少坐标时使用 fallback
+func TravelBuffer(from, to *Schedule, speedKmh float64, fallback time.Duration) (time.Duration, *float64) {
+	if !from.HasPlace() || !to.HasPlace() || samePlace(from, to) {
+		return 0, nil
+	}
+	if from.Latitude == nil || to.Latitude == nil || speedKmh <= 0 {
+		return fallback, nil
+	}
+	km := DistanceKm(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude)
+	minutes := math.Ceil(km / speedKmh * 60)
+	km = math.Round(km*100) / 100
+	return time.Duration(minutes) * time.Minute, &km
+}
+
+// DetectConflicts 检测时间重叠以及相邻日程间的路程时间不足, 未安排时间段的任务不参与检测
+func DetectConflicts(list []Schedule, speedKmh float64, fallback time.Duration) []Conflict {
+	sorted := make([]Schedule, 0, len(list))
+	for _, s := range list {
+		if !s.Unscheduled {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	conflicts := make([]Conflict, 0)
+	for i := range sorted {
+		prev := &sorted[i]
+		for j := i + 1; j < len(sorted); j++ {
+			next := &sorted[j]
+			gap := next.StartTime.Sub(prev.EndTime)
+			if gap < 0 {
+				travel, km := TravelBuffer(prev, next, speedKmh, fallback)
+				conflicts = append(conflicts, Conflict{
+					Type: ConflictOverlap, Previous: *prev, Next: *next,
+					GapMinutes: int(math.Floor(gap.Minutes())), TravelMinutes: int(travel.Minutes()), DistanceKm: km,
+				})
+				continue
+			}
+			// 第一个在 prev 结束后开始的日程即为相邻日程
+			if travel, km := TravelBuffer(prev, next, speedKmh, fallback); gap < travel {
+				conflicts = append(conflicts, Conflict{
+					Type: ConflictTravel, Previous: *prev, Next: *next,
+					GapMinutes: int(gap.Minutes()), TravelMinutes: int(travel.Minutes()), DistanceKm: km,
+				})
+			}
+			break
+		}
+	}
+	return conflicts
+}
@@ -1,23 +1,39 @@
 package schedule
 
 import (
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Schedule 日程表结构体
 type Schedule struct {
-	ID        int64     `gorm:"column:id;primaryKey;autoIncrement;comment:日程ID" json:"id"`
-	UserID    int64     `gorm:"column:user_id;default:0;not null;comment:用户ID" json:"user_id"`
-	CreateAt  time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
-	UpdateAt  time.Time `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
-	Year      int16     `gorm:"column:year;default:1970;not null;comment:年" json:"year"`
-	Month     int8      `gorm:"column:month;default:1;not null;comment:月(1-12)" json:"month"`
-	Day       int8      `gorm:"column:day;default:1;not null;comment:日(1-31)" json:"day"`
-	StartTime time.Time `gorm:"column:start_time;default:CURRENT_TIMESTAMP;not null;comment:开始时间" json:"start_time"`
-	EndTime   time.Time `gorm:"column:end_time;default:CURRENT_TIMESTAMP;not null;comment:结束时间" json:"end_time"`
-	Content   string    `gorm:"column:content;type:varchar(500);default:'';not null;comment:日程安排内容" json:"content"`
-	Priority  int8      `gorm:"column:priority;default:0;not null;comment:优先级(0-低,1-中,2-高)" json:"priority"`
-	Status    int       `gorm:"column:status;default:1;not null;comment:状态：1-未开始，2-进行中，3-已结束，4-已完成" json:"status"`
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement;comment:日程ID" json:"id"`
+	UserID        int64      `gorm:"column:user_id;default:0;not null;comment:用户ID" json:"user_id"`
+	CreateAt      time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt      time.Time  `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+	Year          int16      `gorm:"column:year;default:1970;not null;comment:年" json:"year"`
+	Month         int8       `gorm:"column:month;default:1;not null;comment:月(1-12)" json:"month"`
+	Day           int8       `gorm:"column:day;default:1;not null;comment:日(1-31)" json:"day"`
+	StartTime     time.Time  `gorm:"column:start_time;default:CURRENT_TIMESTAMP;not null;comment:开始时间" json:"start_time"`
+	EndTime       time.Time  `gorm:"column:end_time;default:CURRENT_TIMESTAMP;not null;comment:结束时间" json:"end_time"`
+	Content       string     `gorm:"column:content;type:varchar(500);default:'';not null;comment:日程安排内容" json:"content"`
+	Priority      int8       `gorm:"column:priority;default:0;not null;comment:优先级(0-低,1-中,2-高)" json:"priority"`
+	Status        int        `gorm:"column:status;default:1;not null;comment:状态：1-未开始，2-进行中，3-已结束，4-已完成" json:"status"`
+	Version       int        `gorm:"column:version;default:1;not null;comment:乐观锁版本号" json:"version"`
+	Tags          string     `gorm:"column:tags;type:varchar(255);default:'';not null;comment:标签(逗号分隔)" json:"tags"`
+	Notes         string     `gorm:"column:notes;type:mediumtext;comment:备注(Markdown)" json:"notes"`
+	Location      string     `gorm:"column:location;type:varchar(100);default:'';not null;comment:地点名称" json:"location"`
+	Address       string     `gorm:"column:address;type:varchar(255);default:'';not null;comment:详细地址" json:"address"`
+	Latitude      *float64   `gorm:"column:latitude;comment:纬度" json:"latitude"`
+	Longitude     *float64   `gorm:"column:longitude;comment:经度" json:"longitude"`
+	MeetingURL    string     `gorm:"column:meeting_url;type:varchar(500);default:'';not null;comment:线上会议地址" json:"meeting_url"`
+	Kind          string     `gorm:"column:kind;type:varchar(20);default:'event';not null;comment:类型(event-日程,task-任务)" json:"kind"`
+	RolloverCount int        `gorm:"column:rollover_count;default:0;not null;comment:逾期顺延次数" json:"rollover_count"`
+	DueAt         *time.Time `gorm:"column:due_at;comment:截止时间" json:"due_at"`
+	EffortMinutes int        `gorm:"column:effort_minutes;default:0;not null;comment:预计耗时(分钟)" json:"effort_minutes"`
+	Unscheduled   bool       `gorm:"column:unscheduled;not null;comment:是否未安排时间段(仅有截止时间的任务)" json:"unscheduled"`
 }
 
 // TableName 设置表名
@@ -35,4 +51,69 @@ const (
 	StatusInProgress = 2 // 进行中
 	StatusEnded      = 3 // 已结束
 	StatusCompleted  = 4 // 已完成
+
+	KindEvent = "event" // 占用时间段的日程
+	KindTask  = "task"  // 任务, 逾期未完成时自动顺延
 )
+
+// ContentMaxLen 日程内容最大长度(字符数)
+const ContentMaxLen = 500
+
+// EffortMaxMinutes 预计耗时上限(分钟)
+const EffortMaxMinutes = 100000
+
+// NotesMaxLen 日程备注最大长度(字符数)
+const NotesMaxLen = 20000
+
+// ValidPriority 判断优先级是否合法
+func ValidPriority(priority int) bool {
+	return priority >= PriorityLow && priority <= PriorityHigh
+}
+
+// ValidStatus 判断状态是否合法
+func ValidStatus(status int) bool {
+	return status >= StatusNotStarted && status <= StatusCompleted
+}
+
+// ValidKind 判断类型是否合法
+func ValidKind(kind string) bool {
+	return kind == KindEvent || kind == KindTask
+}
+
+// BeforeSave 保存前补全类型, 兼容新增 kind 字段之前的历史快照
+func (s *Schedule) BeforeSave(tx *gorm.DB) error {
+	if s.Kind == "" {
+		s.Kind = KindEvent
+	}
+	return nil
+}
+
+// SyncDate 根据开始时间同步年月日字段
+func (s *Schedule) SyncDate() {
+	s.Year = int16(s.StartTime.Year())
+	s.Month = int8(s.StartTime.Month())
+	s.Day = int8(s.StartTime.Day())
+}
+
+// TagList 获取标签列表
+func (s *Schedule) TagList() []string {
+	if s.Tags == "" {
+		return []string{}
+	}
+	return strings.Split(s.Tags, ",")
+}
+
+// SetTags 设置标签, 自动去重并忽略空标签
+func (s *Schedule) SetTags(tags []string) {
+	seen := make(map[string]bool, len(tags))
+	list := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.ReplaceAll(tag, ",", ""))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		list = append(list, tag)
+	}
+	s.Tags = strings.Join(list, ",")
+}
@@ -0,0 +1,19 @@
+package schedule
+
+import (
+	"time"
+)
+
+// ScheduleDependency 日程依赖(完成-开始), 后置日程必须在前置日程结束后才能开始
+type ScheduleDependency struct {
+	ID            int64     `gorm:"column:id;primaryKey;autoIncrement;comment:依赖ID" json:"id"`
+	PredecessorID int64     `gorm:"column:predecessor_id;not null;uniqueIndex:uk_predecessor_successor;comment:前置日程ID" json:"predecessor_id"`
+	SuccessorID   int64     `gorm:"column:successor_id;not null;uniqueIndex:uk_predecessor_successor;index:idx_successor_id;comment:后置日程ID" json:"successor_id"`
+	UserID        int64     `gorm:"column:user_id;default:0;not null;comment:创建人ID" json:"user_id"`
+	CreateAt      time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (ScheduleDependency) TableName() string {
+	return "schedule_dependency"
+}
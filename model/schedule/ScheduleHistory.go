@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ScheduleHistory 日程变更历史, 每次创建/更新/删除都会生成一个版本
+type ScheduleHistory struct {
+	ID         int64                  `gorm:"column:id;primaryKey;autoIncrement;comment:历史ID" json:"id"`
+	ScheduleID int64                  `gorm:"column:schedule_id;not null;uniqueIndex:uk_schedule_version;comment:日程ID" json:"schedule_id"`
+	Version    int                    `gorm:"column:version;not null;uniqueIndex:uk_schedule_version;comment:历史版本号" json:"version"`
+	Action     string                 `gorm:"column:action;type:varchar(20);not null;comment:操作类型(create/update/delete/revert)" json:"action"`
+	ActorID    int64                  `gorm:"column:actor_id;default:0;not null;comment:操作人ID" json:"actor_id"`
+	Snapshot   *Schedule              `gorm:"column:snapshot;type:mediumtext;serializer:json;comment:该版本的日程快照" json:"snapshot"`
+	Diff       map[string]FieldChange `gorm:"column:diff;type:mediumtext;serializer:json;comment:字段级变更" json:"diff"`
+	CreateAt   time.Time              `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:操作时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (ScheduleHistory) TableName() string {
+	return "schedule_history"
+}
+
+// FieldChange 单个字段的变更前后值
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// 历史操作类型
+const (
+	HistoryActionCreate = "create"
+	HistoryActionUpdate = "update"
+	HistoryActionDelete = "delete"
+	HistoryActionRevert = "revert"
+)
+
+// diffIgnoreFields 不参与比较的字段
+var diffIgnoreFields = map[string]bool{
+	"create_at": true,
+	"update_at": true,
+	"version":   true,
+}
+
+// Diff 比较两个日程的字段差异, 以 json 字段名为 key, before/after 为空表示创建/删除
+func Diff(before, after *Schedule) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	if before == nil && after == nil {
+		return changes
+	}
+
+	var bv, av reflect.Value
+	if before != nil {
+		bv = reflect.ValueOf(*before)
+	}
+	if after != nil {
+		av = reflect.ValueOf(*after)
+	}
+
+	t := reflect.TypeOf(Schedule{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || diffIgnoreFields[name] {
+			continue
+		}
+
+		var oldVal, newVal any
+		if bv.IsValid() {
+			oldVal = bv.Field(i).Interface()
+		}
+		if av.IsValid() {
+			newVal = av.Field(i).Interface()
+		}
+		if fieldEqual(oldVal, newVal) {
+			continue
+		}
+		changes[name] = FieldChange{Old: oldVal, New: newVal}
+	}
+	return changes
+}
+
+// fieldEqual 判断字段值是否相等, 时间类型按时刻比较
+func fieldEqual(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"time"
+)
+
+// ScheduleOperation 用户操作日志, 记录一次写操作涉及的全部日程变更, 用于撤销/重做
+type ScheduleOperation struct {
+	ID       int64             `gorm:"column:id;primaryKey;autoIncrement;comment:操作ID" json:"id"`
+	UserID   int64             `gorm:"column:user_id;not null;index:idx_user_state;comment:用户ID" json:"user_id"`
+	OpType   string            `gorm:"column:op_type;type:varchar(20);not null;comment:操作类型" json:"op_type"`
+	State    string            `gorm:"column:state;type:varchar(20);not null;index:idx_user_state;comment:状态(done/undone/discarded)" json:"state"`
+	Changes  []OperationChange `gorm:"column:changes;type:longtext;serializer:json;comment:日程变更列表" json:"changes"`
+	CreateAt time.Time         `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt time.Time         `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (ScheduleOperation) TableName() string {
+	return "schedule_operation"
+}
+
+// OperationChange 单条日程的变更前后状态, Before 为空表示创建, After 为空表示删除
+type OperationChange struct {
+	ScheduleID int64     `json:"schedule_id"`
+	Before     *Schedule `json:"before"`
+	After      *Schedule `json:"after"`
+}
+
+// 操作类型
+const (
+	OpCreate   = "create"
+	OpUpdate   = "update"
+	OpPatch    = "patch"
+	OpDelete   = "delete"
+	OpBulk     = "bulk"
+	OpRevert   = "revert"
+	OpImport   = "import"
+	OpRollover = "rollover"
+)
+
+// 操作状态
+const (
+	OpStateDone      = "done"      // 已执行, 可撤销
+	OpStateUndone    = "undone"    // 已撤销, 可重做
+	OpStateDiscarded = "discarded" // 撤销后又产生了新操作, 不能再重做
+)
@@ -1,32 +1,207 @@
 package schedule
 
+import (
+	"go-film-demo/model/countdown"
+	"strings"
+)
+
 type QueryReq struct {
-	UserID int64 `json:"user_id"`
-	Year   int32 `json:"year"`
-	Month  int32 `json:"month"`
-	Day    int32 `json:"day"`
+	UserID         int64 `json:"-"`
+	Year           int32 `json:"year"`
+	Month          int32 `json:"month"`
+	Day            int32 `json:"day"`
+	WithCountdowns bool  `json:"with_countdowns"` // 为 true 时返回 QueryResp, 附带当日/当月的倒数日与纪念日
 }
 
-type StoreReq struct {
+type QueryResp struct {
+	Schedules  []Schedule             `json:"schedules"`
+	Countdowns []countdown.Occurrence `json:"countdowns"`
+}
+
+type PageReq struct {
+	UserID   int64  `json:"-"`
 	Year     int32  `json:"year"`
 	Month    int32  `json:"month"`
 	Day      int32  `json:"day"`
-	UserID   int64  `json:"user_id"`
 	Content  string `json:"content"`
-	Start    string `json:"start"` // 时间字符串格式
-	End      string `json:"end"`   // 时间字符串格式
 	Priority int    `json:"priority"`
+	Status   int    `json:"status"`
+	Page     int    `json:"page"`   // 页码, 从1开始
+	Size     int    `json:"size"`   // 每页大小, 默认20, 最大100
+	Cursor   string `json:"cursor"` // 游标, 传入上一页返回的 nextCursor 时使用游标分页
+	Sort     string `json:"sort"`   // 排序字段: start_time/end_time/create_at/update_at/priority/status/id
+	Order    string `json:"order"`  // 排序方向: asc/desc
+}
+
+type StoreReq struct {
+	Year          int32        `json:"year"`
+	Month         int32        `json:"month"`
+	Day           int32        `json:"day"`
+	UserID        int64        `json:"-"`
+	Content       string       `json:"content"`
+	Notes         string       `json:"notes"` // Markdown 格式的备注
+	Start         string       `json:"start"` // 时间字符串格式
+	End           string       `json:"end"`   // 时间字符串格式
+	Priority      int          `json:"priority"`
+	Location      *LocationReq `json:"location"`
+	Kind          string       `json:"kind"`           // event/task, 默认 event
+	Due           string       `json:"due"`            // 截止时间, YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS
+	EffortMinutes int          `json:"effort_minutes"` // 预计耗时(分钟)
 }
 
 type UpdateReq struct {
-	ID       int    `json:"id" binding:"required"`
-	Year     int    `json:"year" binding:"required"`
-	Month    int    `json:"month" binding:"required"`
-	Day      int    `json:"day" binding:"required"`
-	Start    string `json:"start"`
+	ID            int          `json:"id" binding:"required"`
+	Year          int          `json:"year" binding:"required"`
+	Month         int          `json:"month" binding:"required"`
+	Day           int          `json:"day" binding:"required"`
+	Start         string       `json:"start"`
+	End           string       `json:"end"`
+	Content       string       `json:"content"`
+	Notes         *string      `json:"notes"`          // Markdown 格式的备注, 不传时保留原备注
+	Location      *LocationReq `json:"location"`       // 地点, 不传时保留原地点
+	Kind          string       `json:"kind"`           // event/task, 不传时保留原类型
+	Due           *string      `json:"due"`            // 截止时间, 不传时保留, 空字符串表示清除
+	EffortMinutes *int         `json:"effort_minutes"` // 预计耗时(分钟), 不传时保留
+	Status        int          `json:"status" binding:"required"`
+	UserID        int64        `json:"-"`
+	Priority      int          `json:"priority"`
+	Version       int          `json:"version"` // 读取时的版本号, 也可通过 If-Match 头传递
+	Cascade       bool         `json:"cascade"` // 时间变化时是否自动顺延后置日程
+}
+
+// LocationReq 日程地点, 线下地点与线上会议地址可以同时提供
+type LocationReq struct {
+	Name       string   `json:"name"`
+	Address    string   `json:"address"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+	MeetingURL string   `json:"meeting_url"`
+}
+
+// ApplyTo 将地点写入日程
+func (l *LocationReq) ApplyTo(s *Schedule) {
+	s.Location = strings.TrimSpace(l.Name)
+	s.Address = strings.TrimSpace(l.Address)
+	s.Latitude = l.Latitude
+	s.Longitude = l.Longitude
+	s.MeetingURL = strings.TrimSpace(l.MeetingURL)
+}
+
+type ChronicReq struct {
+	UserID   int64 `json:"-"`
+	MinCount int   `json:"min_count"` // 最少顺延次数, 默认3
+	Page     int   `json:"page"`
+	Size     int   `json:"size"`
+}
+
+type TodoReq struct {
+	UserID           int64 `json:"-"`
+	IncludeCompleted bool  `json:"include_completed"`
+	Page             int   `json:"page"`
+	Size             int   `json:"size"`
+}
+
+type ConflictReq struct {
+	UserID int64  `json:"-"`
+	Begin  string `json:"begin" binding:"required"` // 检测范围, YYYY-MM-DD HH:MM:SS
+	End    string `json:"end" binding:"required"`
+	ID     int64  `json:"id"` // 只返回与该日程相关的冲突
+}
+
+// NotesResp 日程备注, HTML 为渲染并净化后的结果
+type NotesResp struct {
+	ID      int64  `json:"id"`
+	Version int    `json:"version"`
+	Notes   string `json:"notes"`
+	HTML    string `json:"html"`
+}
+
+type DeleteReq struct {
+	ID      int64 `json:"id" binding:"required"`
+	UserID  int64 `json:"-"`
+	Version int   `json:"version"`
+}
+
+type HistoryReq struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+type RevertReq struct {
+	ID      int64 `json:"id" binding:"required"`
+	Version int   `json:"version" binding:"required"`
+	UserID  int64 `json:"-"`
+}
+
+type BulkReq struct {
+	UserID int64       `json:"-"`
+	IDs    []int64     `json:"ids"`    // 指定日程ID, 与 filter 二选一
+	Filter *BulkFilter `json:"filter"` // 按条件筛选当前用户的日程
+	Ops    BulkOps     `json:"ops"`
+}
+
+type BulkFilter struct {
+	Year     int32  `json:"year"`
+	Month    int32  `json:"month"`
+	Day      int32  `json:"day"`
+	Begin    string `json:"begin"` // 开始时间范围, YYYY-MM-DD HH:MM:SS
 	End      string `json:"end"`
 	Content  string `json:"content"`
-	Status   int    `json:"status" binding:"required"`
-	UserID   int64  `json:"user_id"`
 	Priority int    `json:"priority"`
+	Status   int    `json:"status"`
+}
+
+type BulkOps struct {
+	Status       *int     `json:"status"`
+	Priority     *int     `json:"priority"`
+	AddTags      []string `json:"add_tags"`
+	RemoveTags   []string `json:"remove_tags"`
+	ShiftMinutes int      `json:"shift_minutes"` // 整体平移的分钟数, 可为负
+	Delete       bool     `json:"delete"`        // 删除, 不能与其他操作同时使用
+}
+
+type BulkItemResult struct {
+	ID      int64  `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type OperationReq struct {
+	UserID int64 `json:"-"`
+	Steps  int   `json:"steps"` // 撤销/重做的操作数, 默认1
+}
+
+type DependencyReq struct {
+	UserID        int64 `json:"-"`
+	PredecessorID int64 `json:"predecessor_id" binding:"required"`
+	SuccessorID   int64 `json:"successor_id" binding:"required"`
+}
+
+type DependencyListReq struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+type DependencyListResp struct {
+	Predecessors []Schedule `json:"predecessors"`
+	Successors   []Schedule `json:"successors"`
+}
+
+type ExportReq struct {
+	UserID int64      `json:"-"`
+	Format string     `json:"format"` // csv/xlsx, 默认 csv
+	Filter BulkFilter `json:"filter"`
+}
+
+// ImportError 导入时单元格级别的校验错误, Line 为表格中的行号(表头为第1行)
+type ImportError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+type ImportResult struct {
+	Total   int           `json:"total"`   // 数据行数
+	Created int           `json:"created"` // 实际创建的日程数, 试运行时为 0
+	Updated int           `json:"updated"` // 按 id 更新的日程数, 试运行时为 0
+	DryRun  bool          `json:"dry_run"`
+	Errors  []ImportError `json:"errors"`
 }
@@ -0,0 +1,73 @@
+package share
+
+import (
+	"time"
+)
+
+// ShareLink 公开只读分享链接, 可分享单个日程或按条件筛选的日历视图
+type ShareLink struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement;comment:分享ID" json:"id"`
+	UserID     int64      `gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	Token      string     `gorm:"column:token;type:varchar(64);not null;uniqueIndex:uk_token;comment:分享令牌" json:"token"`
+	Kind       string     `gorm:"column:kind;type:varchar(20);not null;comment:类型(schedule-单个日程,calendar-日历视图)" json:"kind"`
+	ScheduleID int64      `gorm:"column:schedule_id;default:0;not null;comment:分享的日程ID" json:"schedule_id"`
+	Filter     *Filter    `gorm:"column:filter;type:varchar(500);serializer:json;comment:日历视图筛选条件" json:"filter"`
+	Redact     bool       `gorm:"column:redact;not null;comment:是否隐藏内容与优先级(显示为忙碌)" json:"redact"`
+	ExpireAt   *time.Time `gorm:"column:expire_at;comment:过期时间, 为空表示永久有效" json:"expire_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;comment:撤销时间" json:"revoked_at"`
+	CreateAt   time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt   time.Time  `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (ShareLink) TableName() string {
+	return "share_link"
+}
+
+// Active 判断分享链接当前是否有效
+func (l *ShareLink) Active(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	return l.ExpireAt == nil || now.Before(*l.ExpireAt)
+}
+
+// Filter 日历视图筛选条件; 未指定固定时间范围时展示从访问当天起 Days 天内的日程
+type Filter struct {
+	Begin    string `json:"begin,omitempty"` // YYYY-MM-DD
+	End      string `json:"end,omitempty"`   // YYYY-MM-DD, 包含当天
+	Days     int    `json:"days,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	Status   int    `json:"status,omitempty"`
+}
+
+// PublicSchedule 公开展示的日程, 隐藏模式下内容显示为 busy 且不返回优先级
+type PublicSchedule struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Content   string    `json:"content"`
+	Priority  *int8     `json:"priority,omitempty"`
+}
+
+// PublicView 分享链接的公开数据
+type PublicView struct {
+	Kind      string           `json:"kind"`
+	Begin     string           `json:"begin,omitempty"`
+	End       string           `json:"end,omitempty"`
+	Redacted  bool             `json:"redacted"`
+	Schedules []PublicSchedule `json:"schedules"`
+}
+
+// 分享类型
+const (
+	KindSchedule = "schedule"
+	KindCalendar = "calendar"
+)
+
+// BusyLabel 隐藏模式下显示的内容
+const BusyLabel = "busy"
+
+const (
+	DefaultDays = 30 // 日历视图默认展示天数
+	MaxDays     = 92 // 日历视图最大展示天数
+)
@@ -0,0 +1,19 @@
+package share
+
+type StoreReq struct {
+	UserID      int64   `json:"-"`
+	Kind        string  `json:"kind" binding:"required"` // schedule/calendar
+	ScheduleID  int64   `json:"schedule_id"`
+	Filter      *Filter `json:"filter"`
+	Redact      bool    `json:"redact"`
+	ExpireHours int     `json:"expire_hours"` // 有效小时数, 0 表示永久有效
+}
+
+type IDReq struct {
+	ID     int64 `json:"id" binding:"required"`
+	UserID int64 `json:"-"`
+}
+
+type ListReq struct {
+	UserID int64 `json:"-"`
+}
@@ -12,8 +12,12 @@ import (
 */
 
 const (
-	SUCCESS = 200
-	FAILED  = 500
+	SUCCESS           = 200
+	UNAUTHORIZED      = 401
+	FORBIDDEN         = 403
+	CONFLICT          = 409
+	TOO_MANY_REQUESTS = 429
+	FAILED            = 500
 )
 
 // Response http返回数据结构体
@@ -37,6 +41,7 @@ type Page struct {
 	PageCount int `json:"pageCount"` // 总页数
 	Total     int `json:"total"`     // 总记录数
 	//List      []interface{} `json:"list"`      // 数据
+	NextCursor string `json:"nextCursor,omitempty"` // 下一页游标, 为空表示没有更多数据
 }
 
 // Result 构建response返回数据结构
@@ -68,6 +73,26 @@ func FailedWithData(data any, message string, c *gin.Context) {
 	Result(FAILED, data, message, c)
 }
 
+// Conflict 数据版本冲突, 返回 409 以及服务端当前数据
+func Conflict(data any, message string, c *gin.Context) {
+	CustomResult(http.StatusConflict, CONFLICT, data, message, c)
+}
+
+// Unauthorized 未登录或令牌无效, 返回 401
+func Unauthorized(message string, c *gin.Context) {
+	CustomResult(http.StatusUnauthorized, UNAUTHORIZED, nil, message, c)
+}
+
+// Forbidden 已登录但没有权限, 返回 403
+func Forbidden(message string, c *gin.Context) {
+	CustomResult(http.StatusForbidden, FORBIDDEN, nil, message, c)
+}
+
+// TooManyRequests 请求过于频繁, 返回 429
+func TooManyRequests(message string, c *gin.Context) {
+	CustomResult(http.StatusTooManyRequests, TOO_MANY_REQUESTS, nil, message, c)
+}
+
 // CustomResult 自定义返回状态以及相关数据, 用于异常返回情况
 func CustomResult(statusCode int, code int, data any, msg string, c *gin.Context) {
 	c.JSON(statusCode, Response{
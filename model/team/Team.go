@@ -0,0 +1,56 @@
+package team
+
+import "time"
+
+// 团队角色
+const (
+	RoleLead   = "lead"   // 团队负责人, 可以查看团队日程并管理普通成员
+	RoleMember = "member" // 团队成员
+)
+
+// Team 团队表结构体
+type Team struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement;comment:团队ID" json:"id"`
+	Name        string    `gorm:"column:name;type:varchar(50);not null;uniqueIndex:uk_name;comment:团队名称" json:"name"`
+	Description string    `gorm:"column:description;type:varchar(255);default:'';not null;comment:团队描述" json:"description"`
+	CreateAt    time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt    time.Time `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (Team) TableName() string {
+	return "team"
+}
+
+// Member 团队成员, 一个用户可以加入多个团队
+type Member struct {
+	ID       int64     `gorm:"column:id;primaryKey;autoIncrement;comment:ID" json:"id"`
+	TeamID   int64     `gorm:"column:team_id;not null;uniqueIndex:uk_team_user,priority:1;comment:团队ID" json:"team_id"`
+	UserID   int64     `gorm:"column:user_id;not null;uniqueIndex:uk_team_user,priority:2;index:idx_user_id;comment:用户ID" json:"user_id"`
+	Role     string    `gorm:"column:role;type:varchar(20);default:'member';not null;comment:团队角色(lead/member)" json:"role"`
+	CreateAt time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:加入时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (Member) TableName() string {
+	return "team_member"
+}
+
+// MemberInfo 团队成员及其用户信息
+type MemberInfo struct {
+	Member
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+}
+
+// TeamInfo 团队及当前用户在团队中的角色
+type TeamInfo struct {
+	Team
+	MyRole      string `json:"my_role"` // 未加入团队(管理员查看)时为空
+	MemberCount int    `json:"member_count"`
+}
+
+// ValidRole 是否为支持的团队角色
+func ValidRole(role string) bool {
+	return role == RoleLead || role == RoleMember
+}
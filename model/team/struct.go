@@ -0,0 +1,29 @@
+package team
+
+type StoreReq struct {
+	ID          int64  `json:"id"` // 为0时新建
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type IDReq struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+type MemberReq struct {
+	TeamID   int64  `json:"team_id" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role"` // lead/member, 默认 member
+}
+
+type MemberRemoveReq struct {
+	TeamID int64 `json:"team_id" binding:"required"`
+	UserID int64 `json:"user_id" binding:"required"`
+}
+
+type ScheduleReq struct {
+	TeamID int64  `json:"team_id" binding:"required"`
+	UserID int64  `json:"user_id"` // 只查看指定成员, 为0时查看全部成员
+	Begin  string `json:"begin" binding:"required"`
+	End    string `json:"end" binding:"required"`
+}
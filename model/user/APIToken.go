@@ -0,0 +1,64 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// APITokenPrefix 个人访问令牌前缀, 用于与 JWT 访问令牌区分
+const APITokenPrefix = "gst_"
+
+// 个人访问令牌权限范围
+const (
+	ScopeScheduleRead  = "schedule:read"  // 查询日程、倒数日、简报等
+	ScopeScheduleWrite = "schedule:write" // 创建、修改、删除日程以及相关配置
+	ScopeNewsRead      = "news:read"      // 查询新闻
+)
+
+// Scopes 全部可用的权限范围
+var Scopes = []string{ScopeScheduleRead, ScopeScheduleWrite, ScopeNewsRead}
+
+// APITokenMaxPerUser 每个用户最多保留的未撤销令牌数
+const APITokenMaxPerUser = 20
+
+// APIToken 个人访问令牌, 只保存 SHA-256 哈希, 明文仅在创建时返回一次
+type APIToken struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement;comment:令牌ID" json:"id"`
+	UserID     int64      `gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	Name       string     `gorm:"column:name;type:varchar(50);not null;comment:令牌名称" json:"name"`
+	Hint       string     `gorm:"column:hint;type:varchar(20);not null;comment:令牌前几位, 用于识别" json:"hint"`
+	TokenHash  string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex:uk_token_hash;comment:令牌哈希(SHA-256)" json:"-"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(255);not null;comment:权限范围(逗号分隔)" json:"scopes"`
+	ExpireAt   *time.Time `gorm:"column:expire_at;comment:过期时间, 为空表示永久有效" json:"expire_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;comment:最近使用时间" json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at;comment:撤销时间" json:"revoked_at"`
+	CreateAt   time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (APIToken) TableName() string {
+	return "user_api_token"
+}
+
+// ScopeList 权限范围列表
+func (t *APIToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// Active 令牌在 now 时是否可用
+func (t *APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpireAt == nil || now.Before(*t.ExpireAt))
+}
+
+// ValidScope 是否为支持的权限范围
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
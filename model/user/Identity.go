@@ -0,0 +1,18 @@
+package user
+
+import "time"
+
+// Identity 外部身份提供方(OIDC)账号与本地用户的绑定关系
+type Identity struct {
+	ID       int64     `gorm:"column:id;primaryKey;autoIncrement;comment:ID" json:"id"`
+	UserID   int64     `gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	Issuer   string    `gorm:"column:issuer;type:varchar(255);not null;uniqueIndex:uk_issuer_subject,priority:1;comment:身份提供方(iss)" json:"issuer"`
+	Subject  string    `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:uk_issuer_subject,priority:2;comment:提供方用户标识(sub)" json:"subject"`
+	Email    string    `gorm:"column:email;type:varchar(255);default:'';not null;comment:最近一次登录时的邮箱" json:"email"`
+	CreateAt time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:绑定时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (Identity) TableName() string {
+	return "user_identity"
+}
@@ -0,0 +1,47 @@
+package user
+
+// 用户角色
+const (
+	RoleAdmin  = "admin"  // 管理员, 拥有全部权限
+	RoleMember = "member" // 普通成员
+)
+
+// 权限
+const (
+	PermSpiderManage    = "spider:manage"     // 手动触发新闻采集
+	PermCronManage      = "cron:manage"       // 查看与手动执行定时任务
+	PermUserManage      = "user:manage"       // 查看用户、修改用户角色
+	PermTeamManage      = "team:manage"       // 创建、删除团队, 管理任意团队成员
+	PermTeamChannel     = "notify:team"       // 管理团队共享通知渠道
+	PermScheduleViewAll = "schedule:view_all" // 查看任意用户的日程
+)
+
+// rolePermissions 角色拥有的权限, 团队负责人查看团队日程的权限由团队成员关系决定
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermSpiderManage, PermCronManage, PermUserManage,
+		PermTeamManage, PermTeamChannel, PermScheduleViewAll,
+	},
+	RoleMember: {},
+}
+
+// ValidRole 是否为支持的角色
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission 角色是否拥有权限
+func HasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 用户是否拥有权限
+func (u *User) HasPermission(perm string) bool {
+	return HasPermission(u.Role, perm)
+}
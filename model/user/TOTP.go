@@ -0,0 +1,20 @@
+package user
+
+import "time"
+
+// RecoveryCodeCount 每次生成的恢复码数量
+const RecoveryCodeCount = 10
+
+// RecoveryCode 两步验证恢复码, 丢失验证器时代替验证码使用, 每个只能使用一次
+type RecoveryCode struct {
+	ID       int64      `gorm:"column:id;primaryKey;autoIncrement;comment:ID" json:"id"`
+	UserID   int64      `gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	CodeHash string     `gorm:"column:code_hash;type:varchar(64);not null;comment:恢复码SHA-256哈希" json:"-"`
+	UsedAt   *time.Time `gorm:"column:used_at;comment:使用时间" json:"used_at"`
+	CreateAt time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (RecoveryCode) TableName() string {
+	return "user_recovery_code"
+}
@@ -0,0 +1,55 @@
+package user
+
+import "time"
+
+// User 用户表结构体
+type User struct {
+	ID           int64      `gorm:"column:id;primaryKey;autoIncrement;comment:用户ID" json:"id"`
+	Username     string     `gorm:"column:username;type:varchar(50);not null;uniqueIndex:uk_username;comment:用户名" json:"username"`
+	Email        string     `gorm:"column:email;type:varchar(255);default:'';not null;comment:邮箱" json:"email"`
+	Nickname     string     `gorm:"column:nickname;type:varchar(50);default:'';not null;comment:昵称" json:"nickname"`
+	PasswordHash string     `gorm:"column:password_hash;type:varchar(100);not null;comment:密码哈希(bcrypt)" json:"-"`
+	Role         string     `gorm:"column:role;type:varchar(20);default:'member';not null;comment:角色(admin/member)" json:"role"`
+	TOTPSecret   string     `gorm:"column:totp_secret;type:varchar(64);default:'';not null;comment:TOTP密钥(base32)" json:"-"`
+	TOTPEnabled  bool       `gorm:"column:totp_enabled;default:false;not null;comment:是否启用两步验证" json:"totp_enabled"`
+	TOTPLastStep int64      `gorm:"column:totp_last_step;default:0;not null;comment:最近一次使用的TOTP时间步, 防止验证码重放" json:"-"`
+	LastLoginAt  *time.Time `gorm:"column:last_login_at;comment:最近登录时间" json:"last_login_at"`
+	CreateAt     time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt     time.Time  `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (User) TableName() string {
+	return "user"
+}
+
+// RefreshToken 已签发的刷新令牌, 刷新时轮换, 登出或检测到重复使用时撤销
+type RefreshToken struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement;comment:ID" json:"id"`
+	UserID    int64      `gorm:"column:user_id;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	TokenID   string     `gorm:"column:token_id;type:varchar(64);not null;uniqueIndex:uk_token_id;comment:令牌ID(jti)" json:"token_id"`
+	ExpireAt  time.Time  `gorm:"column:expire_at;not null;comment:过期时间" json:"expire_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at;comment:撤销时间" json:"revoked_at"`
+	CreateAt  time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+}
+
+// TableName 设置表名
+func (RefreshToken) TableName() string {
+	return "user_refresh_token"
+}
+
+// 令牌类型
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+	TokenMFA     = "mfa"    // 密码校验通过、等待两步验证的临时令牌
+	TokenStream  = "stream" // 建立 SSE 连接的短期票据, 只能用于 /stream
+)
+
+// 用户名与密码长度限制
+const (
+	UsernameMinLen = 3
+	UsernameMaxLen = 50
+	PasswordMinLen = 8
+	PasswordMaxLen = 72 // bcrypt 只使用前 72 字节
+)
@@ -0,0 +1,101 @@
+package user
+
+type RegisterReq struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"`
+	Nickname string `json:"nickname"`
+}
+
+type LoginReq struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type PasswordReq struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// TokenResp 登录/刷新返回的令牌; 用户启用两步验证时登录只返回 mfa_required 与 mfa_token, 验证通过后再签发令牌
+type TokenResp struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"` // Bearer
+	ExpiresIn    int64  `json:"expires_in"`           // 访问令牌有效期(秒), 两步验证时为 mfa_token 有效期
+	User         *User  `json:"user,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"` // 需要调用 /auth/2fa/verify 完成两步验证
+	MFAToken     string `json:"mfa_token,omitempty"`
+	TOTPEnroll   bool   `json:"totp_enroll,omitempty"` // 角色要求两步验证但尚未启用, 启用前只能访问 /auth/me 与 /auth/2fa/setup、/auth/2fa/enable
+}
+
+type TOTPCodeReq struct {
+	Code         string `json:"code"`          // 验证器应用中的 6 位验证码
+	RecoveryCode string `json:"recovery_code"` // 恢复码, 与 code 二选一
+}
+
+type MFAVerifyReq struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TOTPSetupResp 两步验证密钥, uri 用于生成验证器应用扫描的二维码
+type TOTPSetupResp struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPEnableResp 启用两步验证返回的恢复码(只返回这一次)与新的令牌
+type TOTPEnableResp struct {
+	RecoveryCodes []string   `json:"recovery_codes"`
+	Tokens        *TokenResp `json:"tokens"`
+}
+
+// TOTPStatusResp 两步验证状态
+type TOTPStatusResp struct {
+	Enabled       bool  `json:"enabled"`
+	Required      bool  `json:"required"`       // 当前角色是否必须启用
+	RecoveryCodes int64 `json:"recovery_codes"` // 剩余可用的恢复码数量
+}
+
+type UserIDReq struct {
+	UserID int64 `json:"user_id" binding:"required"`
+}
+
+type APITokenReq struct {
+	Name       string   `json:"name" binding:"required"`
+	Scopes     []string `json:"scopes" binding:"required"` // schedule:read/schedule:write/news:read
+	ExpireDays int      `json:"expire_days"`               // 有效天数, 为0表示永久有效
+}
+
+type APITokenIDReq struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+// StreamTicketResp SSE 票据, 通过 /stream?ticket= 建立连接
+type StreamTicketResp struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int64  `json:"expires_in"` // 有效期(秒)
+}
+
+// APITokenResp 创建个人访问令牌返回, token 明文只返回这一次
+type APITokenResp struct {
+	Token string    `json:"token"`
+	Info  *APIToken `json:"info"`
+}
+
+type RoleReq struct {
+	UserID int64  `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required"` // admin/member
+}
+
+type UserListReq struct {
+	Keyword string `json:"keyword"` // 按用户名、昵称模糊查询
+	Page    int    `json:"page"`
+	Size    int    `json:"size"`
+}
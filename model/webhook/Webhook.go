@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+)
+
+// Subscription Webhook 订阅, 事件发生时向 URL 投递 HMAC 签名的 JSON
+type Subscription struct {
+	ID       int64     `gorm:"column:id;primaryKey;autoIncrement;comment:订阅ID" json:"id"`
+	UserID   int64     `gorm:"column:user_id;default:0;not null;index:idx_user_id;comment:用户ID" json:"user_id"`
+	URL      string    `gorm:"column:url;type:varchar(500);not null;comment:回调地址" json:"url"`
+	Secret   string    `gorm:"column:secret;type:varchar(255);not null;comment:签名密钥" json:"-"`
+	Events   string    `gorm:"column:events;type:varchar(255);not null;comment:订阅事件(逗号分隔)" json:"events"`
+	Enabled  bool      `gorm:"column:enabled;not null;comment:是否启用" json:"enabled"`
+	CreateAt time.Time `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt time.Time `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (Subscription) TableName() string {
+	return "webhook_subscription"
+}
+
+// Subscribes 判断是否订阅了事件
+func (s *Subscription) Subscribes(event string) bool {
+	for _, e := range strings.Split(s.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery Webhook 投递记录, 同时作为投递队列与投递日志
+type Delivery struct {
+	ID             int64      `gorm:"column:id;primaryKey;autoIncrement;comment:投递ID" json:"id"`
+	SubscriptionID int64      `gorm:"column:subscription_id;not null;index:idx_subscription_id;comment:订阅ID" json:"subscription_id"`
+	EventID        string     `gorm:"column:event_id;type:varchar(64);not null;comment:事件ID" json:"event_id"`
+	Event          string     `gorm:"column:event;type:varchar(50);not null;comment:事件类型" json:"event"`
+	Payload        string     `gorm:"column:payload;type:longtext;comment:请求体" json:"payload"`
+	Status         string     `gorm:"column:status;type:varchar(20);not null;index:idx_status_next;comment:状态(pending/success/failed)" json:"status"`
+	Attempts       int        `gorm:"column:attempts;default:0;not null;comment:已尝试次数" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;not null;index:idx_status_next;comment:下次尝试时间" json:"next_attempt_at"`
+	ResponseStatus int        `gorm:"column:response_status;default:0;not null;comment:最近一次响应状态码" json:"response_status"`
+	ResponseBody   string     `gorm:"column:response_body;type:varchar(1000);default:'';not null;comment:最近一次响应内容" json:"response_body"`
+	LastError      string     `gorm:"column:last_error;type:varchar(1000);default:'';not null;comment:最近一次错误" json:"last_error"`
+	RedeliveryOf   int64      `gorm:"column:redelivery_of;default:0;not null;comment:重新投递的原投递ID" json:"redelivery_of"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at;comment:投递成功时间" json:"delivered_at"`
+	CreateAt       time.Time  `gorm:"column:create_at;default:CURRENT_TIMESTAMP;not null;comment:创建时间" json:"create_at"`
+	UpdateAt       time.Time  `gorm:"column:update_at;default:CURRENT_TIMESTAMP;not null;onUpdate:CURRENT_TIMESTAMP;comment:更新时间" json:"update_at"`
+}
+
+// TableName 设置表名
+func (Delivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// Envelope 投递的请求体
+type Envelope struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// 事件类型
+const (
+	EventScheduleCreated   = "schedule.created"
+	EventScheduleUpdated   = "schedule.updated"
+	EventScheduleCompleted = "schedule.completed"
+	EventScheduleDeleted   = "schedule.deleted"
+	EventNewsCreated       = "news.created"
+)
+
+// Events 可订阅的事件
+var Events = []string{
+	EventScheduleCreated, EventScheduleUpdated, EventScheduleCompleted, EventScheduleDeleted, EventNewsCreated,
+}
+
+// 投递状态
+const (
+	DeliveryPending = "pending"
+	DeliverySuccess = "success"
+	DeliveryFailed  = "failed"
+)
@@ -0,0 +1,38 @@
+package webhook
+
+type StoreReq struct {
+	ID      int64    `json:"id"` // 为0时新建
+	UserID  int64    `json:"-"`
+	URL     string   `json:"url" binding:"required"`
+	Secret  string   `json:"secret"` // 为空时新建订阅自动生成
+	Events  []string `json:"events" binding:"required"`
+	Enabled bool     `json:"enabled"`
+}
+
+// StoreResp 保存订阅的返回结果, 密钥只在保存时返回
+type StoreResp struct {
+	Subscription
+	Secret string `json:"secret"`
+}
+
+type IDReq struct {
+	ID     int64 `json:"id" binding:"required"`
+	UserID int64 `json:"-"`
+}
+
+type ListReq struct {
+	UserID int64 `json:"-"`
+}
+
+type DeliveryListReq struct {
+	SubscriptionID int64  `json:"subscription_id" binding:"required"`
+	UserID         int64  `json:"-"`
+	Status         string `json:"status"`
+	Page           int    `json:"page"`
+	Size           int    `json:"size"`
+}
+
+type RedeliverReq struct {
+	DeliveryID int64 `json:"delivery_id" binding:"required"`
+	UserID     int64 `json:"-"`
+}
@@ -17,11 +17,283 @@ CREATE TABLE IF NOT EXISTS `schedule` (
     `content` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '日程安排内容',
     `priority` TINYINT NOT NULL DEFAULT 0 COMMENT '优先级(0-低,1-中,2-高)',
     `status` INT NOT NULL DEFAULT 1 COMMENT '状态：1-未开始，2-进行中，3-已结束，4-已完成',
+    `version` INT NOT NULL DEFAULT 1 COMMENT '乐观锁版本号',
+    `tags` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标签(逗号分隔)',
+    `notes` MEDIUMTEXT COMMENT '备注(Markdown)',
+    `location` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '地点名称',
+    `address` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '详细地址',
+    `latitude` DOUBLE DEFAULT NULL COMMENT '纬度',
+    `longitude` DOUBLE DEFAULT NULL COMMENT '经度',
+    `meeting_url` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '线上会议地址',
+    `kind` VARCHAR(20) NOT NULL DEFAULT 'event' COMMENT '类型(event-日程,task-任务)',
+    `rollover_count` INT NOT NULL DEFAULT 0 COMMENT '逾期顺延次数',
+    `due_at` DATETIME DEFAULT NULL COMMENT '截止时间',
+    `effort_minutes` INT NOT NULL DEFAULT 0 COMMENT '预计耗时(分钟)',
+    `unscheduled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否未安排时间段(仅有截止时间的任务)',
     PRIMARY KEY (`id`),
     INDEX `idx_user_id` (`user_id`),
-    INDEX `idx_year_month_day` (`year`, `month`, `day`)
+    INDEX `idx_year_month_day` (`year`, `month`, `day`),
+    INDEX `idx_kind_end_time` (`kind`, `end_time`),
+    INDEX `idx_user_kind_due` (`user_id`, `kind`, `due_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程表';
 
+-- 创建日程变更历史表
+CREATE TABLE IF NOT EXISTS `schedule_history` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '历史ID',
+    `schedule_id` BIGINT NOT NULL COMMENT '日程ID',
+    `version` INT NOT NULL COMMENT '历史版本号',
+    `action` VARCHAR(20) NOT NULL COMMENT '操作类型(create/update/delete/revert)',
+    `actor_id` BIGINT NOT NULL DEFAULT 0 COMMENT '操作人ID',
+    `snapshot` MEDIUMTEXT COMMENT '该版本的日程快照',
+    `diff` MEDIUMTEXT COMMENT '字段级变更',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_schedule_version` (`schedule_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程变更历史表';
+
+-- 创建日程操作日志表(撤销/重做)
+CREATE TABLE IF NOT EXISTS `schedule_operation` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '操作ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `op_type` VARCHAR(20) NOT NULL COMMENT '操作类型',
+    `state` VARCHAR(20) NOT NULL COMMENT '状态(done/undone/discarded)',
+    `changes` LONGTEXT COMMENT '日程变更列表',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_state` (`user_id`, `state`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程操作日志表';
+
+-- 创建日程依赖表
+CREATE TABLE IF NOT EXISTS `schedule_dependency` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '依赖ID',
+    `predecessor_id` BIGINT NOT NULL COMMENT '前置日程ID',
+    `successor_id` BIGINT NOT NULL COMMENT '后置日程ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '创建人ID',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_predecessor_successor` (`predecessor_id`, `successor_id`),
+    INDEX `idx_successor_id` (`successor_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程依赖表';
+
+-- 创建倒数日/纪念日表
+CREATE TABLE IF NOT EXISTS `countdown` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '倒数日ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `title` VARCHAR(200) NOT NULL COMMENT '标题',
+    `kind` VARCHAR(20) NOT NULL DEFAULT 'countdown' COMMENT '类型(countdown-倒数日,anniversary-每年重复的纪念日)',
+    `calendar` VARCHAR(10) NOT NULL DEFAULT 'solar' COMMENT '历法(solar-公历,lunar-农历)',
+    `year` INT NOT NULL DEFAULT 0 COMMENT '年(纪念日可为0)',
+    `month` INT NOT NULL COMMENT '月',
+    `day` INT NOT NULL COMMENT '日',
+    `leap_month` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否农历闰月',
+    `remark` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='倒数日/纪念日表';
+
+-- 创建每日简报设置表
+CREATE TABLE IF NOT EXISTS `briefing_setting` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '设置ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `send_time` VARCHAR(5) NOT NULL DEFAULT '07:30' COMMENT '每日生成时间(HH:MM)',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每日简报设置表';
+
+-- 创建通知渠道表
+CREATE TABLE IF NOT EXISTS `notify_channel` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '渠道ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `type` VARCHAR(20) NOT NULL COMMENT '渠道类型',
+    `name` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '渠道名称',
+    `target` VARCHAR(500) NOT NULL COMMENT '接收地址',
+    `secret` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '签名密钥',
+    `events` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '订阅事件(逗号分隔)',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='通知渠道表';
+
+-- 创建通知投递表(投递队列与投递日志)
+CREATE TABLE IF NOT EXISTS `notify_delivery` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '投递ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `channel_id` BIGINT NOT NULL COMMENT '渠道ID',
+    `channel_type` VARCHAR(20) NOT NULL COMMENT '渠道类型',
+    `event` VARCHAR(50) NOT NULL COMMENT '通知事件',
+    `dedupe_key` VARCHAR(191) NOT NULL COMMENT '去重键',
+    `subject` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标题',
+    `payload` LONGTEXT COMMENT '消息内容',
+    `status` VARCHAR(20) NOT NULL COMMENT '状态(pending/sent/failed)',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+    `next_attempt_at` DATETIME NOT NULL COMMENT '下次尝试时间',
+    `last_error` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '最近一次错误',
+    `sent_at` DATETIME DEFAULT NULL COMMENT '发送成功时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_dedupe_key` (`dedupe_key`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_status_next` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='通知投递表';
+
+-- 创建 Webhook 订阅表
+CREATE TABLE IF NOT EXISTS `webhook_subscription` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '订阅ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `url` VARCHAR(500) NOT NULL COMMENT '回调地址',
+    `secret` VARCHAR(255) NOT NULL COMMENT '签名密钥',
+    `events` VARCHAR(255) NOT NULL COMMENT '订阅事件(逗号分隔)',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 订阅表';
+
+-- 创建 Webhook 投递表(投递队列与投递日志)
+CREATE TABLE IF NOT EXISTS `webhook_delivery` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '投递ID',
+    `subscription_id` BIGINT NOT NULL COMMENT '订阅ID',
+    `event_id` VARCHAR(64) NOT NULL COMMENT '事件ID',
+    `event` VARCHAR(50) NOT NULL COMMENT '事件类型',
+    `payload` LONGTEXT COMMENT '请求体',
+    `status` VARCHAR(20) NOT NULL COMMENT '状态(pending/success/failed)',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+    `next_attempt_at` DATETIME NOT NULL COMMENT '下次尝试时间',
+    `response_status` INT NOT NULL DEFAULT 0 COMMENT '最近一次响应状态码',
+    `response_body` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '最近一次响应内容',
+    `last_error` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '最近一次错误',
+    `redelivery_of` BIGINT NOT NULL DEFAULT 0 COMMENT '重新投递的原投递ID',
+    `delivered_at` DATETIME DEFAULT NULL COMMENT '投递成功时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_subscription_id` (`subscription_id`),
+    INDEX `idx_status_next` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 投递表';
+
+-- 创建分享链接表
+CREATE TABLE IF NOT EXISTS `share_link` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '分享ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `token` VARCHAR(64) NOT NULL COMMENT '分享令牌',
+    `kind` VARCHAR(20) NOT NULL COMMENT '类型(schedule-单个日程,calendar-日历视图)',
+    `schedule_id` BIGINT NOT NULL DEFAULT 0 COMMENT '分享的日程ID',
+    `filter` VARCHAR(500) DEFAULT NULL COMMENT '日历视图筛选条件',
+    `redact` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否隐藏内容与优先级(显示为忙碌)',
+    `expire_at` DATETIME DEFAULT NULL COMMENT '过期时间, 为空表示永久有效',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '撤销时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token` (`token`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分享链接表';
+
+-- 创建用户表
+CREATE TABLE IF NOT EXISTS `user` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '用户ID',
+    `username` VARCHAR(50) NOT NULL COMMENT '用户名',
+    `email` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '邮箱',
+    `nickname` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '昵称',
+    `password_hash` VARCHAR(100) NOT NULL COMMENT '密码哈希(bcrypt)',
+    `role` VARCHAR(20) NOT NULL DEFAULT 'member' COMMENT '角色(admin/member)',
+    `totp_secret` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'TOTP密钥(base32)',
+    `totp_enabled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用两步验证',
+    `totp_last_step` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次使用的TOTP时间步, 防止验证码重放',
+    `last_login_at` DATETIME DEFAULT NULL COMMENT '最近登录时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_username` (`username`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
+
+-- 创建刷新令牌表
+CREATE TABLE IF NOT EXISTS `user_refresh_token` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `token_id` VARCHAR(64) NOT NULL COMMENT '令牌ID(jti)',
+    `expire_at` DATETIME NOT NULL COMMENT '过期时间',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '撤销时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token_id` (`token_id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
+
+-- 创建外部身份绑定表
+CREATE TABLE IF NOT EXISTS `user_identity` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `issuer` VARCHAR(255) NOT NULL COMMENT '身份提供方(iss)',
+    `subject` VARCHAR(255) NOT NULL COMMENT '提供方用户标识(sub)',
+    `email` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '最近一次登录时的邮箱',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '绑定时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_issuer_subject` (`issuer`, `subject`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='外部身份绑定表';
+
+-- 创建两步验证恢复码表
+CREATE TABLE IF NOT EXISTS `user_recovery_code` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `code_hash` VARCHAR(64) NOT NULL COMMENT '恢复码SHA-256哈希',
+    `used_at` DATETIME DEFAULT NULL COMMENT '使用时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='两步验证恢复码表';
+
+-- 创建个人访问令牌表
+CREATE TABLE IF NOT EXISTS `user_api_token` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '令牌ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `name` VARCHAR(50) NOT NULL COMMENT '令牌名称',
+    `hint` VARCHAR(20) NOT NULL COMMENT '令牌前几位, 用于识别',
+    `token_hash` CHAR(64) NOT NULL COMMENT '令牌哈希(SHA-256)',
+    `scopes` VARCHAR(255) NOT NULL COMMENT '权限范围(逗号分隔)',
+    `expire_at` DATETIME DEFAULT NULL COMMENT '过期时间, 为空表示永久有效',
+    `last_used_at` DATETIME DEFAULT NULL COMMENT '最近使用时间',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '撤销时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token_hash` (`token_hash`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='个人访问令牌表';
+
+-- 创建团队表
+CREATE TABLE IF NOT EXISTS `team` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '团队ID',
+    `name` VARCHAR(50) NOT NULL COMMENT '团队名称',
+    `description` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '团队描述',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='团队表';
+
+-- 创建团队成员表
+CREATE TABLE IF NOT EXISTS `team_member` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `team_id` BIGINT NOT NULL COMMENT '团队ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `role` VARCHAR(20) NOT NULL DEFAULT 'member' COMMENT '团队角色(lead/member)',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '加入时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_team_user` (`team_id`, `user_id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='团队成员表';
+
 CREATE TABLE `news` (
                         `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '新闻ID',
                         `news_id` varchar(100) NOT NULL COMMENT '新闻唯一标识',
@@ -0,0 +1,15 @@
+-- 日程变更历史表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `schedule_history` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '历史ID',
+    `schedule_id` BIGINT NOT NULL COMMENT '日程ID',
+    `version` INT NOT NULL COMMENT '历史版本号',
+    `action` VARCHAR(20) NOT NULL COMMENT '操作类型(create/update/delete/revert)',
+    `actor_id` BIGINT NOT NULL DEFAULT 0 COMMENT '操作人ID',
+    `snapshot` MEDIUMTEXT COMMENT '该版本的日程快照',
+    `diff` MEDIUMTEXT COMMENT '字段级变更',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_schedule_version` (`schedule_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程变更历史表';
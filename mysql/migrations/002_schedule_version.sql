@@ -0,0 +1,5 @@
+-- 日程乐观锁版本号, 已有日程的版本号从 1 开始
+USE `FilmSite`;
+
+ALTER TABLE `schedule`
+    ADD COLUMN `version` INT NOT NULL DEFAULT 1 COMMENT '乐观锁版本号' AFTER `status`;
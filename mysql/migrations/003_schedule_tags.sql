@@ -0,0 +1,5 @@
+-- 日程标签, 用于批量操作中的添加/移除标签
+USE `FilmSite`;
+
+ALTER TABLE `schedule`
+    ADD COLUMN `tags` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标签(逗号分隔)' AFTER `version`;
@@ -0,0 +1,14 @@
+-- 日程操作日志表, 用于撤销/重做, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `schedule_operation` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '操作ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `op_type` VARCHAR(20) NOT NULL COMMENT '操作类型',
+    `state` VARCHAR(20) NOT NULL COMMENT '状态(done/undone/discarded)',
+    `changes` LONGTEXT COMMENT '日程变更列表',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_state` (`user_id`, `state`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程操作日志表';
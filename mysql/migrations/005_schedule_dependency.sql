@@ -0,0 +1,13 @@
+-- 日程依赖表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `schedule_dependency` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '依赖ID',
+    `predecessor_id` BIGINT NOT NULL COMMENT '前置日程ID',
+    `successor_id` BIGINT NOT NULL COMMENT '后置日程ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '创建人ID',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_predecessor_successor` (`predecessor_id`, `successor_id`),
+    INDEX `idx_successor_id` (`successor_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='日程依赖表';
@@ -0,0 +1,19 @@
+-- 倒数日/纪念日表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `countdown` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '倒数日ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `title` VARCHAR(200) NOT NULL COMMENT '标题',
+    `kind` VARCHAR(20) NOT NULL DEFAULT 'countdown' COMMENT '类型(countdown-倒数日,anniversary-每年重复的纪念日)',
+    `calendar` VARCHAR(10) NOT NULL DEFAULT 'solar' COMMENT '历法(solar-公历,lunar-农历)',
+    `year` INT NOT NULL DEFAULT 0 COMMENT '年(纪念日可为0)',
+    `month` INT NOT NULL COMMENT '月',
+    `day` INT NOT NULL COMMENT '日',
+    `leap_month` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否农历闰月',
+    `remark` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='倒数日/纪念日表';
@@ -0,0 +1,13 @@
+-- 每日简报设置表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `briefing_setting` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '设置ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `send_time` VARCHAR(5) NOT NULL DEFAULT '07:30' COMMENT '每日生成时间(HH:MM)',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='每日简报设置表';
@@ -0,0 +1,39 @@
+-- 通知渠道表与通知投递表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `notify_channel` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '渠道ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `type` VARCHAR(20) NOT NULL COMMENT '渠道类型',
+    `name` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '渠道名称',
+    `target` VARCHAR(500) NOT NULL COMMENT '接收地址',
+    `secret` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '签名密钥',
+    `events` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '订阅事件(逗号分隔)',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='通知渠道表';
+
+CREATE TABLE IF NOT EXISTS `notify_delivery` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '投递ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `channel_id` BIGINT NOT NULL COMMENT '渠道ID',
+    `channel_type` VARCHAR(20) NOT NULL COMMENT '渠道类型',
+    `event` VARCHAR(50) NOT NULL COMMENT '通知事件',
+    `dedupe_key` VARCHAR(191) NOT NULL COMMENT '去重键',
+    `subject` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '标题',
+    `payload` LONGTEXT COMMENT '消息内容',
+    `status` VARCHAR(20) NOT NULL COMMENT '状态(pending/sent/failed)',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+    `next_attempt_at` DATETIME NOT NULL COMMENT '下次尝试时间',
+    `last_error` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '最近一次错误',
+    `sent_at` DATETIME DEFAULT NULL COMMENT '发送成功时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_dedupe_key` (`dedupe_key`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_status_next` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='通知投递表';
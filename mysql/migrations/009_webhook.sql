@@ -0,0 +1,36 @@
+-- Webhook 订阅表与投递表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `webhook_subscription` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '订阅ID',
+    `user_id` BIGINT NOT NULL DEFAULT 0 COMMENT '用户ID',
+    `url` VARCHAR(500) NOT NULL COMMENT '回调地址',
+    `secret` VARCHAR(255) NOT NULL COMMENT '签名密钥',
+    `events` VARCHAR(255) NOT NULL COMMENT '订阅事件(逗号分隔)',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 订阅表';
+
+CREATE TABLE IF NOT EXISTS `webhook_delivery` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '投递ID',
+    `subscription_id` BIGINT NOT NULL COMMENT '订阅ID',
+    `event_id` VARCHAR(64) NOT NULL COMMENT '事件ID',
+    `event` VARCHAR(50) NOT NULL COMMENT '事件类型',
+    `payload` LONGTEXT COMMENT '请求体',
+    `status` VARCHAR(20) NOT NULL COMMENT '状态(pending/success/failed)',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+    `next_attempt_at` DATETIME NOT NULL COMMENT '下次尝试时间',
+    `response_status` INT NOT NULL DEFAULT 0 COMMENT '最近一次响应状态码',
+    `response_body` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '最近一次响应内容',
+    `last_error` VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '最近一次错误',
+    `redelivery_of` BIGINT NOT NULL DEFAULT 0 COMMENT '重新投递的原投递ID',
+    `delivered_at` DATETIME DEFAULT NULL COMMENT '投递成功时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_subscription_id` (`subscription_id`),
+    INDEX `idx_status_next` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 投递表';
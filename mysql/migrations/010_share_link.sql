@@ -0,0 +1,19 @@
+-- 分享链接表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `share_link` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '分享ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `token` VARCHAR(64) NOT NULL COMMENT '分享令牌',
+    `kind` VARCHAR(20) NOT NULL COMMENT '类型(schedule-单个日程,calendar-日历视图)',
+    `schedule_id` BIGINT NOT NULL DEFAULT 0 COMMENT '分享的日程ID',
+    `filter` VARCHAR(500) DEFAULT NULL COMMENT '日历视图筛选条件',
+    `redact` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否隐藏内容与优先级(显示为忙碌)',
+    `expire_at` DATETIME DEFAULT NULL COMMENT '过期时间, 为空表示永久有效',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '撤销时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token` (`token`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分享链接表';
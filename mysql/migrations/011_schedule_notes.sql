@@ -0,0 +1,9 @@
+-- 日程备注(Markdown), 变更历史的快照与差异包含备注, 同时扩大为 MEDIUMTEXT
+USE `FilmSite`;
+
+ALTER TABLE `schedule`
+    ADD COLUMN `notes` MEDIUMTEXT COMMENT '备注(Markdown)' AFTER `tags`;
+
+ALTER TABLE `schedule_history`
+    MODIFY COLUMN `snapshot` MEDIUMTEXT COMMENT '该版本的日程快照',
+    MODIFY COLUMN `diff` MEDIUMTEXT COMMENT '字段级变更';
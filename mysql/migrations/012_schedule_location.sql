@@ -0,0 +1,9 @@
+-- 日程地点与线上会议地址
+USE `FilmSite`;
+
+ALTER TABLE `schedule`
+    ADD COLUMN `location` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '地点名称' AFTER `notes`,
+    ADD COLUMN `address` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '详细地址' AFTER `location`,
+    ADD COLUMN `latitude` DOUBLE DEFAULT NULL COMMENT '纬度' AFTER `address`,
+    ADD COLUMN `longitude` DOUBLE DEFAULT NULL COMMENT '经度' AFTER `latitude`,
+    ADD COLUMN `meeting_url` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '线上会议地址' AFTER `longitude`;
@@ -0,0 +1,7 @@
+-- 日程类型与逾期任务顺延次数, 已有日程均为普通日程
+USE `FilmSite`;
+
+ALTER TABLE `schedule`
+    ADD COLUMN `kind` VARCHAR(20) NOT NULL DEFAULT 'event' COMMENT '类型(event-日程,task-任务)' AFTER `meeting_url`,
+    ADD COLUMN `rollover_count` INT NOT NULL DEFAULT 0 COMMENT '逾期顺延次数' AFTER `kind`,
+    ADD INDEX `idx_kind_end_time` (`kind`, `end_time`);
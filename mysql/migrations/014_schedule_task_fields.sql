@@ -0,0 +1,8 @@
+-- 任务截止时间、预计耗时以及未安排时间段标记
+USE `FilmSite`;
+
+ALTER TABLE `schedule`
+    ADD COLUMN `due_at` DATETIME DEFAULT NULL COMMENT '截止时间' AFTER `rollover_count`,
+    ADD COLUMN `effort_minutes` INT NOT NULL DEFAULT 0 COMMENT '预计耗时(分钟)' AFTER `due_at`,
+    ADD COLUMN `unscheduled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否未安排时间段(仅有截止时间的任务)' AFTER `effort_minutes`,
+    ADD INDEX `idx_user_kind_due` (`user_id`, `kind`, `due_at`);
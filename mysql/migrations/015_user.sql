@@ -0,0 +1,31 @@
+-- 用户表与刷新令牌表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `user` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '用户ID',
+    `username` VARCHAR(50) NOT NULL COMMENT '用户名',
+    `email` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '邮箱',
+    `nickname` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '昵称',
+    `password_hash` VARCHAR(100) NOT NULL COMMENT '密码哈希(bcrypt)',
+    `role` VARCHAR(20) NOT NULL DEFAULT 'member' COMMENT '角色(admin/member)',
+    `totp_secret` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'TOTP密钥(base32)',
+    `totp_enabled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用两步验证',
+    `totp_last_step` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次使用的TOTP时间步, 防止验证码重放',
+    `last_login_at` DATETIME DEFAULT NULL COMMENT '最近登录时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_username` (`username`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
+
+CREATE TABLE IF NOT EXISTS `user_refresh_token` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `token_id` VARCHAR(64) NOT NULL COMMENT '令牌ID(jti)',
+    `expire_at` DATETIME NOT NULL COMMENT '过期时间',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '撤销时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token_id` (`token_id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
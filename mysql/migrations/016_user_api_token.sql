@@ -0,0 +1,18 @@
+-- 个人访问令牌表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `user_api_token` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '令牌ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `name` VARCHAR(50) NOT NULL COMMENT '令牌名称',
+    `hint` VARCHAR(20) NOT NULL COMMENT '令牌前几位, 用于识别',
+    `token_hash` CHAR(64) NOT NULL COMMENT '令牌哈希(SHA-256)',
+    `scopes` VARCHAR(255) NOT NULL COMMENT '权限范围(逗号分隔)',
+    `expire_at` DATETIME DEFAULT NULL COMMENT '过期时间, 为空表示永久有效',
+    `last_used_at` DATETIME DEFAULT NULL COMMENT '最近使用时间',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '撤销时间',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token_hash` (`token_hash`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='个人访问令牌表';
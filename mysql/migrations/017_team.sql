@@ -0,0 +1,23 @@
+-- 团队表与团队成员表, 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `team` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '团队ID',
+    `name` VARCHAR(50) NOT NULL COMMENT '团队名称',
+    `description` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '团队描述',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='团队表';
+
+CREATE TABLE IF NOT EXISTS `team_member` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `team_id` BIGINT NOT NULL COMMENT '团队ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `role` VARCHAR(20) NOT NULL DEFAULT 'member' COMMENT '团队角色(lead/member)',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '加入时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_team_user` (`team_id`, `user_id`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='团队成员表';
@@ -0,0 +1,14 @@
+-- 外部身份绑定表(单点登录), 表结构与 init.sql 一致
+USE `FilmSite`;
+
+CREATE TABLE IF NOT EXISTS `user_identity` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    `user_id` BIGINT NOT NULL COMMENT '用户ID',
+    `issuer` VARCHAR(255) NOT NULL COMMENT '身份提供方(iss)',
+    `subject` VARCHAR(255) NOT NULL COMMENT '提供方用户标识(sub)',
+    `email` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '最近一次登录时的邮箱',
+    `create_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '绑定时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_issuer_subject` (`issuer`, `subject`),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='外部身份绑定表';
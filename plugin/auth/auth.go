@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/dao"
+	"go-film-demo/model/user"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	用户认证: 密码使用 bcrypt 哈希保存; 登录后签发短期访问令牌与长期刷新令牌,
+	刷新令牌记录在数据库中, 每次刷新都会轮换, 已轮换的令牌再次使用时视为泄露并撤销该用户的全部刷新令牌
+*/
+
+// ErrBadCredentials 用户名或密码错误
+var ErrBadCredentials = errors.New("用户名或密码错误")
+
+var (
+	UserDao = dao.NewUserDao()
+
+	secretOnce sync.Once
+	secret     []byte
+
+	// dummyHash 用户不存在时同样执行一次 bcrypt 比较, 避免通过响应时间枚举用户名
+	dummyHash, _ = bcrypt.GenerateFromPassword([]byte("go-schedule"), bcrypt.MinCost)
+)
+
+// signingKey 返回签名密钥, 未配置 JWT_SECRET 时随机生成
+func signingKey() []byte {
+	secretOnce.Do(func() {
+		if config.JWTSecret != "" {
+			secret = []byte(config.JWTSecret)
+			return
+		}
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+		log.Println("未配置 JWT_SECRET, 已随机生成签名密钥, 服务重启后需要重新登录")
+	})
+	return secret
+}
+
+// ValidatePassword 校验密码长度
+func ValidatePassword(password string) error {
+	if len(password) < user.PasswordMinLen || len(password) > user.PasswordMaxLen {
+		return fmt.Errorf("密码长度必须为 %d-%d 个字符", user.PasswordMinLen, user.PasswordMaxLen)
+	}
+	return nil
+}
+
+// HashPassword 使用 bcrypt 计算密码哈希
+func HashPassword(password string) (string, error) {
+	cost := config.BcryptCost
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验密码是否与哈希匹配
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Login 校验用户名密码并签发令牌
+func Login(username, password string) (*user.TokenResp, error) {
+	u, err := UserDao.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrBadCredentials
+	}
+	if !CheckPassword(u.PasswordHash, password) {
+		return nil, ErrBadCredentials
+	}
+	return beginSession(u)
+}
+
+// beginSession 第一步认证通过后开始会话: 启用两步验证的用户返回验证挑战, 否则直接签发令牌
+func beginSession(u *user.User) (*user.TokenResp, error) {
+	if u.TOTPEnabled {
+		return mfaChallenge(u)
+	}
+	return completeLogin(u)
+}
+
+// BootstrapAdmin 按 ADMIN_USERNAME 初始化管理员: 用户不存在时使用 ADMIN_PASSWORD 创建, 已存在时设置为管理员;
+// 注册与单点登录创建的用户都是普通成员, 避免在新部署上抢先注册获得管理员权限
+func BootstrapAdmin() error {
+	if config.AdminUsername == "" {
+		return nil
+	}
+	u, err := UserDao.GetUserByUsername(config.AdminUsername)
+	if err != nil {
+		return err
+	}
+	if u != nil {
+		if u.Role == user.RoleAdmin {
+			return nil
+		}
+		log.Printf("将用户 %s 设置为管理员", u.Username)
+		return UserDao.UpdateRole(u.ID, user.RoleAdmin)
+	}
+
+	if err = ValidatePassword(config.AdminPassword); err != nil {
+		return fmt.Errorf("ADMIN_PASSWORD 不符合要求: %w", err)
+	}
+	hash, err := HashPassword(config.AdminPassword)
+	if err != nil {
+		return err
+	}
+	u = &user.User{Username: config.AdminUsername, Nickname: config.AdminUsername, PasswordHash: hash, Role: user.RoleAdmin}
+	if err = UserDao.CreateUser(u); err != nil {
+		return err
+	}
+	log.Printf("已创建管理员 %s", u.Username)
+	return nil
+}
+
+// completeLogin 记录登录时间并签发令牌
+func completeLogin(u *user.User) (*user.TokenResp, error) {
+	now := time.Now()
+	UserDao.TouchLogin(u.ID, now)
+	u.LastLoginAt = &now
+	return IssueTokens(u)
+}
+
+// IssueTokens 为用户签发访问令牌与刷新令牌
+func IssueTokens(u *user.User) (*user.TokenResp, error) {
+	now := time.Now()
+	accessTTL := time.Duration(config.AccessTokenMinutes) * time.Minute
+	refreshTTL := time.Duration(config.RefreshTokenHours) * time.Hour
+	subject := strconv.FormatInt(u.ID, 10)
+	enroll := TOTPRequired(u.Role) && !u.TOTPEnabled
+
+	access, err := Sign(&Claims{
+		Issuer: config.JWTIssuer, Subject: subject, Type: user.TokenAccess, ID: randomHex(16),
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(accessTTL).Unix(), Enroll: enroll,
+	}, signingKey())
+	if err != nil {
+		return nil, err
+	}
+
+	refreshClaims := &Claims{
+		Issuer: config.JWTIssuer, Subject: subject, Type: user.TokenRefresh, ID: randomHex(16),
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(refreshTTL).Unix(),
+	}
+	refresh, err := Sign(refreshClaims, signingKey())
+	if err != nil {
+		return nil, err
+	}
+	record := &user.RefreshToken{UserID: u.ID, TokenID: refreshClaims.ID, ExpireAt: now.Add(refreshTTL), CreateAt: now}
+	if err = UserDao.SaveRefreshToken(record); err != nil {
+		return nil, err
+	}
+
+	return &user.TokenResp{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL.Seconds()),
+		User:         u,
+		TOTPEnroll:   enroll,
+	}, nil
+}
+
+// Authenticate 校验访问令牌
+func Authenticate(token string) (*Principal, error) {
+	claims, err := Parse(token, signingKey(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != user.TokenAccess {
+		return nil, ErrInvalidToken
+	}
+	return &Principal{UserID: claims.UserID(), TOTPEnroll: claims.Enroll}, nil
+}
+
+// streamTicketTTL SSE 票据有效期, 只需覆盖从获取票据到建立连接的时间
+const streamTicketTTL = time.Minute
+
+// IssueStreamTicket 签发建立 SSE 连接的短期票据; EventSource 无法设置请求头, 票据通过查询参数传递,
+// 即使被代理或访问日志记录也会很快失效, 且不能用于其他接口
+func IssueStreamTicket(userID int64) (*user.StreamTicketResp, error) {
+	now := time.Now()
+	ticket, err := Sign(&Claims{
+		Issuer: config.JWTIssuer, Subject: strconv.FormatInt(userID, 10), Type: user.TokenStream, ID: randomHex(16),
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(streamTicketTTL).Unix(),
+	}, signingKey())
+	if err != nil {
+		return nil, err
+	}
+	return &user.StreamTicketResp{Ticket: ticket, ExpiresIn: int64(streamTicketTTL.Seconds())}, nil
+}
+
+// AuthenticateStreamTicket 校验 SSE 票据
+func AuthenticateStreamTicket(ticket string) (*Principal, error) {
+	claims, err := Parse(ticket, signingKey(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != user.TokenStream {
+		return nil, ErrInvalidToken
+	}
+	return &Principal{UserID: claims.UserID()}, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对, 旧的刷新令牌随即失效
+func Refresh(token string) (*user.TokenResp, error) {
+	claims, err := parseRefresh(token)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := UserDao.RevokeRefreshToken(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// 已撤销的令牌被再次使用, 可能已经泄露
+		log.Printf("用户 %d 的刷新令牌被重复使用, 撤销全部刷新令牌", claims.UserID())
+		if err = UserDao.RevokeUserRefreshTokens(claims.UserID()); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+
+	u, err := UserDao.GetUserByID(claims.UserID())
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrInvalidToken
+	}
+	return IssueTokens(u)
+}
+
+// Logout 撤销刷新令牌
+func Logout(token string) error {
+	claims, err := parseRefresh(token)
+	if err != nil {
+		return err
+	}
+	_, err = UserDao.RevokeRefreshToken(claims.ID)
+	return err
+}
+
+// parseRefresh 校验刷新令牌并确认已签发记录存在
+func parseRefresh(token string) (*Claims, error) {
+	claims, err := Parse(token, signingKey(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != user.TokenRefresh || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	record, err := UserDao.GetRefreshToken(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.UserID != claims.UserID() {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// randomHex 生成 n 字节随机数的十六进制
+func randomHex(n int) string {
+	return hex.EncodeToString(randomBytes(n))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
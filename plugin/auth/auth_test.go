@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"errors"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/db"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// fakeRefreshDB 不执行 SQL 的连接: 查询刷新令牌与用户时返回给定记录, rotated 决定撤销单个令牌的 UPDATE 是否命中
+type fakeRefreshDB struct {
+	record  user.RefreshToken
+	user    user.User
+	rotated bool
+	updates []string
+}
+
+func useFakeRefreshDB(t *testing.T, f *fakeRefreshDB) {
+	t.Helper()
+	conn, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=True",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		NamingStrategy:         schema.NamingStrategy{SingularTable: true},
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Callback().Query().After("gorm:query").Register("test:fake_rows", func(tx *gorm.DB) {
+		switch dest := tx.Statement.Dest.(type) {
+		case *user.RefreshToken:
+			*dest = f.record
+		case *user.User:
+			*dest = f.user
+		}
+	})
+	_ = conn.Callback().Update().After("gorm:update").Register("test:rows_affected", func(tx *gorm.DB) {
+		sql := tx.Statement.SQL.String()
+		f.updates = append(f.updates, sql)
+		if f.rotated && strings.Contains(sql, "token_id") {
+			tx.RowsAffected = 1
+		}
+	})
+
+	previous := db.Mdb
+	db.Mdb = conn
+	t.Cleanup(func() { db.Mdb = previous })
+}
+
+func issue(t *testing.T, userID int64, typ, id string) string {
+	t.Helper()
+	now := time.Now()
+	token, err := Sign(&Claims{
+		Subject: strconv.FormatInt(userID, 10), Type: typ, ID: id,
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix(),
+	}, signingKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthenticateOnlyAcceptsAccessTokens(t *testing.T) {
+	for typ, want := range map[string]error{
+		user.TokenAccess:  nil,
+		user.TokenRefresh: ErrInvalidToken,
+		user.TokenMFA:     ErrInvalidToken,
+		user.TokenStream:  ErrInvalidToken,
+		"":                ErrInvalidToken,
+	} {
+		p, err := Authenticate(issue(t, 3, typ, "jti"))
+		if !errors.Is(err, want) {
+			t.Errorf("typ=%q: err = %v, want %v", typ, err, want)
+			continue
+		}
+		if err == nil && p.UserID != 3 {
+			t.Errorf("typ=%q: UserID = %d, want 3", typ, p.UserID)
+		}
+	}
+
+	for typ, want := range map[string]error{user.TokenStream: nil, user.TokenAccess: ErrInvalidToken, user.TokenRefresh: ErrInvalidToken} {
+		if _, err := AuthenticateStreamTicket(issue(t, 3, typ, "jti")); !errors.Is(err, want) {
+			t.Errorf("stream typ=%q: err = %v, want %v", typ, err, want)
+		}
+	}
+}
+
+func TestRefreshRejectsOtherTokenTypes(t *testing.T) {
+	f := &fakeRefreshDB{record: user.RefreshToken{UserID: 3, TokenID: "jti"}, rotated: true}
+	useFakeRefreshDB(t, f)
+	for _, typ := range []string{user.TokenAccess, user.TokenMFA, user.TokenStream} {
+		if _, err := Refresh(issue(t, 3, typ, "jti")); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("typ=%q: err = %v, want ErrInvalidToken", typ, err)
+		}
+	}
+	if len(f.updates) != 0 {
+		t.Errorf("非刷新令牌不应撤销任何令牌: %v", f.updates)
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	f := &fakeRefreshDB{record: user.RefreshToken{UserID: 3, TokenID: "jti"}, user: user.User{ID: 3, Username: "u"}, rotated: true}
+	useFakeRefreshDB(t, f)
+	resp, err := Refresh(issue(t, 3, user.TokenRefresh, "jti"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.updates) != 1 || !strings.Contains(f.updates[0], "token_id") {
+		t.Errorf("应只撤销本次使用的刷新令牌: %v", f.updates)
+	}
+	claims, err := Parse(resp.RefreshToken, signingKey(), time.Now())
+	if err != nil || claims.Type != user.TokenRefresh || claims.ID == "jti" || claims.UserID() != 3 {
+		t.Errorf("新刷新令牌 = %+v, %v", claims, err)
+	}
+}
+
+func TestRefreshReuseRevokesAllTokens(t *testing.T) {
+	f := &fakeRefreshDB{record: user.RefreshToken{UserID: 3, TokenID: "jti"}, user: user.User{ID: 3}, rotated: false}
+	useFakeRefreshDB(t, f)
+	if _, err := Refresh(issue(t, 3, user.TokenRefresh, "jti")); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("err = %v, want ErrInvalidToken", err)
+	}
+	if len(f.updates) != 2 || !strings.Contains(f.updates[1], "user_id") || strings.Contains(f.updates[1], "token_id") {
+		t.Errorf("重复使用应撤销该用户全部刷新令牌: %v", f.updates)
+	}
+}
+
+func TestRefreshRejectsTokenOfAnotherUser(t *testing.T) {
+	f := &fakeRefreshDB{record: user.RefreshToken{UserID: 4, TokenID: "jti"}, rotated: true}
+	useFakeRefreshDB(t, f)
+	if _, err := Refresh(issue(t, 3, user.TokenRefresh, "jti")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("err = %v, want ErrInvalidToken", err)
+	}
+	if len(f.updates) != 0 {
+		t.Errorf("记录不属于令牌用户时不应撤销: %v", f.updates)
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("jwt-test")
+
+// forge 使用任意头部签名生成令牌, 用于构造非法令牌
+func forge(t *testing.T, header string, claims *Claims, secret []byte) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signing + "." + base64.RawURLEncoding.EncodeToString(signHS256(signing, secret))
+}
+
+func TestParse(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := func() *Claims {
+		return &Claims{Subject: "7", Type: "access", ID: "abc", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	}
+	sign := func(c *Claims) string {
+		token, err := Sign(c, testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := sign(claims())
+	parts := strings.Split(valid, ".")
+
+	tampered := claims()
+	tampered.Subject = "1"
+	tamperedPayload, _ := json.Marshal(tampered)
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	signature[0] ^= 1
+
+	expired := claims()
+	expired.ExpiresAt = now.Add(-clockSkew - time.Second).Unix()
+	skewedExpiry := claims()
+	skewedExpiry.ExpiresAt = now.Add(-clockSkew).Unix()
+	future := claims()
+	future.IssuedAt = now.Add(clockSkew + time.Second).Unix()
+	skewedIssue := claims()
+	skewedIssue.IssuedAt = now.Add(clockSkew).Unix()
+	noSubject := claims()
+	noSubject.Subject = "abc"
+
+	cases := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"有效令牌", valid, nil},
+		{"alg=none", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + ".", ErrInvalidToken},
+		{"alg=none 并保留签名", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "." + parts[2], ErrInvalidToken},
+		{"alg=RS256", forge(t, `{"alg":"RS256","typ":"JWT"}`, claims(), testSecret), ErrInvalidToken},
+		{"alg 大小写", forge(t, `{"alg":"hs256","typ":"JWT"}`, claims(), testSecret), ErrInvalidToken},
+		{"头部不是 JSON", forge(t, `HS256`, claims(), testSecret), ErrInvalidToken},
+		{"篡改载荷", parts[0] + "." + base64.RawURLEncoding.EncodeToString(tamperedPayload) + "." + parts[2], ErrInvalidToken},
+		{"篡改签名", parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(signature), ErrInvalidToken},
+		{"签名为空", parts[0] + "." + parts[1] + ".", ErrInvalidToken},
+		{"其他密钥签名", forge(t, `{"alg":"HS256","typ":"JWT"}`, claims(), []byte("other")), ErrInvalidToken},
+		{"段数不足", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"段数过多", valid + ".x", ErrInvalidToken},
+		{"非 base64url 签名", parts[0] + "." + parts[1] + ".***", ErrInvalidToken},
+		{"已过期", sign(expired), ErrTokenExpired},
+		{"过期但在时钟误差内", sign(skewedExpiry), nil},
+		{"签发时间在未来", sign(future), ErrInvalidToken},
+		{"签发时间在时钟误差内", sign(skewedIssue), nil},
+		{"sub 不是用户ID", sign(noSubject), ErrInvalidToken},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.token, testSecret, now)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+			continue
+		}
+		if err == nil && (got.UserID() != 7 || got.Type != "access" || got.ID != "abc") {
+			t.Errorf("%s: claims = %+v", tc.name, got)
+		}
+	}
+}
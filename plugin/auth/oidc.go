@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"go-film-demo/config"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/oidc"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrOIDCNotProvisioned 未开启自动创建用户且外部账号未绑定本地用户
+var ErrOIDCNotProvisioned = errors.New("该账号尚未开通, 请联系管理员")
+
+// usernameIllegal 用户名中不允许的字符
+var usernameIllegal = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+var (
+	oidcOnce     sync.Once
+	oidcProvider *oidc.Provider
+)
+
+// OIDC 返回配置的 OpenID Connect 提供方, 未配置 OIDC_ISSUER 时返回 nil
+func OIDC() *oidc.Provider {
+	oidcOnce.Do(func() {
+		if config.OIDCIssuer == "" || config.OIDCClientID == "" {
+			return
+		}
+		oidcProvider = oidc.New(config.OIDCIssuer, config.OIDCClientID, config.OIDCClientSecret,
+			config.OIDCRedirectURL, strings.Fields(config.OIDCScopes))
+	})
+	return oidcProvider
+}
+
+// LoginOIDC 根据校验通过的 ID Token 声明登录: 已绑定时登录对应的本地用户,
+// 未绑定时按配置自动创建本地用户(不设置密码, 只能通过单点登录)
+func LoginOIDC(claims *oidc.Claims) (*user.TokenResp, error) {
+	issuer := strings.TrimSuffix(claims.Issuer, "/")
+	email := ""
+	if claims.EmailVerified {
+		email = claims.Email
+	}
+
+	identity, err := UserDao.GetIdentity(issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	var u *user.User
+	if identity != nil {
+		if u, err = UserDao.GetUserByID(identity.UserID); err != nil {
+			return nil, err
+		}
+		if u == nil {
+			return nil, ErrOIDCNotProvisioned
+		}
+		if email != "" && email != identity.Email {
+			UserDao.TouchIdentity(identity.ID, email)
+		}
+	} else {
+		if !config.OIDCAutoProvision {
+			return nil, ErrOIDCNotProvisioned
+		}
+		if u, err = provisionOIDCUser(issuer, claims, email); err != nil {
+			return nil, err
+		}
+	}
+
+	return beginSession(u)
+}
+
+// provisionOIDCUser 首次单点登录时创建本地用户, 用户名冲突时追加随机后缀
+func provisionOIDCUser(issuer string, claims *oidc.Claims, email string) (*user.User, error) {
+	base := oidcUsername(claims)
+	nickname := claims.Name
+	if utf8.RuneCountInString(nickname) > 50 {
+		nickname = string([]rune(nickname)[:50])
+	}
+
+	for i := 0; i < 5; i++ {
+		username := base
+		if i > 0 {
+			username = base + "-" + randomHex(3)
+		}
+		existing, err := UserDao.GetUserByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			continue
+		}
+		u := &user.User{Username: username, Email: email, Nickname: nickname, Role: user.RoleMember}
+		identity := &user.Identity{Issuer: issuer, Subject: claims.Subject, Email: email, CreateAt: time.Now()}
+		if err = UserDao.CreateUserWithIdentity(u, identity); err != nil {
+			return nil, err
+		}
+		log.Printf("单点登录自动创建用户 %s (%s)", u.Username, claims.Subject)
+		return u, nil
+	}
+	return nil, errors.New("无法生成可用的用户名, 请联系管理员")
+}
+
+// oidcUsername 按配置的声明生成合法的本地用户名, 依次回退到 preferred_username、邮箱前缀
+func oidcUsername(claims *oidc.Claims) string {
+	candidates := []string{claims.String(config.OIDCUsernameClaim), claims.PreferredUsername}
+	if at := strings.Index(claims.Email, "@"); at > 0 {
+		candidates = append(candidates, claims.Email[:at])
+	}
+	for _, c := range candidates {
+		name := strings.Trim(usernameIllegal.ReplaceAllString(c, "_"), "_.-")
+		if len(name) > user.UsernameMaxLen-7 {
+			name = name[:user.UsernameMaxLen-7]
+		}
+		if len(name) >= user.UsernameMinLen {
+			return name
+		}
+	}
+	return "user-" + randomHex(3)
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"go-film-demo/dao"
+	"go-film-demo/model/team"
+	"go-film-demo/model/user"
+)
+
+/*
+	基于角色的访问控制: 用户角色(admin/member)决定全局权限, 团队角色(lead/member)决定团队内的权限;
+	团队负责人可以查看团队成员的日程, 日程的修改始终只允许日程所属用户
+*/
+
+var TeamDao = dao.NewTeamDao()
+
+// Can 判断用户是否拥有全局权限
+func Can(userID int64, perm string) (bool, error) {
+	u, err := UserDao.GetUserByID(userID)
+	if err != nil || u == nil {
+		return false, err
+	}
+	return u.HasPermission(perm), nil
+}
+
+// TeamRole 返回用户在团队中的角色, 不是团队成员时为空
+func TeamRole(teamID, userID int64) (string, error) {
+	m, err := TeamDao.GetMember(teamID, userID)
+	if err != nil || m == nil {
+		return "", err
+	}
+	return m.Role, nil
+}
+
+// CanManageTeam 管理员或团队负责人可以管理团队成员与查看团队日程
+func CanManageTeam(userID, teamID int64) (bool, error) {
+	if ok, err := Can(userID, user.PermTeamManage); err != nil || ok {
+		return ok, err
+	}
+	role, err := TeamRole(teamID, userID)
+	return role == team.RoleLead, err
+}
+
+// CanViewSchedulesOf 判断 viewerID 是否可以查看 ownerID 的日程:
+// 本人、拥有 schedule:view_all 权限的管理员, 以及 ownerID 所在团队的负责人
+func CanViewSchedulesOf(viewerID, ownerID int64) (bool, error) {
+	if viewerID == ownerID {
+		return true, nil
+	}
+	if ok, err := Can(viewerID, user.PermScheduleViewAll); err != nil || ok {
+		return ok, err
+	}
+	return TeamDao.IsLeadOf(viewerID, ownerID)
+}
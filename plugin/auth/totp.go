@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"go-film-demo/config"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/totp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+/*
+	两步验证: 启用 TOTP 的用户在密码或单点登录通过后只拿到 5 分钟有效的 mfa_token,
+	再提交验证码或恢复码换取令牌; 验证码记录已使用的时间步防止重放, 恢复码只保存 SHA-256 哈希且只能使用一次.
+	TOTP_REQUIRED_ROLES 中的角色未启用两步验证时, 签发的访问令牌只能用于启用两步验证
+*/
+
+const (
+	// mfaTTL 两步验证挑战的有效期
+	mfaTTL = 5 * time.Minute
+	// mfaMaxAttempts 同一个 mfa_token 允许的验证失败次数
+	mfaMaxAttempts = 5
+	// totpSkew 允许前后各一个时间步的时钟误差
+	totpSkew = 1
+)
+
+var (
+	ErrTOTPInvalid     = errors.New("验证码错误")
+	ErrTOTPNotEnabled  = errors.New("未启用两步验证")
+	ErrTOTPEnabled     = errors.New("已启用两步验证, 请先关闭后重新绑定")
+	ErrTOTPRequired    = errors.New("当前角色必须启用两步验证, 不能关闭")
+	ErrMFATooManyTries = errors.New("验证失败次数过多, 请重新登录")
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// mfaAttempts 记录每个 mfa_token(jti) 的验证失败次数, 验证通过后标记为用尽, 防止同一挑战被重复使用
+var mfaAttempts = struct {
+	sync.Mutex
+	m map[string]*mfaAttempt
+}{m: make(map[string]*mfaAttempt)}
+
+type mfaAttempt struct {
+	count    int
+	expireAt time.Time
+}
+
+// TOTPRequired 角色是否必须启用两步验证
+func TOTPRequired(role string) bool {
+	for _, r := range strings.Split(config.TOTPRequiredRoles, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupTOTP 生成新的 TOTP 密钥, 用户使用验证器扫码后调用 EnableTOTP 确认
+func SetupTOTP(u *user.User) (*user.TOTPSetupResp, error) {
+	if u.TOTPEnabled {
+		return nil, ErrTOTPEnabled
+	}
+	secret := totp.GenerateSecret()
+	if err := UserDao.SaveTOTPSecret(u.ID, secret); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTOTPEnabled
+		}
+		return nil, err
+	}
+	return &user.TOTPSetupResp{Secret: secret, URI: totp.ProvisioningURI(config.TOTPIssuer, u.Username, secret)}, nil
+}
+
+// EnableTOTP 校验验证码后启用两步验证, 生成恢复码并撤销其他会话, 返回恢复码与新的令牌
+func EnableTOTP(u *user.User, code string) (*user.TOTPEnableResp, error) {
+	if u.TOTPEnabled {
+		return nil, ErrTOTPEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, errors.New("请先获取两步验证密钥")
+	}
+	step, ok := totp.Validate(u.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrTOTPInvalid
+	}
+	codes, hashes := newRecoveryCodes()
+	if err := UserDao.EnableTOTP(u.ID, step, hashes); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTOTPEnabled
+		}
+		return nil, err
+	}
+	if err := UserDao.RevokeUserRefreshTokens(u.ID); err != nil {
+		return nil, err
+	}
+	u.TOTPEnabled, u.TOTPLastStep = true, step
+	tokens, err := IssueTokens(u)
+	if err != nil {
+		return nil, err
+	}
+	return &user.TOTPEnableResp{RecoveryCodes: codes, Tokens: tokens}, nil
+}
+
+// DisableTOTP 校验验证码或恢复码后关闭两步验证, 角色要求两步验证时不允许关闭
+func DisableTOTP(u *user.User, req user.TOTPCodeReq) error {
+	if !u.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+	if TOTPRequired(u.Role) {
+		return ErrTOTPRequired
+	}
+	if err := verifySecondFactor(u, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	return UserDao.DisableTOTP(u.ID)
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码, 旧的恢复码全部失效
+func RegenerateRecoveryCodes(u *user.User, code string) ([]string, error) {
+	if !u.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err := verifySecondFactor(u, code, ""); err != nil {
+		return nil, err
+	}
+	codes, hashes := newRecoveryCodes()
+	if err := UserDao.ReplaceRecoveryCodes(u.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTOTP 管理员为丢失验证器与恢复码的用户关闭两步验证, 并撤销其全部刷新令牌
+func ResetTOTP(userID int64) error {
+	if err := UserDao.DisableTOTP(userID); err != nil {
+		return err
+	}
+	return UserDao.RevokeUserRefreshTokens(userID)
+}
+
+// VerifyMFA 校验 mfa_token 与验证码或恢复码, 通过后签发令牌
+func VerifyMFA(req user.MFAVerifyReq) (*user.TokenResp, error) {
+	claims, err := Parse(req.MFAToken, signingKey(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != user.TokenMFA || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	if !mfaAttemptAllowed(claims.ID, time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrMFATooManyTries
+	}
+	u, err := UserDao.GetUserByID(claims.UserID())
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || !u.TOTPEnabled {
+		return nil, ErrInvalidToken
+	}
+	if err = verifySecondFactor(u, req.Code, req.RecoveryCode); err != nil {
+		if errors.Is(err, ErrTOTPInvalid) {
+			mfaAttemptFailed(claims.ID)
+		}
+		return nil, err
+	}
+	mfaAttemptConsumed(claims.ID)
+	return completeLogin(u)
+}
+
+// mfaChallenge 签发两步验证挑战令牌
+func mfaChallenge(u *user.User) (*user.TokenResp, error) {
+	now := time.Now()
+	token, err := Sign(&Claims{
+		Issuer: config.JWTIssuer, Subject: strconv.FormatInt(u.ID, 10), Type: user.TokenMFA, ID: randomHex(16),
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(mfaTTL).Unix(),
+	}, signingKey())
+	if err != nil {
+		return nil, err
+	}
+	return &user.TokenResp{MFARequired: true, MFAToken: token, ExpiresIn: int64(mfaTTL.Seconds())}, nil
+}
+
+// verifySecondFactor 校验验证码或恢复码, 两者都会被标记为已使用
+func verifySecondFactor(u *user.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		ok, err := UserDao.UseRecoveryCode(u.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrTOTPInvalid
+		}
+		return nil
+	}
+	step, ok := totp.Validate(u.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrTOTPInvalid
+	}
+	used, err := UserDao.UseTOTPStep(u.ID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		// 验证码已被使用过, 需要等待下一个验证码
+		return ErrTOTPInvalid
+	}
+	return nil
+}
+
+// newRecoveryCodes 生成恢复码明文(xxxxx-xxxxx)与对应的哈希
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, user.RecoveryCodeCount)
+	hashes := make([]string, user.RecoveryCodeCount)
+	for i := range codes {
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(randomBytes(7)))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes
+}
+
+// hashRecoveryCode 忽略大小写、空白与连字符后计算哈希, 方便用户输入
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func mfaAttemptAllowed(id string, expireAt time.Time) bool {
+	mfaAttempts.Lock()
+	defer mfaAttempts.Unlock()
+	now := time.Now()
+	for k, v := range mfaAttempts.m {
+		if now.After(v.expireAt) {
+			delete(mfaAttempts.m, k)
+		}
+	}
+	a, ok := mfaAttempts.m[id]
+	if !ok {
+		mfaAttempts.m[id] = &mfaAttempt{expireAt: expireAt.Add(clockSkew)}
+		return true
+	}
+	return a.count < mfaMaxAttempts
+}
+
+func mfaAttemptFailed(id string) {
+	mfaAttempts.Lock()
+	defer mfaAttempts.Unlock()
+	if a, ok := mfaAttempts.m[id]; ok {
+		a.count++
+	}
+}
+
+func mfaAttemptConsumed(id string) {
+	mfaAttempts.Lock()
+	defer mfaAttempts.Unlock()
+	if a, ok := mfaAttempts.m[id]; ok {
+		a.count = mfaMaxAttempts
+	}
+}
@@ -3,7 +3,10 @@ package cron
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
@@ -12,17 +15,30 @@ import (
 type CronManager struct {
 	cron   *cron.Cron
 	tasks  map[string]cron.EntryID
+	specs  map[string]string
 	mutex  sync.RWMutex
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
+// TaskInfo 定时任务信息
+type TaskInfo struct {
+	Name string     `json:"name"`
+	Spec string     `json:"spec"`
+	Prev *time.Time `json:"prev"` // 上次执行时间, 未执行过时为空
+	Next *time.Time `json:"next"` // 下次执行时间
+}
+
+// Default 全局定时任务管理器
+var Default = NewCronManager()
+
 // NewCronManager 创建定时任务管理器
 func NewCronManager() *CronManager {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &CronManager{
 		cron:   cron.New(cron.WithSeconds()),
 		tasks:  make(map[string]cron.EntryID),
+		specs:  make(map[string]string),
 		ctx:    ctx,
 		cancel: cancel,
 	}
@@ -43,6 +59,7 @@ func (cm *CronManager) AddTask(name, spec string, task func()) error {
 	}
 
 	cm.tasks[name] = entryID
+	cm.specs[name] = spec
 	return nil
 }
 
@@ -51,6 +68,11 @@ func (cm *CronManager) AddEvery10SecondsTask(name string, task func()) error {
 	return cm.AddTask(name, "*/50 * * * * *", task)
 }
 
+// AddDailyTask 添加每天在指定时刻执行的任务
+func (cm *CronManager) AddDailyTask(name string, hour, minute int, task func()) error {
+	return cm.AddTask(name, fmt.Sprintf("0 %d %d * * *", minute, hour), task)
+}
+
 // Start 启动定时任务
 func (cm *CronManager) Start() {
 	cm.cron.Start()
@@ -70,5 +92,45 @@ func (cm *CronManager) RemoveTask(name string) {
 	if entryID, exists := cm.tasks[name]; exists {
 		cm.cron.Remove(entryID)
 		delete(cm.tasks, name)
+		delete(cm.specs, name)
+	}
+}
+
+// Tasks 返回全部定时任务, 按名称排序
+func (cm *CronManager) Tasks() []TaskInfo {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	list := make([]TaskInfo, 0, len(cm.tasks))
+	for name, entryID := range cm.tasks {
+		entry := cm.cron.Entry(entryID)
+		info := TaskInfo{Name: name, Spec: cm.specs[name]}
+		if !entry.Prev.IsZero() {
+			prev := entry.Prev
+			info.Prev = &prev
+		}
+		if !entry.Next.IsZero() {
+			next := entry.Next
+			info.Next = &next
+		}
+		list = append(list, info)
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// RunTask 立即在后台执行一次任务, 不影响原有的执行计划
+func (cm *CronManager) RunTask(name string) error {
+	cm.mutex.RLock()
+	entryID, exists := cm.tasks[name]
+	cm.mutex.RUnlock()
+	if !exists {
+		return errors.New("任务不存在")
+	}
+	entry := cm.cron.Entry(entryID)
+	if entry.Job == nil {
+		return errors.New("任务不存在")
+	}
+	go entry.Job.Run()
+	return nil
 }
@@ -0,0 +1,148 @@
+package digest
+
+import (
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/dao"
+	"go-film-demo/model/briefing"
+	"go-film-demo/plugin/cron"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+	每日简报生成器: 汇总用户当天的日程与当天阅读量最高的新闻,
+	并按用户设置的时间通过定时任务预生成
+*/
+
+// cacheTTL 预生成简报的有效期, 过期后重新生成以反映日程变化
+const cacheTTL = 10 * time.Minute
+
+var (
+	ScheduleDao = dao.NewScheduleDao()
+	NewsDao     = dao.NewNewsRepository()
+	BriefingDao = dao.NewBriefingDao()
+
+	cronManager *cron.CronManager
+	cache       sync.Map // key: userID, value: *briefing.Briefing, 每个用户只缓存当天的简报
+	listeners   []func(b *briefing.Briefing)
+)
+
+// Build 生成用户指定日期的简报
+func Build(userID int64, date time.Time) (*briefing.Briefing, error) {
+	schedules, err := ScheduleDao.GetSchedulesByUserAndDate(userID, int16(date.Year()), int8(date.Month()), int8(date.Day()))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(schedules, func(i, j int) bool {
+		if schedules[i].Priority != schedules[j].Priority {
+			return schedules[i].Priority > schedules[j].Priority
+		}
+		return schedules[i].StartTime.Before(schedules[j].StartTime)
+	})
+
+	newsList, err := NewsDao.GetNewsByDateRange(date)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].ReadNum > newsList[j].ReadNum
+	})
+	if len(newsList) > config.BriefingNewsLimit {
+		newsList = newsList[:config.BriefingNewsLimit]
+	}
+
+	return &briefing.Briefing{
+		UserID:      userID,
+		Date:        date.Format("2006-01-02"),
+		GeneratedAt: time.Now(),
+		Schedules:   schedules,
+		News:        newsList,
+	}, nil
+}
+
+// Get 获取简报, 当天的简报优先使用未过期的预生成结果; 其他日期每次重新生成且不缓存, 避免缓存随查询日期无限增长
+func Get(userID int64, date time.Time, refresh bool) (*briefing.Briefing, error) {
+	today := date.Format("2006-01-02") == time.Now().Format("2006-01-02")
+	if today && !refresh {
+		if v, ok := cache.Load(userID); ok {
+			if b := v.(*briefing.Briefing); b.Date == date.Format("2006-01-02") && time.Since(b.GeneratedAt) < cacheTTL {
+				return b, nil
+			}
+		}
+	}
+	b, err := Build(userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if today {
+		cache.Store(userID, b)
+	}
+	return b, nil
+}
+
+// Setup 为所有启用简报的用户注册定时任务, 需在数据库初始化之后调用
+func Setup(cm *cron.CronManager) error {
+	cronManager = cm
+	settings, err := BriefingDao.ListEnabledSettings()
+	if err != nil {
+		return err
+	}
+	for i := range settings {
+		if err = ScheduleUser(&settings[i]); err != nil {
+			log.Printf("注册用户 %d 的每日简报任务失败: %v", settings[i].UserID, err)
+		}
+	}
+	return nil
+}
+
+// ScheduleUser 按用户设置注册(或移除)每日预生成任务
+func ScheduleUser(setting *briefing.BriefingSetting) error {
+	if cronManager == nil {
+		return fmt.Errorf("定时任务管理器未初始化")
+	}
+	name := fmt.Sprintf("briefing-user-%d", setting.UserID)
+	cronManager.RemoveTask(name)
+	if !setting.Enabled {
+		return nil
+	}
+	hour, minute, err := ParseClock(setting.SendTime)
+	if err != nil {
+		return err
+	}
+	userID := setting.UserID
+	return cronManager.AddDailyTask(name, hour, minute, func() {
+		prebuild(userID)
+	})
+}
+
+// OnPrebuilt 注册简报预生成后的回调(如推送到通知渠道), 需在启动阶段调用
+func OnPrebuilt(fn func(b *briefing.Briefing)) {
+	listeners = append(listeners, fn)
+}
+
+// ParseClock 解析 HH:MM 格式的时刻
+func ParseClock(clock string) (int, int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, fmt.Errorf("时间格式错误, 期望格式: HH:MM, 实际输入: '%s'", clock)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// prebuild 预生成用户当天的简报
+func prebuild(userID int64) {
+	now := time.Now()
+	b, err := Build(userID, now)
+	if err != nil {
+		log.Printf("预生成用户 %d 的每日简报失败: %v", userID, err)
+		return
+	}
+	cache.Store(userID, b)
+	log.Printf("预生成用户 %d 的每日简报成功, 日程 %d 条, 新闻 %d 条", userID, len(b.Schedules), len(b.News))
+	for _, fn := range listeners {
+		fn(b)
+	}
+}
@@ -0,0 +1,193 @@
+package digest
+
+import (
+	"go-film-demo/config"
+	"go-film-demo/model/briefing"
+	"go-film-demo/model/news"
+	"go-film-demo/model/schedule"
+	"go-film-demo/plugin/db"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// useFakeDB 将全局连接替换为不执行 SQL 的连接, 查询结果由 schedules/newsList 提供, 返回执行过的查询语句
+func useFakeDB(t *testing.T, schedules []schedule.Schedule, newsList []news.News) *[]string {
+	t.Helper()
+	conn, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=True",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		NamingStrategy:       schema.NamingStrategy{SingularTable: true},
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	_ = conn.Callback().Query().After("gorm:query").Register("test:fake_rows", func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+		switch dest := tx.Statement.Dest.(type) {
+		case *[]schedule.Schedule:
+			*dest = append([]schedule.Schedule(nil), schedules...)
+		case *[]news.News:
+			*dest = append([]news.News(nil), newsList...)
+		}
+	})
+
+	previous := db.Mdb
+	db.Mdb = conn
+	t.Cleanup(func() { db.Mdb = previous })
+	return &statements
+}
+
+func fixtures(date time.Time) ([]schedule.Schedule, []news.News) {
+	at := func(hour int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, time.Local)
+	}
+	schedules := []schedule.Schedule{
+		{ID: 1, Content: "低优先级早会", Priority: schedule.PriorityLow, StartTime: at(8), EndTime: at(9)},
+		{ID: 2, Content: "高优先级下午", Priority: schedule.PriorityHigh, StartTime: at(15), EndTime: at(16)},
+		{ID: 3, Content: "高优先级上午", Priority: schedule.PriorityHigh, StartTime: at(10), EndTime: at(11)},
+	}
+	var newsList []news.News
+	for i := 1; i <= config.BriefingNewsLimit+2; i++ {
+		newsList = append(newsList, news.News{ID: int64(i), Title: "新闻", ReadNum: i * 10, Link: "https://example.com/" + string(rune('a'+i))})
+	}
+	return schedules, newsList
+}
+
+func TestBuild(t *testing.T) {
+	date := time.Date(2026, 5, 1, 12, 0, 0, 0, time.Local)
+	schedules, newsList := fixtures(date)
+	statements := useFakeDB(t, schedules, newsList)
+
+	b, err := Build(42, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.UserID != 42 || b.Date != "2026-05-01" {
+		t.Errorf("briefing = %+v", b)
+	}
+	var order []int64
+	for _, s := range b.Schedules {
+		order = append(order, s.ID)
+	}
+	if len(order) != 3 || order[0] != 3 || order[1] != 2 || order[2] != 1 {
+		t.Errorf("日程应按优先级降序、开始时间升序排列: %v", order)
+	}
+	if len(b.News) != config.BriefingNewsLimit || b.News[0].ReadNum != (config.BriefingNewsLimit+2)*10 {
+		t.Errorf("新闻应按阅读量取前 %d 条: %+v", config.BriefingNewsLimit, b.News)
+	}
+	if len(*statements) != 2 || !strings.Contains((*statements)[0], "user_id = 42") ||
+		!strings.Contains((*statements)[0], "day = 1") {
+		t.Errorf("查询语句 = %v", *statements)
+	}
+}
+
+func TestGetCachesOnlyToday(t *testing.T) {
+	now := time.Now()
+	schedules, newsList := fixtures(now)
+	statements := useFakeDB(t, schedules, newsList)
+	t.Cleanup(func() { cache.Delete(int64(7)) })
+
+	for i := 0; i < 3; i++ {
+		if _, err := Get(7, now, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*statements) != 2 {
+		t.Errorf("当天简报应命中缓存, 实际查询 %d 次", len(*statements))
+	}
+	if _, err := Get(7, now, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 4 {
+		t.Errorf("refresh 应重新生成, 实际查询 %d 次", len(*statements))
+	}
+
+	*statements = nil
+	for i := 1; i <= 20; i++ {
+		if _, err := Get(7, now.AddDate(0, 0, -i), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*statements) != 40 {
+		t.Errorf("其他日期每次都应重新生成, 实际查询 %d 次", len(*statements))
+	}
+	entries := 0
+	cache.Range(func(_, _ any) bool { entries++; return true })
+	if entries != 1 {
+		t.Errorf("缓存条目数 = %d, 只应缓存当天的简报", entries)
+	}
+	if v, _ := cache.Load(int64(7)); v.(*briefing.Briefing).Date != now.Format("2006-01-02") {
+		t.Errorf("缓存的简报日期 = %s", v.(*briefing.Briefing).Date)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	b := &briefing.Briefing{
+		Date: "2026-05-01",
+		Schedules: []schedule.Schedule{
+			{Content: "评审 *重要* [链接](x)\n# 标题", Priority: schedule.PriorityHigh, StartTime: start, EndTime: start.Add(time.Hour)},
+		},
+		News: []news.News{{Title: "标题_1", Creator: "作者`x`", ReadNum: 99, Link: "https://example.com/1"}},
+	}
+	got := RenderMarkdown(b)
+	for _, want := range []string{
+		"# 每日简报 2026-05-01\n",
+		"## 今日日程 (1)\n",
+		"- **09:00-10:00** [高] 评审 \\*重要\\* \\[链接\\](x) \\# 标题\n",
+		"1. [标题\\_1](https://example.com/1) · 作者\\`x\\` · 阅读 99\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown 缺少 %q:\n%s", want, got)
+		}
+	}
+
+	empty := RenderMarkdown(&briefing.Briefing{Date: "2026-05-02"})
+	if !strings.Contains(empty, "今天没有日程安排。") || !strings.Contains(empty, "暂无新闻。") {
+		t.Errorf("空简报:\n%s", empty)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	b := &briefing.Briefing{
+		Date: "2026-05-01",
+		Schedules: []schedule.Schedule{
+			{Content: `<script>alert(1)</script>`, Priority: schedule.PriorityLow, StartTime: start, EndTime: start.Add(time.Hour)},
+		},
+		News: []news.News{
+			{Title: `<img src=x onerror=alert(1)>`, Creator: "作者", ReadNum: 5, Link: "javascript:alert(1)"},
+			{Title: "正常", ReadNum: 3, Link: "https://example.com/a?b=1&c=2"},
+		},
+	}
+	got, err := RenderHTML(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"<script>", "<img", `href="javascript:`} {
+		if strings.Contains(got, bad) {
+			t.Errorf("HTML 未转义 %q:\n%s", bad, got)
+		}
+	}
+	for _, want := range []string{
+		"<title>每日简报 2026-05-01</title>",
+		"<strong>09:00-10:00</strong> [低] &lt;script&gt;alert(1)&lt;/script&gt;",
+		`<a href="#ZgotmplZ">&lt;img src=x onerror=alert(1)&gt;</a>`,
+		`<a href="https://example.com/a?b=1&amp;c=2">正常</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML 缺少 %q:\n%s", want, got)
+		}
+	}
+}
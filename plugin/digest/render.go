@@ -0,0 +1,87 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"go-film-demo/model/briefing"
+	"go-film-demo/model/schedule"
+	"html/template"
+	"strings"
+)
+
+// priorityLabels 优先级展示名称
+var priorityLabels = map[int8]string{
+	schedule.PriorityLow:    "低",
+	schedule.PriorityMedium: "中",
+	schedule.PriorityHigh:   "高",
+}
+
+// PriorityLabel 优先级展示名称
+func PriorityLabel(p int8) string {
+	return priorityLabels[p]
+}
+
+// RenderMarkdown 将简报渲染为 Markdown
+func RenderMarkdown(b *briefing.Briefing) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# 每日简报 %s\n\n", b.Date)
+
+	fmt.Fprintf(&sb, "## 今日日程 (%d)\n\n", len(b.Schedules))
+	if len(b.Schedules) == 0 {
+		sb.WriteString("今天没有日程安排。\n")
+	}
+	for _, s := range b.Schedules {
+		fmt.Fprintf(&sb, "- **%s-%s** [%s] %s\n", s.StartTime.Format("15:04"), s.EndTime.Format("15:04"),
+			priorityLabels[s.Priority], MarkdownEscape(s.Content))
+	}
+
+	sb.WriteString("\n## 热门新闻\n\n")
+	if len(b.News) == 0 {
+		sb.WriteString("暂无新闻。\n")
+	}
+	for i, n := range b.News {
+		fmt.Fprintf(&sb, "%d. [%s](%s) · %s · 阅读 %d\n", i+1, MarkdownEscape(n.Title), n.Link,
+			MarkdownEscape(n.Creator), n.ReadNum)
+	}
+	return sb.String()
+}
+
+// markdownReplacer Markdown 特殊字符转义表
+var markdownReplacer = strings.NewReplacer(
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]", "`", "\\`", "#", "\\#",
+	"\r", "", "\n", " ",
+)
+
+// MarkdownEscape 转义 Markdown 特殊字符并去除换行
+func MarkdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+var htmlTemplate = template.Must(template.New("briefing").Funcs(template.FuncMap{
+	"clock":    func(s schedule.Schedule) string { return s.StartTime.Format("15:04") + "-" + s.EndTime.Format("15:04") },
+	"priority": func(p int8) string { return priorityLabels[p] },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><title>每日简报 {{.Date}}</title></head>
+<body style="font-family: -apple-system, 'PingFang SC', 'Microsoft YaHei', sans-serif; max-width: 720px; margin: 0 auto; padding: 16px;">
+<h1>每日简报 {{.Date}}</h1>
+<h2>今日日程 ({{len .Schedules}})</h2>
+{{if .Schedules}}<ul>{{range .Schedules}}
+<li><strong>{{clock .}}</strong> [{{priority .Priority}}] {{.Content}}</li>{{end}}
+</ul>{{else}}<p>今天没有日程安排。</p>{{end}}
+<h2>热门新闻</h2>
+{{if .News}}<ol>{{range .News}}
+<li><a href="{{.Link}}">{{.Title}}</a> · {{.Creator}} · 阅读 {{.ReadNum}}</li>{{end}}
+</ol>{{else}}<p>暂无新闻。</p>{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML 将简报渲染为 HTML, 内容均经过转义
+func RenderHTML(b *briefing.Briefing) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, b); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
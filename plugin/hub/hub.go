@@ -0,0 +1,143 @@
+package hub
+
+import (
+	"go-film-demo/dao"
+	"go-film-demo/model/news"
+	"go-film-demo/model/schedule"
+	"sync"
+	"time"
+)
+
+/*
+	进程内发布/订阅中心: 日程变更与新闻入库后发布事件, 实时推送接口订阅后转发给浏览器;
+	保留最近的事件用于断线重连时按 Last-Event-ID 补发
+*/
+
+// 事件主题
+const (
+	TopicSchedule = "schedule"
+	TopicNews     = "news"
+)
+
+const (
+	subscriberBuffer = 64  // 每个订阅者的缓冲事件数, 消费过慢时丢弃新事件
+	historySize      = 256 // 保留的最近事件数
+)
+
+// Event 推送事件, UserID 为 0 表示广播给全部订阅者
+type Event struct {
+	ID        uint64    `json:"id"`
+	Topic     string    `json:"topic"`
+	Type      string    `json:"type"`
+	UserID    int64     `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Subscriber 订阅者
+type Subscriber struct {
+	C      chan Event
+	userID int64
+	topics map[string]bool
+}
+
+// accepts 判断订阅者是否接收该事件
+func (s *Subscriber) accepts(e *Event) bool {
+	if len(s.topics) > 0 && !s.topics[e.Topic] {
+		return false
+	}
+	return e.UserID == 0 || e.UserID == s.userID
+}
+
+// Hub 发布/订阅中心
+type Hub struct {
+	mutex       sync.RWMutex
+	nextID      uint64
+	subscribers map[*Subscriber]struct{}
+	history     []Event
+}
+
+// Default 全局发布/订阅中心
+var Default = New()
+
+// New 创建发布/订阅中心
+func New() *Hub {
+	return &Hub{subscribers: make(map[*Subscriber]struct{})}
+}
+
+// Subscribe 订阅用户的事件, topics 为空时订阅全部主题; lastID 大于 0 时先补发之后的历史事件
+func (h *Hub) Subscribe(userID int64, topics []string, lastID uint64) *Subscriber {
+	sub := &Subscriber{C: make(chan Event, subscriberBuffer), userID: userID, topics: make(map[string]bool)}
+	for _, t := range topics {
+		sub.topics[t] = true
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if lastID > 0 {
+		for i := range h.history {
+			if e := &h.history[i]; e.ID > lastID && sub.accepts(e) {
+				select {
+				case sub.C <- *e:
+				default:
+				}
+			}
+		}
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe 取消订阅
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.subscribers, sub)
+}
+
+// Publish 发布事件, 不会因订阅者消费过慢而阻塞
+func (h *Hub) Publish(topic, eventType string, userID int64, data any) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.nextID++
+	e := Event{ID: h.nextID, Topic: topic, Type: eventType, UserID: userID, CreatedAt: time.Now(), Data: data}
+
+	h.history = append(h.history, e)
+	if len(h.history) > historySize {
+		h.history = h.history[len(h.history)-historySize:]
+	}
+	for sub := range h.subscribers {
+		if !sub.accepts(&e) {
+			continue
+		}
+		select {
+		case sub.C <- e:
+		default:
+		}
+	}
+}
+
+// scheduleEventData 日程变更事件数据
+type scheduleEventData struct {
+	Schedule *schedule.Schedule `json:"schedule"`
+	Previous *schedule.Schedule `json:"previous,omitempty"`
+}
+
+// Setup 监听日程变更与新闻入库
+func Setup() {
+	dao.OnScheduleChange(func(changes []schedule.OperationChange) {
+		for _, change := range changes {
+			switch {
+			case change.Before == nil:
+				Default.Publish(TopicSchedule, schedule.HistoryActionCreate, change.After.UserID, scheduleEventData{Schedule: change.After})
+			case change.After == nil:
+				Default.Publish(TopicSchedule, schedule.HistoryActionDelete, change.Before.UserID, scheduleEventData{Schedule: change.Before})
+			default:
+				Default.Publish(TopicSchedule, schedule.HistoryActionUpdate, change.After.UserID, scheduleEventData{Schedule: change.After, Previous: change.Before})
+			}
+		}
+	})
+	dao.OnNewsCreated(func(list []*news.News) {
+		Default.Publish(TopicNews, "create", 0, list)
+	})
+}
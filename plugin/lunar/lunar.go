@@ -0,0 +1,162 @@
+package lunar
+
+import (
+	"errors"
+	"time"
+)
+
+/*
+	农历(阴历)与公历互相转换, 支持 1900-2100 年
+	lunarInfo 每个元素描述一个农历年:
+	低4位为闰月月份(0表示无闰月), 第16位表示闰月是否为大月(30天),
+	第4-15位从高到低依次表示1-12月是否为大月
+*/
+
+var lunarInfo = [...]int{
+	0x04bd8, 0x04ae0, 0x0a570, 0x054d5, 0x0d260, 0x0d950, 0x16554, 0x056a0, 0x09ad0, 0x055d2, // 1900-1909
+	0x04ae0, 0x0a5b6, 0x0a4d0, 0x0d250, 0x1d255, 0x0b540, 0x0d6a0, 0x0ada2, 0x095b0, 0x14977, // 1910-1919
+	0x04970, 0x0a4b0, 0x0b4b5, 0x06a50, 0x06d40, 0x1ab54, 0x02b60, 0x09570, 0x052f2, 0x04970, // 1920-1929
+	0x06566, 0x0d4a0, 0x0ea50, 0x16a95, 0x05ad0, 0x02b60, 0x186e3, 0x092e0, 0x1c8d7, 0x0c950, // 1930-1939
+	0x0d4a0, 0x1d8a6, 0x0b550, 0x056a0, 0x1a5b4, 0x025d0, 0x092d0, 0x0d2b2, 0x0a950, 0x0b557, // 1940-1949
+	0x06ca0, 0x0b550, 0x15355, 0x04da0, 0x0a5b0, 0x14573, 0x052b0, 0x0a9a8, 0x0e950, 0x06aa0, // 1950-1959
+	0x0aea6, 0x0ab50, 0x04b60, 0x0aae4, 0x0a570, 0x05260, 0x0f263, 0x0d950, 0x05b57, 0x056a0, // 1960-1969
+	0x096d0, 0x04dd5, 0x04ad0, 0x0a4d0, 0x0d4d4, 0x0d250, 0x0d558, 0x0b540, 0x0b6a0, 0x195a6, // 1970-1979
+	0x095b0, 0x049b0, 0x0a974, 0x0a4b0, 0x0b27a, 0x06a50, 0x06d40, 0x0af46, 0x0ab60, 0x09570, // 1980-1989
+	0x04af5, 0x04970, 0x064b0, 0x074a3, 0x0ea50, 0x06b58, 0x05ac0, 0x0ab60, 0x096d5, 0x092e0, // 1990-1999
+	0x0c960, 0x0d954, 0x0d4a0, 0x0da50, 0x07552, 0x056a0, 0x0abb7, 0x025d0, 0x092d0, 0x0cab5, // 2000-2009
+	0x0a950, 0x0b4a0, 0x0baa4, 0x0ad50, 0x055d9, 0x04ba0, 0x0a5b0, 0x15176, 0x052b0, 0x0a930, // 2010-2019
+	0x07954, 0x06aa0, 0x0ad50, 0x05b52, 0x04b60, 0x0a6e6, 0x0a4e0, 0x0d260, 0x0ea65, 0x0d530, // 2020-2029
+	0x05aa0, 0x076a3, 0x096d0, 0x04afb, 0x04ad0, 0x0a4d0, 0x1d0b6, 0x0d250, 0x0d520, 0x0dd45, // 2030-2039
+	0x0b5a0, 0x056d0, 0x055b2, 0x049b0, 0x0a577, 0x0a4b0, 0x0aa50, 0x1b255, 0x06d20, 0x0ada0, // 2040-2049
+	0x14b63, 0x09370, 0x049f8, 0x04970, 0x064b0, 0x168a6, 0x0ea50, 0x06b20, 0x1a6c4, 0x0aae0, // 2050-2059
+	0x092e0, 0x0d2e3, 0x0c960, 0x0d557, 0x0d4a0, 0x0da50, 0x05d55, 0x056a0, 0x0a6d0, 0x055d4, // 2060-2069
+	0x052d0, 0x0a9b8, 0x0a950, 0x0b4a0, 0x0b6a6, 0x0ad50, 0x055a0, 0x0aba4, 0x0a5b0, 0x052b0, // 2070-2079
+	0x0b273, 0x06930, 0x07337, 0x06aa0, 0x0ad50, 0x14b55, 0x04b60, 0x0a570, 0x054e4, 0x0d160, // 2080-2089
+	0x0e968, 0x0d520, 0x0daa0, 0x16aa6, 0x056d0, 0x04ae0, 0x0a9d4, 0x0a2d0, 0x0d150, 0x0f252, // 2090-2099
+	0x0d520, // 2100
+}
+
+const (
+	MinYear = 1900
+	MaxYear = 2100
+)
+
+// ErrOutOfRange 日期超出支持范围
+var ErrOutOfRange = errors.New("农历日期超出支持范围(1900-2100)")
+
+// ErrInvalidDate 农历日期不存在
+var ErrInvalidDate = errors.New("农历日期不存在")
+
+// baseDate 农历1900年正月初一对应的公历日期
+var baseDate = time.Date(1900, 1, 31, 0, 0, 0, 0, time.UTC)
+
+// Date 农历日期
+type Date struct {
+	Year  int  `json:"year"`
+	Month int  `json:"month"`
+	Day   int  `json:"day"`
+	Leap  bool `json:"leap"` // 是否为闰月
+}
+
+// LeapMonth 返回农历年的闰月月份, 0 表示没有闰月
+func LeapMonth(year int) int {
+	return lunarInfo[year-MinYear] & 0xf
+}
+
+// leapDays 返回农历年闰月的天数
+func leapDays(year int) int {
+	if LeapMonth(year) == 0 {
+		return 0
+	}
+	if lunarInfo[year-MinYear]&0x10000 != 0 {
+		return 30
+	}
+	return 29
+}
+
+// MonthDays 返回农历年某个非闰月的天数
+func MonthDays(year, month int) int {
+	if lunarInfo[year-MinYear]&(0x10000>>month) != 0 {
+		return 30
+	}
+	return 29
+}
+
+// yearDays 返回农历年的总天数
+func yearDays(year int) int {
+	days := 0
+	for m := 1; m <= 12; m++ {
+		days += MonthDays(year, m)
+	}
+	return days + leapDays(year)
+}
+
+// ToSolar 农历转公历, 返回 loc 时区当天零点
+func ToSolar(d Date, loc *time.Location) (time.Time, error) {
+	if d.Year < MinYear || d.Year > MaxYear || d.Month < 1 || d.Month > 12 {
+		return time.Time{}, ErrOutOfRange
+	}
+	if d.Leap && LeapMonth(d.Year) != d.Month {
+		return time.Time{}, ErrInvalidDate
+	}
+	monthLen := MonthDays(d.Year, d.Month)
+	if d.Leap {
+		monthLen = leapDays(d.Year)
+	}
+	if d.Day < 1 || d.Day > monthLen {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	offset := 0
+	for y := MinYear; y < d.Year; y++ {
+		offset += yearDays(y)
+	}
+	leap := LeapMonth(d.Year)
+	for m := 1; m < d.Month; m++ {
+		offset += MonthDays(d.Year, m)
+		if m == leap {
+			offset += leapDays(d.Year)
+		}
+	}
+	if d.Leap {
+		offset += MonthDays(d.Year, d.Month)
+	}
+	offset += d.Day - 1
+
+	t := baseDate.AddDate(0, 0, offset)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// FromSolar 公历转农历
+func FromSolar(t time.Time) (Date, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := int(day.Sub(baseDate).Hours() / 24)
+	if offset < 0 {
+		return Date{}, ErrOutOfRange
+	}
+
+	year := MinYear
+	for ; year <= MaxYear && offset >= yearDays(year); year++ {
+		offset -= yearDays(year)
+	}
+	if year > MaxYear {
+		return Date{}, ErrOutOfRange
+	}
+
+	leap := LeapMonth(year)
+	for month := 1; month <= 12; month++ {
+		if days := MonthDays(year, month); offset < days {
+			return Date{Year: year, Month: month, Day: offset + 1}, nil
+		} else {
+			offset -= days
+		}
+		if month == leap {
+			if days := leapDays(year); offset < days {
+				return Date{Year: year, Month: month, Day: offset + 1, Leap: true}, nil
+			} else {
+				offset -= days
+			}
+		}
+	}
+	return Date{}, ErrOutOfRange
+}
@@ -0,0 +1,620 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	Markdown 渲染, 支持常用的 CommonMark/GFM 语法:
+	标题、段落、换行、强调/加粗/删除线、行内代码、围栏代码块、引用、
+	有序/无序列表(含任务列表)、表格、分隔线、链接、图片与自动链接
+	输出为净化后的 HTML: 原始 HTML 一律转义, 链接只允许 http/https/mailto 与相对地址,
+	因此渲染结果可以直接嵌入页面
+*/
+
+var (
+	headingRe    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	fenceRe      = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*)$")
+	hrRe         = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	listRe       = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])( +|$)(.*)$`)
+	quoteRe      = regexp.MustCompile(`^ {0,3}> ?`)
+	tableDelimRe = regexp.MustCompile(`^ *\|? *:?-+:? *(\| *:?-+:? *)*\|? *$`)
+	langRe       = regexp.MustCompile(`[^A-Za-z0-9_+#-]`)
+)
+
+// Render 将 Markdown 渲染为净化后的 HTML
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), false)
+	return b.String()
+}
+
+// renderBlocks 渲染块级元素, tight 为 true 时段落不包裹 <p> (紧凑列表项)
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case fenceRe.MatchString(line):
+			i = renderFence(b, lines, i)
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">")
+			renderInline(b, strings.TrimSpace(m[2]))
+			b.WriteString("</h" + level + ">\n")
+			i++
+		case hrRe.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case quoteRe.MatchString(line):
+			i = renderQuote(b, lines, i)
+		case listRe.MatchString(line):
+			i = renderList(b, lines, i)
+		case isTableStart(lines, i):
+			i = renderTable(b, lines, i)
+		default:
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+// isBlockStart 判断某行是否会打断段落
+func isBlockStart(lines []string, i int) bool {
+	line := lines[i]
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) || hrRe.MatchString(line) ||
+		quoteRe.MatchString(line) || listRe.MatchString(line) || isTableStart(lines, i)
+}
+
+func renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	text := []string{strings.TrimSpace(lines[i])}
+	for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !isBlockStart(lines, i); i++ {
+		text = append(text, strings.TrimSpace(lines[i]))
+	}
+	if !tight {
+		b.WriteString("<p>")
+	}
+	renderInline(b, strings.Join(text, "\n"))
+	if !tight {
+		b.WriteString("</p>")
+	}
+	b.WriteString("\n")
+	return i
+}
+
+func renderFence(b *strings.Builder, lines []string, i int) int {
+	m := fenceRe.FindStringSubmatch(lines[i])
+	indent, marker := len(m[1]), m[2]
+	lang := ""
+	if fields := strings.Fields(m[3]); len(fields) > 0 {
+		lang = langRe.ReplaceAllString(fields[0], "")
+	}
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, marker) && strings.Trim(trimmed, marker[:1]) == "" {
+			i++
+			break
+		}
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + lang + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func renderQuote(b *strings.Builder, lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if loc := quoteRe.FindStringIndex(line); loc != nil {
+			inner = append(inner, line[loc[1]:])
+			continue
+		}
+		// 懒惰续行: 引用中的段落可以省略后续行的 >
+		if strings.TrimSpace(line) == "" || isBlockStart(lines, i) || len(inner) == 0 ||
+			strings.TrimSpace(inner[len(inner)-1]) == "" {
+			break
+		}
+		inner = append(inner, line)
+	}
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+func renderList(b *strings.Builder, lines []string, i int) int {
+	m := listRe.FindStringSubmatch(lines[i])
+	indent := len(m[1])
+	ordered := !strings.ContainsAny(m[2], "-*+")
+	bullet := m[2][len(m[2])-1:]
+
+	var items [][]string
+	var contentIndent int
+	loose := false
+	for i < len(lines) {
+		line := lines[i]
+		if m := listRe.FindStringSubmatch(line); m != nil && len(m[1]) < indent+2 && (len(items) == 0 || len(m[1]) < contentIndent) {
+			if strings.ContainsAny(m[2], "-*+") == ordered || m[2][len(m[2])-1:] != bullet || hrRe.MatchString(line) {
+				break
+			}
+			width := len(m[3])
+			if width == 0 || width > 4 {
+				width = 1
+			}
+			contentIndent = len(m[1]) + len(m[2]) + width
+			items = append(items, []string{strings.TrimLeft(m[4], " ")})
+			i++
+			continue
+		}
+
+		current := items[len(items)-1]
+		if strings.TrimSpace(line) == "" {
+			// 空行之后只有缩进的内容或同级列表项才属于当前列表
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next == len(lines) {
+				break
+			}
+			nm := listRe.FindStringSubmatch(lines[next])
+			sameLevel := nm != nil && len(nm[1]) < contentIndent && nm[2][len(nm[2])-1:] == bullet
+			if leadingSpaces(lines[next]) < contentIndent && !sameLevel {
+				break
+			}
+			loose = true
+			items[len(items)-1] = append(current, "")
+			i++
+			continue
+		}
+		if leadingSpaces(line) >= contentIndent {
+			items[len(items)-1] = append(current, line[contentIndent:])
+			i++
+			continue
+		}
+		// 懒惰续行
+		if strings.TrimSpace(current[len(current)-1]) == "" || isBlockStart(lines, i) {
+			break
+		}
+		items[len(items)-1] = append(current, strings.TrimSpace(line))
+		i++
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if start, _ := strconv.Atoi(strings.TrimRight(m[2], ".)")); ordered && start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		b.WriteString("<li>")
+		if box, rest, ok := taskBox(item[0]); ok {
+			b.WriteString(box)
+			item[0] = rest
+		}
+		if loose {
+			b.WriteString("\n")
+		}
+		var inner strings.Builder
+		renderBlocks(&inner, item, !loose)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		if loose {
+			b.WriteString("\n")
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// taskBox 识别任务列表项的 [ ] / [x] 前缀
+func taskBox(text string) (string, string, bool) {
+	if len(text) < 3 || text[0] != '[' || text[2] != ']' || (len(text) > 3 && text[3] != ' ') {
+		return "", text, false
+	}
+	switch text[1] {
+	case ' ':
+		return `<input type="checkbox" disabled> `, strings.TrimLeft(text[3:], " "), true
+	case 'x', 'X':
+		return `<input type="checkbox" checked disabled> `, strings.TrimLeft(text[3:], " "), true
+	}
+	return "", text, false
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// isTableStart 表头行之后紧跟分隔行, 且两行列数一致时视为表格
+func isTableStart(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !tableDelimRe.MatchString(lines[i+1]) {
+		return false
+	}
+	if !strings.Contains(lines[i+1], "|") && len(splitRow(lines[i])) != 1 {
+		return false
+	}
+	return len(splitRow(lines[i])) == len(splitRow(lines[i+1]))
+}
+
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitRow(lines[i])
+	aligns := make([]string, len(header))
+	for n, cell := range splitRow(lines[i+1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			aligns[n] = "center"
+		case right:
+			aligns[n] = "right"
+		case left:
+			aligns[n] = "left"
+		}
+	}
+
+	writeRow := func(cells []string, tag string) {
+		b.WriteString("<tr>\n")
+		for n := range header {
+			b.WriteString("<" + tag)
+			if aligns[n] != "" {
+				b.WriteString(` style="text-align:` + aligns[n] + `"`)
+			}
+			b.WriteString(">")
+			if n < len(cells) {
+				renderInline(b, cells[n])
+			}
+			b.WriteString("</" + tag + ">\n")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	b.WriteString("</thead>\n")
+	i += 2
+	if i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|") {
+		b.WriteString("<tbody>\n")
+		for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+			writeRow(splitRow(lines[i]), "td")
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+	return i
+}
+
+// splitRow 拆分表格行, \| 表示单元格内的竖线
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			cell.WriteByte('|')
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderInline 渲染行内元素, 段落内的换行渲染为 <br>
+func renderInline(b *strings.Builder, s string) {
+	renderSpan(b, s, true)
+}
+
+// renderSpan 渲染行内元素, links 为 false 时不解析链接(链接文本中不能再嵌套链接)
+func renderSpan(b *strings.Builder, s string, links bool) {
+	var pairs map[int]int
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '\n':
+			b.WriteString("<br>\n")
+			i++
+			continue
+		case c == '`':
+			n := runLength(s, i)
+			if end := closingRun(s, i+n, '`', n); end >= 0 {
+				code := s[i+n : end]
+				code = strings.ReplaceAll(code, "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end + n
+			} else {
+				b.WriteString(s[i : i+n])
+				i += n
+			}
+			continue
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if pairs == nil {
+				pairs = matchPairs(s)
+			}
+			if text, dest, title, end, ok := parseLink(s, i+1, pairs); ok {
+				writeImage(b, text, dest, title)
+				i = end
+				continue
+			}
+		case c == '[' && links:
+			if pairs == nil {
+				pairs = matchPairs(s)
+			}
+			if text, dest, title, end, ok := parseLink(s, i, pairs); ok {
+				writeLink(b, dest, title, func() { renderSpan(b, text, false) })
+				i = end
+				continue
+			}
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 1 {
+				inner := s[i+1 : i+end]
+				dest := inner
+				if !strings.Contains(inner, ":") && strings.Contains(inner, "@") {
+					dest = "mailto:" + inner
+				}
+				if !strings.ContainsAny(inner, " \n<") && strings.Contains(dest, ":") {
+					if _, ok := safeURL(dest); ok {
+						writeLink(b, dest, "", func() { b.WriteString(html.EscapeString(inner)) })
+						i += end + 1
+						continue
+					}
+				}
+			}
+		case c == 'h' && links && (i == 0 || !isAlnum(s[i-1])) &&
+			(strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")):
+			end := i + strings.IndexFunc(s[i:], func(r rune) bool { return unicode.IsSpace(r) || r == '<' })
+			if end < i {
+				end = len(s)
+			}
+			url := strings.TrimRight(s[i:end], ".,:;!?'\")*_~")
+			if host := url[strings.Index(url, "//")+2:]; host != "" {
+				writeLink(b, url, "", func() { b.WriteString(html.EscapeString(url)) })
+				i += len(url)
+				continue
+			}
+		case c == '*' || c == '_' || c == '~':
+			if end := renderEmphasis(b, s, i, links); end > i {
+				i = end
+				continue
+			}
+			n := runLength(s, i)
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+}
+
+// renderEmphasis 渲染 *斜体* **加粗** ***加粗斜体*** ~~删除线~~, 返回结束位置, 不匹配时返回 i
+func renderEmphasis(b *strings.Builder, s string, i int, links bool) int {
+	c := s[i]
+	n := runLength(s, i)
+	if (c == '~' && n != 2) || n > 3 {
+		return i
+	}
+	if i+n >= len(s) || unicode.IsSpace(rune(s[i+n])) {
+		return i
+	}
+	if c == '_' && i > 0 && isAlnum(s[i-1]) {
+		return i
+	}
+	end := closingRun(s, i+n, c, n)
+	if end < 0 || unicode.IsSpace(rune(s[end-1])) {
+		return i
+	}
+	if c == '_' && end+n < len(s) && isAlnum(s[end+n]) {
+		return i
+	}
+
+	var open, closeTag string
+	switch {
+	case c == '~':
+		open, closeTag = "<del>", "</del>"
+	case n == 1:
+		open, closeTag = "<em>", "</em>"
+	case n == 2:
+		open, closeTag = "<strong>", "</strong>"
+	default:
+		open, closeTag = "<strong><em>", "</em></strong>"
+	}
+	b.WriteString(open)
+	renderSpan(b, s[i+n:end], links)
+	b.WriteString(closeTag)
+	return end + n
+}
+
+// runLength 返回从 i 开始连续相同字符的个数
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// closingRun 从 from 开始查找长度恰好为 n 的 c 字符串, 跳过转义字符与行内代码
+func closingRun(s string, from int, c byte, n int) int {
+	for k := from; k < len(s); {
+		switch {
+		case s[k] == '\\' && k+1 < len(s):
+			k += 2
+		case s[k] == '`' && c != '`':
+			m := runLength(s, k)
+			if end := closingRun(s, k+m, '`', m); end >= 0 {
+				k = end + m
+			} else {
+				k += m
+			}
+		case s[k] == c:
+			m := runLength(s, k)
+			if m == n {
+				return k
+			}
+			k += m
+		default:
+			k++
+		}
+	}
+	return -1
+}
+
+// parseLink 解析 [text](dest "title"), i 指向 '[', pairs 为 matchPairs 计算的括号配对位置
+func parseLink(s string, i int, pairs map[int]int) (text, dest, title string, end int, ok bool) {
+	closeBracket, found := pairs[i]
+	if !found || closeBracket+1 >= len(s) || s[closeBracket+1] != '(' {
+		return "", "", "", 0, false
+	}
+	closeParen, found := pairs[closeBracket+1]
+	if !found {
+		return "", "", "", 0, false
+	}
+
+	inner := strings.TrimSpace(s[closeBracket+2 : closeParen])
+	if strings.HasPrefix(inner, "<") {
+		if gt := strings.IndexByte(inner, '>'); gt > 0 {
+			dest, inner = inner[1:gt], strings.TrimSpace(inner[gt+1:])
+		}
+	} else if sp := strings.IndexAny(inner, " \t"); sp >= 0 {
+		dest, inner = inner[:sp], strings.TrimSpace(inner[sp:])
+	} else {
+		dest, inner = inner, ""
+	}
+	if len(inner) >= 2 && strings.ContainsRune(`"'`, rune(inner[0])) && inner[len(inner)-1] == inner[0] {
+		title = inner[1 : len(inner)-1]
+	} else if inner != "" {
+		return "", "", "", 0, false
+	}
+	return s[i+1 : closeBracket], dest, title, closeParen + 1, true
+}
+
+// matchPairs 一次扫描计算 [ ] 与 ( ) 的配对位置(跳过转义字符, 圆括号不跨行),
+// 返回左括号位置到对应右括号位置的映射; 逐个从 [ 向后查找在嵌套或未闭合的括号较多时会退化为平方级
+func matchPairs(s string) map[int]int {
+	pairs := make(map[int]int)
+	var brackets, parens []int
+	for k := 0; k < len(s); k++ {
+		switch s[k] {
+		case '\\':
+			k++
+		case '\n':
+			parens = parens[:0]
+		case '[':
+			brackets = append(brackets, k)
+		case ']':
+			if n := len(brackets); n > 0 {
+				pairs[brackets[n-1]] = k
+				brackets = brackets[:n-1]
+			}
+		case '(':
+			parens = append(parens, k)
+		case ')':
+			if n := len(parens); n > 0 {
+				pairs[parens[n-1]] = k
+				parens = parens[:n-1]
+			}
+		}
+	}
+	return pairs
+}
+
+// safeURL 校验链接地址, 只允许 http/https/mailto 以及相对地址
+func safeURL(raw string) (string, bool) {
+	u := strings.TrimSpace(raw)
+	if u == "" {
+		return "", false
+	}
+	for _, r := range u {
+		if r < 0x20 || r == 0x7f || unicode.IsSpace(r) {
+			return "", false
+		}
+	}
+	if k := strings.IndexAny(u, ":/?#"); k >= 0 && u[k] == ':' {
+		switch strings.ToLower(u[:k]) {
+		case "http", "https", "mailto":
+		default:
+			return "", false
+		}
+	}
+	return html.EscapeString(u), true
+}
+
+// writeLink 输出链接, 地址不安全时只输出文本
+func writeLink(b *strings.Builder, dest, title string, text func()) {
+	href, ok := safeURL(dest)
+	if !ok {
+		text()
+		return
+	}
+	b.WriteString(`<a href="` + href + `"`)
+	if title != "" {
+		b.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	b.WriteString(` rel="nofollow noopener noreferrer" target="_blank">`)
+	text()
+	b.WriteString("</a>")
+}
+
+// writeImage 输出图片, 不允许 mailto 等非图片地址
+func writeImage(b *strings.Builder, alt, dest, title string) {
+	src, ok := safeURL(dest)
+	if !ok || strings.HasPrefix(strings.ToLower(strings.TrimSpace(dest)), "mailto:") {
+		b.WriteString(html.EscapeString(alt))
+		return
+	}
+	b.WriteString(`<img src="` + src + `" alt="` + html.EscapeString(alt) + `"`)
+	if title != "" {
+		b.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	b.WriteString(` loading="lazy">`)
+}
+
+func isPunct(c byte) bool {
+	return c < 0x80 && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,150 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// linkAttrs 链接固定附加的属性
+const linkAttrs = ` rel="nofollow noopener noreferrer" target="_blank"`
+
+func TestRenderEscapesHTML(t *testing.T) {
+	for _, tc := range []struct{ name, src, want string }{
+		{"script 标签", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"img onerror", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n"},
+		{"标题中的 HTML", "# <b>标题</b>", "<h1>&lt;b&gt;标题&lt;/b&gt;</h1>\n"},
+		{"行内代码", "`<script>`", "<p><code>&lt;script&gt;</code></p>\n"},
+		{"表格单元格", "| a |\n|---|\n| <i>x</i> |", "<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>&lt;i&gt;x&lt;/i&gt;</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"转义字符", `\<script\>`, "<p>&lt;script&gt;</p>\n"},
+		{"引号与 &", `"a" & 'b'`, "<p>&#34;a&#34; &amp; &#39;b&#39;</p>\n"},
+	} {
+		if got := Render(tc.src); got != tc.want {
+			t.Errorf("%s: Render(%q)\n got  %q\n want %q", tc.name, tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestRenderLinkSchemes(t *testing.T) {
+	link := func(href, text string) string {
+		return `<p><a href="` + href + `"` + linkAttrs + `>` + text + "</a></p>\n"
+	}
+	for _, tc := range []struct{ name, src, want string }{
+		{"https", "[x](https://a.com/p?a=1&b=2)", link("https://a.com/p?a=1&amp;b=2", "x")},
+		{"mailto", "[x](mailto:a@b.com)", link("mailto:a@b.com", "x")},
+		{"相对地址", "[x](/notes/1)", link("/notes/1", "x")},
+		{"锚点", "[x](#top)", link("#top", "x")},
+		{"javascript", "[x](javascript:alert(1))", "<p>x</p>\n"},
+		{"大小写混合", "[x](JaVaScRiPt:alert(1))", "<p>x</p>\n"},
+		{"前导空白", "[x](  javascript:alert(1))", "<p>x</p>\n"},
+		{"尖括号地址", "[x](<javascript:alert(1)>)", "<p>x</p>\n"},
+		{"控制字符", "[x](java\x01script:alert(1))", "<p>x</p>\n"},
+		{"不间断空格", "[x]( javascript:alert(1))", "<p>x</p>\n"},
+		{"制表符拆分", "[x](java\tscript:alert(1))", "<p>[x](java    script:alert(1))</p>\n"},
+		{"换行拆分", "[x](java\nscript:alert(1))", "<p>[x](java<br>\nscript:alert(1))</p>\n"},
+		{"vbscript", "[x](vbscript:msgbox(1))", "<p>x</p>\n"},
+		{"data", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>\n"},
+		// 不解码实体: & 被转义后浏览器得到的是字面量 &#58;, 地址按相对路径处理
+		{"十进制实体冒号", "[x](javascript&#58;alert(1))", link("javascript&amp;#58;alert(1)", "x")},
+		{"命名实体冒号", "[x](javascript&colon;alert(1))", link("javascript&amp;colon;alert(1)", "x")},
+		{"地址中的引号", `[x](https://a.com/"onmouseover=)`, link("https://a.com/&#34;onmouseover=", "x")},
+		{"自动链接", "<https://a.com/?a=1&b=2>", link("https://a.com/?a=1&amp;b=2", "https://a.com/?a=1&amp;b=2")},
+		{"邮箱自动链接", "<a@b.com>", link("mailto:a@b.com", "a@b.com")},
+		{"javascript 自动链接", "<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{"裸地址截断于引号", `https://a.com/x"<z>`, `<p><a href="https://a.com/x"` + linkAttrs + `>https://a.com/x</a>&#34;&lt;z&gt;</p>` + "\n"},
+	} {
+		if got := Render(tc.src); got != tc.want {
+			t.Errorf("%s: Render(%q)\n got  %q\n want %q", tc.name, tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestRenderTitlesAndImages(t *testing.T) {
+	for _, tc := range []struct{ name, src, want string }{
+		{"标题中的双引号", `[x](https://a.com 'a"b')`,
+			`<p><a href="https://a.com" title="a&#34;b"` + linkAttrs + ">x</a></p>\n"},
+		{"标题中的转义引号", `[x](https://a.com "t\" onmouseover=\"x")`,
+			`<p><a href="https://a.com" title="t\&#34; onmouseover=\&#34;x"` + linkAttrs + ">x</a></p>\n"},
+		{"标题未加引号", `[x](https://a.com title)`, "<p>[x](<a href=\"https://a.com\"" + linkAttrs + ">https://a.com</a> title)</p>\n"},
+		{"图片 alt 中的引号", `![a" onerror="x](https://a.com/i.png "t'")`,
+			`<p><img src="https://a.com/i.png" alt="a&#34; onerror=&#34;x" title="t&#39;" loading="lazy"></p>` + "\n"},
+		{"图片 alt 中的标签", `![<script>](https://a.com/i.png)`,
+			`<p><img src="https://a.com/i.png" alt="&lt;script&gt;" loading="lazy"></p>` + "\n"},
+		{"javascript 图片", "![a](javascript:alert(1))", "<p>a</p>\n"},
+		{"mailto 图片", "![a](mailto:a@b.com)", "<p>a</p>\n"},
+		{"链接中的图片", "[![i](https://a.com/i.png)](https://a.com)",
+			`<p><a href="https://a.com"` + linkAttrs + `><img src="https://a.com/i.png" alt="i" loading="lazy"></a></p>` + "\n"},
+		{"链接不能嵌套", "[a [b](https://b.com)](https://a.com)",
+			`<p><a href="https://a.com"` + linkAttrs + ">a [b](https://b.com)</a></p>\n"},
+	} {
+		if got := Render(tc.src); got != tc.want {
+			t.Errorf("%s: Render(%q)\n got  %q\n want %q", tc.name, tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestRenderFenceInfo(t *testing.T) {
+	for _, tc := range []struct{ name, src, want string }{
+		{"语言", "```go\nfmt.Println(\"<b>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;b&gt;&#34;)\n</code></pre>\n"},
+		{"信息串中的引号", "```js\" onload=\"x\ncode\n```", "<pre><code class=\"language-js\">code\n</code></pre>\n"},
+		{"信息串中的标签", "~~~<script>\ncode\n~~~", "<pre><code class=\"language-script\">code\n</code></pre>\n"},
+		{"只有符号的信息串", "```\"><\ncode\n```", "<pre><code>code\n</code></pre>\n"},
+		{"未闭合的代码块", "```\n<script>", "<pre><code>&lt;script&gt;\n</code></pre>\n"},
+	} {
+		if got := Render(tc.src); got != tc.want {
+			t.Errorf("%s: Render(%q)\n got  %q\n want %q", tc.name, tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestRenderUnclosed(t *testing.T) {
+	for _, tc := range []struct{ name, src, want string }{
+		{"未闭合斜体", "*abc", "<p>*abc</p>\n"},
+		{"不匹配的加粗", "**abc*", "<p>**abc*</p>\n"},
+		{"未闭合删除线", "~~abc", "<p>~~abc</p>\n"},
+		{"未闭合行内代码", "`abc", "<p>`abc</p>\n"},
+		{"未闭合链接文本", "[abc", "<p>[abc</p>\n"},
+		{"没有地址", "[abc]", "<p>[abc]</p>\n"},
+		{"未闭合地址", "[abc](/x", "<p>[abc](/x</p>\n"},
+		{"未闭合图片", "![abc](", "<p>![abc](</p>\n"},
+		{"强调", "***a*** **b** *c* ~~d~~", "<p><strong><em>a</em></strong> <strong>b</strong> <em>c</em> <del>d</del></p>\n"},
+		{"单词内下划线", "snake_case_name", "<p>snake_case_name</p>\n"},
+	} {
+		if got := Render(tc.src); got != tc.want {
+			t.Errorf("%s: Render(%q)\n got  %q\n want %q", tc.name, tc.src, got, tc.want)
+		}
+	}
+}
+
+// TestRenderPathological 嵌套或未闭合的 [ ( * ` 不应导致平方级的扫描
+func TestRenderPathological(t *testing.T) {
+	const n = 20000 // 备注长度上限
+	inputs := map[string]string{
+		"[":          strings.Repeat("[", n),
+		"[a":         strings.Repeat("[a", n/2),
+		"![":         strings.Repeat("![", n/2),
+		"[a](x":      strings.Repeat("[a](x", n/5),
+		"[a](":       strings.Repeat("[a](", n/4),
+		"*[":         strings.Repeat("*[", n/2),
+		"[*...*]":    strings.Repeat("[*", n/4) + strings.Repeat("*]", n/4),
+		"嵌套链接":       strings.Repeat("[", n/5) + "a" + strings.Repeat("](x)", n/5),
+		"**a*":       strings.Repeat("**a*", n/4),
+		"``a`":       strings.Repeat("``a`", n/4),
+		"<a":         strings.Repeat("<a", n/2),
+		"*a _b":      strings.Repeat("*a _b ", n/6),
+		">":          strings.Repeat(">", n),
+		"- ":         strings.Repeat("- ", n/2),
+		"多行 [a](x\n": strings.Repeat("[a](x\n", n/6),
+	}
+	start := time.Now()
+	for name, src := range inputs {
+		began := time.Now()
+		Render(src)
+		if d := time.Since(began); d > time.Second {
+			t.Errorf("%s: 渲染 %d 字节耗时 %v", name, len(src), d)
+		}
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("渲染全部病态输入耗时 %v", d)
+	}
+}
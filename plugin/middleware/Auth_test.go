@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"go-film-demo/config"
+	"go-film-demo/model/user"
+	"go-film-demo/plugin/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamTicketOnlyAcceptedByStreamAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.JWTSecret = "middleware-test"
+	r := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/stream", StreamAuth(), ok)
+	r.GET("/other", Auth(), ok)
+
+	ticket, err := auth.IssueStreamTicket(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := auth.Sign(&auth.Claims{Subject: "1", Type: user.TokenAccess, ExpiresAt: 1 << 40}, []byte(config.JWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/stream?ticket=" + ticket.Ticket, http.StatusOK},
+		{"/other?ticket=" + ticket.Ticket, http.StatusUnauthorized},
+		{"/stream?access_token=" + access, http.StatusUnauthorized},
+		{"/other?access_token=" + access, http.StatusUnauthorized},
+		{"/stream?ticket=" + access, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("GET %s = %d, want %d", tc.path[:12], w.Code, tc.want)
+		}
+	}
+
+	for token, want := range map[string]int{access: http.StatusOK, ticket.Ticket: http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/other", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("bearer token = %d, want %d", w.Code, want)
+		}
+	}
+}
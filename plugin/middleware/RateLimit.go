@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"go-film-demo/config"
+	"go-film-demo/model/system"
+	"go-film-demo/plugin/ratelimit"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc 返回限流维度的 key
+type KeyFunc func(c *gin.Context) string
+
+// userLimiter 每个登录用户全部接口共用的限流器, 在 Auth 中间件认证通过后检查
+var userLimiter = newLimiter(config.RateLimitUser)
+
+// ByIP 按客户端 IP 限流, 只信任 TRUSTED_PROXIES 中代理转发的 X-Forwarded-For
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser 按登录用户限流, 未经过 Auth 中间件时按 IP 限流
+func ByUser(c *gin.Context) string {
+	if id := UserID(c); id > 0 {
+		return "user:" + strconv.FormatInt(id, 10)
+	}
+	return ByIP(c)
+}
+
+// RateLimit 按 key 限流, 使用该中间件的全部路由共用令牌桶; spec 格式见 ratelimit.Parse, 为空或 off 时不限流
+func RateLimit(spec string, key KeyFunc) gin.HandlerFunc {
+	return rateLimit(newLimiter(spec), key, false)
+}
+
+// RouteRateLimit 按 key 与路由限流, 每个路由单独计数
+func RouteRateLimit(spec string, key KeyFunc) gin.HandlerFunc {
+	return rateLimit(newLimiter(spec), key, true)
+}
+
+func rateLimit(limiter *ratelimit.Limiter, key KeyFunc, perRoute bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if perRoute {
+			k = c.Request.Method + " " + c.FullPath() + " " + k
+		}
+		if !allow(c, limiter, k) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow 检查限流, 超出限制时写入 429 响应与 Retry-After 响应头; limiter 为 nil 表示不限流
+func allow(c *gin.Context, limiter *ratelimit.Limiter, key string) bool {
+	if limiter == nil {
+		return true
+	}
+	ok, wait := limiter.Allow(key, time.Now())
+	if ok {
+		return true
+	}
+	retryAfter := ratelimit.RetryAfter(wait)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	system.TooManyRequests("请求过于频繁, 请 "+strconv.Itoa(retryAfter)+" 秒后重试", c)
+	return false
+}
+
+// newLimiter 根据配置创建限流器, 配置错误时终止启动
+func newLimiter(spec string) *ratelimit.Limiter {
+	limit, err := ratelimit.Parse(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if limit == nil {
+		return nil
+	}
+	return ratelimit.New(*limit)
+}
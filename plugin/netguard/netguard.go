@@ -0,0 +1,115 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+/*
+	防止服务端请求伪造(SSRF): 用户提交的回调地址只能指向公网,
+	保存时解析域名检查一次, 发送时在建立连接前再检查实际连接的 IP(防止 DNS 重绑定), 并且不跟随重定向
+*/
+
+// ErrBlockedAddress 地址指向本机、内网、链路本地或云厂商元数据服务
+var ErrBlockedAddress = errors.New("不允许访问本机、内网或保留地址")
+
+// blockedPrefixes IsPrivate/IsLoopback 等方法未覆盖的保留网段
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, 可映射到内网 IPv4
+	netip.MustParsePrefix("2001:db8::/32"), // 文档
+}
+
+// blockedHosts 元数据服务等内部域名
+var blockedHosts = []string{"localhost", "metadata.google.internal", "metadata"}
+
+// Blocked 判断 IP 是否为禁止访问的地址
+func Blocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL 校验地址为 http(s) 且域名解析到的全部 IP 都是公网地址
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("地址必须为 http 或 https 地址")
+	}
+	if u.User != nil {
+		return errors.New("地址不能包含用户名与密码")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	for _, h := range blockedHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return ErrBlockedAddress
+		}
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if Blocked(ip) {
+			return ErrBlockedAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("无法解析域名 %s: %w", host, err)
+	}
+	for _, ip := range addrs {
+		if Blocked(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+// NewClient 创建只能连接公网地址、不跟随重定向、不使用环境变量代理的 HTTP 客户端
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// 在建立连接前检查实际连接的 IP, 域名在保存后被重新解析到内网时同样会被拒绝
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || Blocked(addrPort.Addr()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
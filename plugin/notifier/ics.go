@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"fmt"
+	"go-film-demo/model/schedule"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsTimeFormat iCalendar UTC 时间格式
+const icsTimeFormat = "20060102T150405Z"
+
+// icsPriority 日程优先级对应的 iCalendar PRIORITY(1 最高, 9 最低)
+var icsPriority = map[int8]int{
+	schedule.PriorityLow:    9,
+	schedule.PriorityMedium: 5,
+	schedule.PriorityHigh:   1,
+}
+
+// icsEscaper iCalendar TEXT 类型转义(RFC 5545 3.3.11)
+var icsEscaper = strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n", "\r", "")
+
+// BuildICS 生成包含单个日程的 iCalendar 文件, 可直接导入日历客户端
+func BuildICS(s *schedule.Schedule, now time.Time) []byte {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Go Schedule//CN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:schedule-%d@go-schedule", s.ID),
+		"DTSTAMP:" + now.UTC().Format(icsTimeFormat),
+		"DTSTART:" + s.StartTime.UTC().Format(icsTimeFormat),
+		"DTEND:" + s.EndTime.UTC().Format(icsTimeFormat),
+		"SUMMARY:" + icsEscaper.Replace(s.Content),
+		fmt.Sprintf("PRIORITY:%d", icsPriority[s.Priority]),
+		fmt.Sprintf("SEQUENCE:%d", s.Version),
+	}
+	if place := strings.TrimSpace(strings.Join([]string{s.Location, s.Address}, " ")); place != "" {
+		lines = append(lines, "LOCATION:"+icsEscaper.Replace(place))
+	} else if s.MeetingURL != "" {
+		lines = append(lines, "LOCATION:"+icsEscaper.Replace(s.MeetingURL))
+	}
+	if s.Latitude != nil && s.Longitude != nil {
+		lines = append(lines, fmt.Sprintf("GEO:%.6f;%.6f", *s.Latitude, *s.Longitude))
+	}
+	if s.MeetingURL != "" {
+		lines = append(lines, "URL:"+s.MeetingURL)
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+	var sb strings.Builder
+	for _, line := range lines {
+		foldLine(&sb, line)
+	}
+	return []byte(sb.String())
+}
+
+// foldLine 按 RFC 5545 将超过 75 字节的内容行折行, 不拆分多字节字符
+func foldLine(sb *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // 续行以空格开头, 占用一个字节
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+}
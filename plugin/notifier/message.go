@@ -0,0 +1,153 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/model/briefing"
+	"go-film-demo/model/news"
+	"go-film-demo/model/notify"
+	"go-film-demo/model/schedule"
+	"go-film-demo/plugin/digest"
+	htmltemplate "html/template"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// reminderData 日程提醒模板数据
+type reminderData struct {
+	Schedule *schedule.Schedule
+	Minutes  int
+	Priority string
+}
+
+var templateFuncs = map[string]any{
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"clock":    func(t time.Time) string { return t.Format("15:04") },
+}
+
+var reminderText = texttemplate.Must(texttemplate.New("reminder").Funcs(templateFuncs).Parse(
+	`您的日程将在 {{.Minutes}} 分钟内开始:
+
+内容: {{.Schedule.Content}}
+时间: {{datetime .Schedule.StartTime}} - {{clock .Schedule.EndTime}}
+{{- if or .Schedule.Location .Schedule.Address}}
+地点: {{.Schedule.Location}} {{.Schedule.Address}}{{end}}
+{{- if .Schedule.MeetingURL}}
+会议链接: {{.Schedule.MeetingURL}}{{end}}
+优先级: {{.Priority}}
+`))
+
+var reminderHTML = htmltemplate.Must(htmltemplate.New("reminder").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><title>日程提醒</title></head>
+<body style="font-family: -apple-system, 'PingFang SC', 'Microsoft YaHei', sans-serif; max-width: 720px; margin: 0 auto; padding: 16px;">
+<p>您的日程将在 <strong>{{.Minutes}}</strong> 分钟内开始:</p>
+<table cellpadding="4">
+<tr><td>内容</td><td><strong>{{.Schedule.Content}}</strong></td></tr>
+<tr><td>时间</td><td>{{datetime .Schedule.StartTime}} - {{clock .Schedule.EndTime}}</td></tr>
+{{- if or .Schedule.Location .Schedule.Address}}
+<tr><td>地点</td><td>{{.Schedule.Location}} {{.Schedule.Address}}</td></tr>{{end}}
+{{- if .Schedule.MeetingURL}}
+<tr><td>会议链接</td><td><a href="{{.Schedule.MeetingURL}}">{{.Schedule.MeetingURL}}</a></td></tr>{{end}}
+<tr><td>优先级</td><td>{{.Priority}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// ReminderMessage 生成日程提醒消息, 附带 .ics 日历文件
+func ReminderMessage(s *schedule.Schedule) (*notify.Message, error) {
+	data := reminderData{Schedule: s, Minutes: config.ReminderLeadMinutes, Priority: digest.PriorityLabel(s.Priority)}
+	var text, html bytes.Buffer
+	if err := reminderText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := reminderHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	return &notify.Message{
+		Subject:  fmt.Sprintf("日程提醒: %s %s", s.StartTime.Format("15:04"), truncate(s.Content, 50)),
+		Text:     text.String(),
+		HTML:     html.String(),
+		Markdown: fmt.Sprintf("### 日程提醒\n\n%s", text.String()),
+		Attachments: []notify.Attachment{{
+			Name:        fmt.Sprintf("schedule-%d.ics", s.ID),
+			ContentType: "text/calendar; charset=UTF-8; method=PUBLISH",
+			Data:        BuildICS(s, time.Now()),
+		}},
+	}, nil
+}
+
+// BriefingMessage 生成每日简报消息
+func BriefingMessage(b *briefing.Briefing) (*notify.Message, error) {
+	html, err := digest.RenderHTML(b)
+	if err != nil {
+		return nil, err
+	}
+	markdown := digest.RenderMarkdown(b)
+	return &notify.Message{
+		Subject:  fmt.Sprintf("每日简报 %s", b.Date),
+		Text:     markdown,
+		HTML:     html,
+		Markdown: markdown,
+	}, nil
+}
+
+// newsMessageLimit 新闻速递中最多列出的新闻条数
+const newsMessageLimit = 5
+
+// NewsMessage 生成新闻速递消息, 按阅读量列出新采集的新闻
+func NewsMessage(list []*news.News) *notify.Message {
+	sorted := slices.Clone(list)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ReadNum > sorted[j].ReadNum
+	})
+	if len(sorted) > newsMessageLimit {
+		sorted = sorted[:newsMessageLimit]
+	}
+
+	var text, markdown, html strings.Builder
+	fmt.Fprintf(&markdown, "### 新闻速递 (新增 %d 条)\n\n", len(list))
+	html.WriteString("<ol>")
+	for i, n := range sorted {
+		fmt.Fprintf(&text, "%d. %s %s\n", i+1, n.Title, n.Link)
+		fmt.Fprintf(&markdown, "%d. [%s](%s)\n", i+1, digest.MarkdownEscape(n.Title), n.Link)
+		fmt.Fprintf(&html, `<li><a href="%s">%s</a></li>`,
+			htmltemplate.HTMLEscapeString(n.Link), htmltemplate.HTMLEscapeString(n.Title))
+	}
+	html.WriteString("</ol>")
+	return &notify.Message{
+		Subject:  fmt.Sprintf("新闻速递: 新增 %d 条新闻", len(list)),
+		Text:     text.String(),
+		HTML:     html.String(),
+		Markdown: markdown.String(),
+	}
+}
+
+// TestMessage 渠道测试消息
+func TestMessage() *notify.Message {
+	text := fmt.Sprintf("这是一条测试通知, 发送时间 %s。", time.Now().Format("2006-01-02 15:04:05"))
+	return &notify.Message{
+		Subject:  "Go Schedule 通知测试",
+		Text:     text,
+		HTML:     "<p>" + htmltemplate.HTMLEscapeString(text) + "</p>",
+		Markdown: text,
+	}
+}
+
+// sendBriefing 每日简报预生成后推送到用户的通知渠道
+func sendBriefing(b *briefing.Briefing) {
+	msg, err := BriefingMessage(b)
+	if err != nil {
+		log.Printf("渲染用户 %d 的每日简报失败: %v", b.UserID, err)
+		return
+	}
+	if err = Dispatch(b.UserID, notify.EventBriefing, b.Date, msg); err != nil {
+		log.Printf("推送用户 %d 的每日简报失败: %v", b.UserID, err)
+	}
+}
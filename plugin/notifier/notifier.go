@@ -0,0 +1,220 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/dao"
+	"go-film-demo/model/notify"
+	"go-film-demo/plugin/cron"
+	"go-film-demo/plugin/digest"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+	通知投递: 业务事件先写入 notify_delivery 队列, 再由定时任务按渠道投递,
+	失败后按 attempts² 分钟退避重试, 超过最大次数标记为失败; 队列同时作为投递日志
+*/
+
+// Notifier 通知渠道实现
+type Notifier interface {
+	// Validate 校验渠道配置
+	Validate(ch *notify.Channel) error
+	// Send 向渠道发送消息
+	Send(ch *notify.Channel, msg *notify.Message) error
+}
+
+// batchSize 每次处理的投递数量
+const batchSize = 50
+
+// errChannelDeleted 渠道已删除, 无需重试
+var errChannelDeleted = errors.New("通知渠道已删除")
+
+var (
+	NotifyDao   = dao.NewNotifyDao()
+	ScheduleDao = dao.NewScheduleDao()
+
+	notifiers = map[string]Notifier{
+		notify.ChannelEmail:    NewSMTPNotifier(),
+		notify.ChannelWeCom:    NewRobotNotifier(notify.ChannelWeCom, config.WeComWebhookURL),
+		notify.ChannelDingTalk: NewRobotNotifier(notify.ChannelDingTalk, config.DingTalkWebhookURL),
+		notify.ChannelFeishu:   NewRobotNotifier(notify.ChannelFeishu, config.FeishuWebhookURL),
+	}
+	running sync.Mutex // 防止上一轮投递未结束时重复执行
+)
+
+// Register 注册(或替换)渠道实现, 需在启动阶段调用
+func Register(channelType string, n Notifier) {
+	notifiers[channelType] = n
+}
+
+// Validate 校验渠道类型、订阅事件及配置
+func Validate(ch *notify.Channel) error {
+	n, ok := notifiers[ch.Type]
+	if !ok {
+		return fmt.Errorf("不支持的通知渠道类型: %s", ch.Type)
+	}
+	if ch.Events != "" {
+		for _, e := range strings.Split(ch.Events, ",") {
+			if !slices.Contains(notify.SubscribableEvents, e) {
+				return fmt.Errorf("不支持的通知事件: %s", e)
+			}
+		}
+	}
+	return n.Validate(ch)
+}
+
+// Setup 注册投递与日程提醒定时任务, 并在每日简报预生成后推送
+func Setup(cm *cron.CronManager) error {
+	if err := cm.AddTask("notify-delivery-30s", "*/30 * * * * *", ProcessQueue); err != nil {
+		return err
+	}
+	if err := cm.AddTask("notify-reminder-1m", "0 * * * * *", scanReminders); err != nil {
+		return err
+	}
+	digest.OnPrebuilt(sendBriefing)
+	return nil
+}
+
+// Dispatch 将消息加入用户订阅了该事件的已启用渠道的投递队列, 相同事件与 key 只投递一次
+func Dispatch(userID int64, event, key string, msg *notify.Message) error {
+	channels, err := NotifyDao.ListEnabledChannels(userID)
+	if err != nil {
+		return err
+	}
+	return enqueueAll(channels, event, key, msg)
+}
+
+// Broadcast 将消息加入全部订阅了该事件的渠道(含团队共享渠道)的投递队列
+func Broadcast(event, key string, msg *notify.Message) error {
+	channels, err := NotifyDao.ListEnabledChannelsByEvent(event)
+	if err != nil {
+		return err
+	}
+	return enqueueAll(channels, event, key, msg)
+}
+
+// enqueueAll 为订阅了事件的渠道写入投递记录
+func enqueueAll(channels []notify.Channel, event, key string, msg *notify.Message) error {
+	var err error
+	for i := range channels {
+		if _, ok := notifiers[channels[i].Type]; !ok || !channels[i].Subscribes(event) {
+			continue
+		}
+		if _, err = Enqueue(&channels[i], event, fmt.Sprintf("%s:%s:%d", event, key, channels[i].ID), msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enqueue 将消息加入指定渠道的投递队列
+func Enqueue(ch *notify.Channel, event, dedupeKey string, msg *notify.Message) (*notify.Delivery, error) {
+	d := &notify.Delivery{
+		UserID:        ch.UserID,
+		ChannelID:     ch.ID,
+		ChannelType:   ch.Type,
+		Event:         event,
+		DedupeKey:     dedupeKey,
+		Subject:       truncate(msg.Subject, 255),
+		Payload:       msg,
+		Status:        notify.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if _, err := NotifyDao.Enqueue(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ProcessQueue 投递全部到期的通知
+func ProcessQueue() {
+	if !running.TryLock() {
+		return
+	}
+	defer running.Unlock()
+
+	list, err := NotifyDao.ListDueDeliveries(time.Now(), batchSize)
+	if err != nil {
+		return
+	}
+	for i := range list {
+		deliver(&list[i])
+	}
+}
+
+// deliver 投递单条通知并记录结果
+func deliver(d *notify.Delivery) {
+	err := send(d)
+	now := time.Now()
+	d.Attempts++
+	d.UpdateAt = now
+	if err == nil {
+		d.Status = notify.DeliverySent
+		d.SentAt = &now
+		d.LastError = ""
+	} else {
+		log.Printf("投递通知 %d 失败(第 %d 次): %v", d.ID, d.Attempts, err)
+		d.LastError = truncate(err.Error(), 1000)
+		if d.Attempts >= config.NotifyMaxAttempts || errors.Is(err, errChannelDeleted) {
+			d.Status = notify.DeliveryFailed
+		} else {
+			d.NextAttemptAt = now.Add(time.Duration(d.Attempts*d.Attempts) * time.Minute)
+		}
+	}
+	_ = NotifyDao.UpdateDeliveryResult(d)
+}
+
+// send 查找渠道并发送
+func send(d *notify.Delivery) error {
+	ch, err := NotifyDao.GetChannel(d.ChannelID)
+	if err != nil {
+		return err
+	}
+	if ch == nil {
+		return errChannelDeleted
+	}
+	n, ok := notifiers[ch.Type]
+	if !ok {
+		return fmt.Errorf("不支持的通知渠道类型: %s", ch.Type)
+	}
+	if d.Payload == nil {
+		return fmt.Errorf("通知内容为空")
+	}
+	return n.Send(ch, d.Payload)
+}
+
+// scanReminders 为即将开始的日程生成提醒, 日程改期后会按新的开始时间重新提醒
+func scanReminders() {
+	now := time.Now()
+	lead := time.Duration(config.ReminderLeadMinutes) * time.Minute
+	schedules, err := ScheduleDao.ListStartingBetween(now, now.Add(lead))
+	if err != nil {
+		return
+	}
+	for i := range schedules {
+		s := &schedules[i]
+		msg, err := ReminderMessage(s)
+		if err != nil {
+			log.Printf("生成日程 %d 的提醒失败: %v", s.ID, err)
+			continue
+		}
+		key := fmt.Sprintf("%d:%d", s.ID, s.StartTime.Unix())
+		if err = Dispatch(s.UserID, notify.EventReminder, key, msg); err != nil {
+			log.Printf("发送日程 %d 的提醒失败: %v", s.ID, err)
+		}
+	}
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,204 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-film-demo/model/notify"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// robotMaxBytes 机器人消息正文最大字节数(企业微信 markdown 上限为 4096 字节)
+const robotMaxBytes = 4000
+
+// officialHosts 各平台官方 webhook 域名, 渠道目标为完整地址时只允许这些域名与配置的 BaseURL 所在域名
+var officialHosts = map[string][]string{
+	notify.ChannelWeCom:    {"qyapi.weixin.qq.com"},
+	notify.ChannelDingTalk: {"oapi.dingtalk.com"},
+	notify.ChannelFeishu:   {"open.feishu.cn", "open.larksuite.com"},
+}
+
+// RobotNotifier 企业微信/钉钉/飞书群机器人通知渠道
+type RobotNotifier struct {
+	Platform string // 渠道类型
+	BaseURL  string // 渠道只配置令牌时拼接的 webhook 地址前缀
+	Client   *http.Client
+}
+
+// NewRobotNotifier 创建群机器人通知渠道
+func NewRobotNotifier(platform, baseURL string) *RobotNotifier {
+	return &RobotNotifier{
+		Platform: platform,
+		BaseURL:  baseURL,
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Validate 校验 webhook 地址
+func (n *RobotNotifier) Validate(ch *notify.Channel) error {
+	if _, err := n.webhookURL(ch.Target); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Send 按平台格式发送 Markdown 消息, 配置了密钥时附加签名
+func (n *RobotNotifier) Send(ch *notify.Channel, msg *notify.Message) error {
+	endpoint, err := n.webhookURL(ch.Target)
+	if err != nil {
+		return err
+	}
+	content := msg.Markdown
+	if content == "" {
+		content = msg.Text
+	}
+	content = truncateBytes(content, robotMaxBytes)
+
+	var payload map[string]any
+	switch n.Platform {
+	case notify.ChannelWeCom:
+		payload = map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]any{"content": content},
+		}
+	case notify.ChannelDingTalk:
+		payload = map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]any{"title": msg.Subject, "text": content},
+		}
+		if ch.Secret != "" {
+			endpoint = dingTalkSign(endpoint, ch.Secret, time.Now())
+		}
+	case notify.ChannelFeishu:
+		payload = map[string]any{
+			"msg_type": "interactive",
+			"card": map[string]any{
+				"header": map[string]any{
+					"title": map[string]any{"tag": "plain_text", "content": msg.Subject},
+				},
+				"elements": []any{map[string]any{"tag": "markdown", "content": content}},
+			},
+		}
+		if ch.Secret != "" {
+			timestamp, sign := feishuSign(ch.Secret, time.Now())
+			payload["timestamp"] = timestamp
+			payload["sign"] = sign
+		}
+	default:
+		return fmt.Errorf("不支持的机器人平台: %s", n.Platform)
+	}
+	return n.post(endpoint, payload)
+}
+
+// webhookURL 渠道目标为完整地址时校验域名后使用, 否则视为令牌拼接到 BaseURL 之后
+func (n *RobotNotifier) webhookURL(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", errors.New("webhook 地址不能为空")
+	}
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = n.BaseURL + url.PathEscape(target)
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("webhook 地址格式错误: %s", target)
+	}
+	if u.User != nil || !n.allowedHost(u) {
+		return "", errors.New("webhook 地址必须为平台官方机器人地址, 或只填写令牌")
+	}
+	return target, nil
+}
+
+// allowedHost 只允许官方域名(https)或 BaseURL 所在域名, 避免用户提交的地址被用于访问内网
+func (n *RobotNotifier) allowedHost(u *url.URL) bool {
+	if base, err := url.Parse(n.BaseURL); err == nil && base.Host != "" &&
+		u.Scheme == base.Scheme && strings.EqualFold(u.Host, base.Host) {
+		return true
+	}
+	return u.Scheme == "https" && slices.Contains(officialHosts[n.Platform], strings.ToLower(u.Host))
+}
+
+// robotResponse 各平台的响应格式, 企业微信/钉钉使用 errcode, 飞书使用 code
+type robotResponse struct {
+	ErrCode *int   `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Code    *int   `json:"code"`
+	Msg     string `json:"msg"`
+}
+
+// post 发送请求并检查平台返回的错误码
+func (n *RobotNotifier) post(endpoint string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(endpoint, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("机器人接口返回 HTTP %d: %s", resp.StatusCode, truncate(string(data), 200))
+	}
+
+	var result robotResponse
+	if err = json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("机器人接口响应格式错误: %s", truncate(string(data), 200))
+	}
+	if result.ErrCode != nil && *result.ErrCode != 0 {
+		return fmt.Errorf("机器人接口返回错误 %d: %s", *result.ErrCode, result.ErrMsg)
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("机器人接口返回错误 %d: %s", *result.Code, result.Msg)
+	}
+	return nil
+}
+
+// dingTalkSign 钉钉加签: 以密钥对 "毫秒时间戳\n密钥" 做 HmacSHA256, Base64 后作为 sign 查询参数
+func dingTalkSign(endpoint, secret string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+}
+
+// feishuSign 飞书签名校验: 以 "秒级时间戳\n密钥" 为 key 对空串做 HmacSHA256, Base64 后放入请求体
+func feishuSign(secret string, now time.Time) (string, string) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// truncateBytes 按字节截断字符串, 不拆分多字节字符
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
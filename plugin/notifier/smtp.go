@@ -0,0 +1,239 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/model/notify"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP 连接加密方式
+const (
+	SecurityNone     = "none"     // 明文, 用于本地测试服务器
+	SecuritySTARTTLS = "starttls" // 明文连接后升级为 TLS, 常用 587 端口
+	SecurityTLS      = "tls"      // 直接建立 TLS 连接, 常用 465 端口
+)
+
+const (
+	smtpDialTimeout = 10 * time.Second
+	smtpSendTimeout = 60 * time.Second
+)
+
+// SMTPNotifier 邮件通知渠道
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Security string
+}
+
+// NewSMTPNotifier 根据配置创建邮件通知渠道
+func NewSMTPNotifier() *SMTPNotifier {
+	return &SMTPNotifier{
+		Host:     config.SMTPHost,
+		Port:     config.SMTPPort,
+		Username: config.SMTPUsername,
+		Password: config.SMTPPassword,
+		From:     config.SMTPFrom,
+		Security: config.SMTPSecurity,
+	}
+}
+
+// Validate 校验收件地址
+func (n *SMTPNotifier) Validate(ch *notify.Channel) error {
+	if _, err := mail.ParseAddress(ch.Target); err != nil {
+		return fmt.Errorf("邮箱地址格式错误: %s", ch.Target)
+	}
+	return nil
+}
+
+// Send 发送邮件
+func (n *SMTPNotifier) Send(ch *notify.Channel, msg *notify.Message) error {
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return fmt.Errorf("发件人地址配置错误: %w", err)
+	}
+	to, err := mail.ParseAddress(ch.Target)
+	if err != nil {
+		return fmt.Errorf("邮箱地址格式错误: %w", err)
+	}
+	body, err := buildMIME(from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("SMTP 服务器不支持身份认证")
+		}
+		if err = client.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+	if err = client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial 按加密方式建立 SMTP 连接
+func (n *SMTPNotifier) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	tlsConfig := &tls.Config{ServerName: n.Host}
+
+	var conn net.Conn
+	var err error
+	switch n.Security {
+	case SecurityTLS:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	case SecurityNone, SecuritySTARTTLS:
+		conn, err = dialer.Dial("tcp", addr)
+	default:
+		return nil, fmt.Errorf("不支持的 SMTP 加密方式: %s", n.Security)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpSendTimeout))
+
+	client, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if n.Security == SecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP 服务器不支持 STARTTLS")
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// buildMIME 构建 multipart/mixed 邮件: 正文为 text/plain 与 text/html 的 multipart/alternative, 其后为附件
+func buildMIME(from, to *mail.Address, msg *notify.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	// 长标题会被编码为多个 encoded-word, 逐个折行避免超过行长限制
+	subject := strings.ReplaceAll(mime.BEncoding.Encode("UTF-8", msg.Subject), "?= =?", "?=\r\n =?")
+	fmt.Fprintf(&buf, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", randomID(), domainOf(from.Address))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+
+	var altBuf bytes.Buffer
+	alt := multipart.NewWriter(&altBuf)
+	if err := writeBase64Part(alt, "text/plain; charset=UTF-8", nil, []byte(msg.Text)); err != nil {
+		return nil, err
+	}
+	if msg.HTML != "" {
+		if err := writeBase64Part(alt, "text/html; charset=UTF-8", nil, []byte(msg.HTML)); err != nil {
+			return nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+	altHeader := textproto.MIMEHeader{}
+	altHeader.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", alt.Boundary()))
+	part, err := mixed.CreatePart(altHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = part.Write(altBuf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range msg.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		if err = writeBase64Part(mixed, a.ContentType, header, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err = mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64Part 写入 base64 编码的 MIME 分段, 每行 76 个字符
+func writeBase64Part(w *multipart.Writer, contentType string, header textproto.MIMEHeader, data []byte) error {
+	if header == nil {
+		header = textproto.MIMEHeader{}
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err = fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+// randomID 生成随机 Message-ID
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// domainOf 取邮箱地址的域名部分
+func domainOf(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,249 @@
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"go-film-demo/model/notify"
+	"go-film-demo/model/schedule"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP 测试用的 SMTP 服务器, 只实现发送一封邮件所需的命令, 收到的信封与正文写入 received
+type fakeSMTP struct {
+	addr     string
+	received chan smtpMail
+}
+
+type smtpMail struct {
+	auth string
+	from string
+	to   []string
+	data []byte
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeSMTP{addr: ln.Addr().String(), received: make(chan smtpMail, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	var m smtpMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"):
+			reply("250-localhost")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(upper, "AUTH PLAIN"):
+			m.auth = strings.TrimSpace(cmd[len("AUTH PLAIN"):])
+			reply("235 2.7.0 Authentication successful")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			m.from = smtpPath(cmd[len("MAIL FROM:"):])
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			m.to = append(m.to, smtpPath(cmd[len("RCPT TO:"):]))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data bytes.Buffer
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, ".")) // 去掉 dot-stuffing
+			}
+			m.data = data.Bytes()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 Bye")
+			s.received <- m
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// smtpPath 取出 MAIL FROM/RCPT TO 参数中尖括号内的地址, 忽略 BODY=8BITMIME 等扩展参数
+func smtpPath(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+func TestSMTPSendReminder(t *testing.T) {
+	server := newFakeSMTP(t)
+	host, port, _ := net.SplitHostPort(server.addr)
+	n := &SMTPNotifier{Host: host, Username: "bot", Password: "pass", From: "Go Schedule <noreply@example.com>", Security: SecurityNone}
+	if _, err := fmt.Sscan(port, &n.Port); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	s := &schedule.Schedule{
+		ID: 42, Version: 3, Priority: schedule.PriorityHigh, StartTime: start, EndTime: start.Add(time.Hour),
+		Content:  "季度评审; 准备材料, 带上电脑\n第二行 " + strings.Repeat("很长的内容", 10),
+		Location: "会议室A", MeetingURL: "https://meet.example.com/abc",
+	}
+	msg, err := ReminderMessage(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := &notify.Channel{Type: notify.ChannelEmail, Target: "Alice <alice@example.com>"}
+	if err = n.Send(ch, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	var m smtpMail
+	select {
+	case m = <-server.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+	if m.from != "noreply@example.com" || len(m.to) != 1 || m.to[0] != "alice@example.com" {
+		t.Errorf("envelope = %s -> %v", m.from, m.to)
+	}
+	if auth, _ := base64.StdEncoding.DecodeString(m.auth); string(auth) != "\x00bot\x00pass" {
+		t.Errorf("auth = %q", auth)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(m.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("subject = %q, want %q (err %v)", subject, msg.Subject, err)
+	}
+	if parsed.Header.Get("Message-ID") == "" || !strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID = %q", parsed.Header.Get("Message-ID"))
+	}
+	for _, line := range strings.Split(string(m.data), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line longer than 998 bytes")
+		}
+	}
+
+	parts := readParts(t, parsed.Header.Get("Content-Type"), parsed.Body)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0].contentType, "multipart/alternative") {
+		t.Fatalf("mixed parts = %+v", parts)
+	}
+	alt := readParts(t, parts[0].contentType, bytes.NewReader(parts[0].body))
+	if len(alt) != 2 || alt[0].contentType != "text/plain; charset=UTF-8" || alt[1].contentType != "text/html; charset=UTF-8" {
+		t.Fatalf("alternative parts = %+v", alt)
+	}
+	if string(alt[0].body) != msg.Text || string(alt[1].body) != msg.HTML {
+		t.Error("body parts do not round trip")
+	}
+
+	ics := parts[1]
+	if !strings.HasPrefix(ics.contentType, "text/calendar") || ics.filename != "schedule-42.ics" {
+		t.Errorf("attachment = %s %s", ics.contentType, ics.filename)
+	}
+	checkICS(t, string(ics.body))
+}
+
+// checkICS 校验 .ics 的结构、转义与折行
+func checkICS(t *testing.T, ics string) {
+	t.Helper()
+	if !strings.HasSuffix(ics, "\r\n") || strings.Contains(strings.ReplaceAll(ics, "\r\n", ""), "\n") {
+		t.Error("ics lines must end with CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 bytes: %q", line)
+		}
+	}
+	// 展开折行后检查内容
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n", "BEGIN:VEVENT\r\n", "UID:schedule-42@go-schedule\r\n",
+		"DTSTART:20260501T093000Z\r\n", "DTEND:20260501T103000Z\r\n",
+		"SUMMARY:季度评审\\; 准备材料\\, 带上电脑\\n第二行 " + strings.Repeat("很长的内容", 10) + "\r\n",
+		"PRIORITY:1\r\n", "SEQUENCE:3\r\n", "LOCATION:会议室A\r\n", "URL:https://meet.example.com/abc\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Errorf("ics missing %q\n%s", want, ics)
+		}
+	}
+}
+
+type mimePart struct {
+	contentType string
+	filename    string
+	body        []byte
+}
+
+// readParts 读取 multipart 分段并解码 base64 内容
+func readParts(t *testing.T, contentType string, r io.Reader) []mimePart {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := multipart.NewReader(r, params["boundary"])
+	var parts []mimePart
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			for _, line := range strings.Split(strings.TrimSpace(string(body)), "\r\n") {
+				if len(line) > 76 {
+					t.Errorf("base64 line longer than 76 characters")
+				}
+			}
+			if body, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(body), "\r\n", "")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		parts = append(parts, mimePart{contentType: p.Header.Get("Content-Type"), filename: p.FileName(), body: body})
+	}
+}
@@ -0,0 +1,251 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	OpenID Connect 授权码模式客户端:
+	通过 /.well-known/openid-configuration 发现端点, 使用 PKCE(S256) 与 state/nonce 防止授权码被截获与重放,
+	ID Token 使用 JWKS 中的 RSA 公钥校验 RS256 签名以及 iss/aud/exp/nonce
+*/
+
+const (
+	// stateTTL 发起登录到回调之间允许的最长时间
+	stateTTL = 10 * time.Minute
+	// maxPending 同时等待回调的登录请求上限, 防止内存被恶意请求占满
+	maxPending = 10000
+	// discoveryTTL 发现文档缓存时间
+	discoveryTTL = time.Hour
+	// maxBody 读取提供方响应的最大字节数
+	maxBody = 1 << 20
+)
+
+var (
+	ErrInvalidState = errors.New("登录请求已过期或无效, 请重新登录")
+	ErrInvalidToken = errors.New("ID Token 校验失败")
+)
+
+// Discovery OpenID 提供方元数据
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// pending 已发起但尚未回调的登录请求
+type pending struct {
+	nonce    string
+	verifier string
+	expireAt time.Time
+}
+
+// Provider OpenID Connect 提供方
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	client *http.Client
+
+	mu           sync.Mutex
+	discovery    *Discovery
+	discoveredAt time.Time
+	keys         map[string]*rsa.PublicKey
+	keysFetched  time.Time
+	pending      map[string]*pending
+}
+
+// New 创建 OpenID Connect 提供方, scopes 中缺少 openid 时自动补充
+func New(issuer, clientID, clientSecret, redirectURL string, scopes []string) *Provider {
+	hasOpenID := false
+	for _, s := range scopes {
+		hasOpenID = hasOpenID || s == "openid"
+	}
+	if !hasOpenID {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	return &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		pending:      make(map[string]*pending),
+	}
+}
+
+// Discover 获取并缓存提供方元数据, 元数据中的 issuer 必须与配置一致
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	if p.discovery != nil && time.Since(p.discoveredAt) < discoveryTTL {
+		d := p.discovery
+		p.mu.Unlock()
+		return d, nil
+	}
+	p.mu.Unlock()
+
+	d := &Discovery{}
+	if err := p.getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", d); err != nil {
+		return nil, fmt.Errorf("获取 OpenID 配置失败: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("OpenID 配置中的 issuer %q 与配置 %q 不一致", d.Issuer, p.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("OpenID 配置缺少 authorization_endpoint、token_endpoint 或 jwks_uri")
+	}
+
+	p.mu.Lock()
+	p.discovery, p.discoveredAt = d, time.Now()
+	p.mu.Unlock()
+	return d, nil
+}
+
+// AuthCodeURL 生成授权地址并返回 state, 同时记录 state、nonce 与 PKCE code_verifier;
+// 调用方需要把 state 绑定到发起登录的浏览器(如 Cookie), 回调时校验, 防止登录 CSRF
+func (p *Provider) AuthCodeURL(ctx context.Context) (string, string, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	state, nonce, verifier := randomString(32), randomString(32), randomString(32)
+
+	p.mu.Lock()
+	now := time.Now()
+	for k, v := range p.pending {
+		if now.After(v.expireAt) {
+			delete(p.pending, k)
+		}
+	}
+	if len(p.pending) >= maxPending {
+		p.mu.Unlock()
+		return "", "", errors.New("登录请求过多, 请稍后重试")
+	}
+	p.pending[state] = &pending{nonce: nonce, verifier: verifier, expireAt: now.Add(stateTTL)}
+	p.mu.Unlock()
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", strings.Join(p.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), state, nil
+}
+
+// StateTTL 发起登录到回调之间允许的最长时间
+func StateTTL() time.Duration {
+	return stateTTL
+}
+
+// Exchange 校验 state 后使用授权码换取令牌, 返回校验通过的 ID Token 声明
+func (p *Provider) Exchange(ctx context.Context, state, code string) (*Claims, error) {
+	p.mu.Lock()
+	pd, ok := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !ok || time.Now().After(pd.expireAt) {
+		return nil, ErrInvalidState
+	}
+
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("code_verifier", pd.verifier)
+	if p.ClientSecret == "" {
+		form.Set("client_id", p.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("换取令牌失败: %w", err)
+	}
+	defer resp.Body.Close()
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxBody)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("换取令牌失败: HTTP %d", resp.StatusCode)
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("换取令牌失败: %s %s", token.Error, token.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		return nil, fmt.Errorf("换取令牌失败: HTTP %d, 响应中没有 id_token", resp.StatusCode)
+	}
+	return p.Verify(ctx, token.IDToken, pd.nonce, time.Now())
+}
+
+// getJSON 请求 JSON 资源
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxBody)).Decode(v)
+}
+
+// CodeChallenge 计算 PKCE S256 code_challenge
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString 生成 n 字节随机数的 base64url 编码, 可用作 state/nonce/code_verifier
+func randomString(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,242 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testClientID = "go-schedule"
+
+// fakeProvider 测试用的 OpenID 提供方, 提供发现文档、JWKS 与令牌端点
+type fakeProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+	kid string
+
+	mu        sync.Mutex
+	challenge string         // 授权请求中的 code_challenge
+	idToken   map[string]any // 令牌端点返回的 ID Token 声明
+	issuer    string         // 发现文档中的 issuer, 为空时使用服务地址
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{key: key, kid: "k1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		issuer := f.issuer
+		f.mu.Unlock()
+		if issuer == "" {
+			issuer = f.URL
+		}
+		_ = json.NewEncoder(w).Encode(Discovery{
+			Issuer:                issuer,
+			AuthorizationEndpoint: f.URL + "/authorize",
+			TokenEndpoint:         f.URL + "/token",
+			JWKSURI:               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{{
+			Kty: "RSA", Kid: f.kid, Use: "sig", Alg: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		challenge, claims := f.challenge, f.idToken
+		f.mu.Unlock()
+		if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != "the-code" ||
+			CodeChallenge(r.PostFormValue("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": f.sign(t, f.key, f.kid, claims)})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// set 修改提供方的行为
+func (f *fakeProvider) set(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn()
+}
+
+// sign 使用 RS256 签名 ID Token
+func (f *fakeProvider) sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// claims 返回一组有效的 ID Token 声明
+func (f *fakeProvider) claims(nonce string, now time.Time) map[string]any {
+	return map[string]any{
+		"iss": f.URL, "sub": "user-1", "aud": testClientID, "nonce": nonce,
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+		"email": "alice@example.com", "email_verified": true, "preferred_username": "alice",
+	}
+}
+
+func (f *fakeProvider) provider() *Provider {
+	return New(f.URL+"/", testClientID, "secret", "http://localhost/callback", []string{"profile"})
+}
+
+func TestDiscover(t *testing.T) {
+	f := newFakeProvider(t)
+	d, err := f.provider().Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.JWKSURI != f.URL+"/jwks" {
+		t.Errorf("jwks_uri = %s", d.JWKSURI)
+	}
+
+	f.set(func() { f.issuer = "https://evil.example.com" })
+	if _, err = f.provider().Discover(context.Background()); err == nil {
+		t.Error("discovery with a different issuer accepted")
+	}
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	f := newFakeProvider(t)
+	p := f.provider()
+	authURL, state, err := p.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if !strings.HasPrefix(authURL, f.URL+"/authorize?") || q.Get("state") != state || q.Get("client_id") != testClientID ||
+		q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid profile" {
+		t.Fatalf("auth url = %s", authURL)
+	}
+	f.set(func() {
+		f.challenge = q.Get("code_challenge")
+		f.idToken = f.claims(q.Get("nonce"), time.Now())
+	})
+
+	claims, err := p.Exchange(context.Background(), state, "the-code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "alice@example.com" || claims.String("preferred_username") != "alice" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// state 只能使用一次
+	if _, err = p.Exchange(context.Background(), state, "the-code"); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("reused state: err = %v, want ErrInvalidState", err)
+	}
+	if _, err = p.Exchange(context.Background(), "unknown", "the-code"); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("unknown state: err = %v, want ErrInvalidState", err)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	f := newFakeProvider(t)
+	p := f.provider()
+	_, state, err := p.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 令牌端点收到的 code_verifier 与授权请求中的 code_challenge 不匹配
+	f.set(func() { f.challenge = CodeChallenge("another verifier") })
+	if _, err = p.Exchange(context.Background(), state, "the-code"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("err = %v, want invalid_grant", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f := newFakeProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	with := func(name string, value any) map[string]any {
+		c := f.claims("n1", now)
+		c[name] = value
+		return c
+	}
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		payload, _ := json.Marshal(with("sub", "admin"))
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	}
+	unsigned := func(alg string) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": f.kid})
+		payload, _ := json.Marshal(f.claims("n1", now))
+		return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", f.sign(t, f.key, f.kid, f.claims("n1", now)), true},
+		{"multiple audiences with azp", f.sign(t, f.key, f.kid, func() map[string]any {
+			c := with("aud", []string{testClientID, "other"})
+			c["azp"] = testClientID
+			return c
+		}()), true},
+		{"bad signature", f.sign(t, otherKey, f.kid, f.claims("n1", now)), false},
+		{"tampered payload", tamper(f.sign(t, f.key, f.kid, f.claims("n1", now))), false},
+		{"alg none", unsigned("none"), false},
+		{"alg HS256", unsigned("HS256"), false},
+		{"unknown kid", f.sign(t, f.key, "k2", f.claims("n1", now)), false},
+		{"wrong nonce", f.sign(t, f.key, f.kid, with("nonce", "n2")), false},
+		{"wrong aud", f.sign(t, f.key, f.kid, with("aud", "other-client")), false},
+		{"multiple audiences without azp", f.sign(t, f.key, f.kid, with("aud", []string{testClientID, "other"})), false},
+		{"wrong iss", f.sign(t, f.key, f.kid, with("iss", "https://evil.example.com")), false},
+		{"expired", f.sign(t, f.key, f.kid, with("exp", now.Add(-2*time.Minute).Unix())), false},
+		{"issued in the future", f.sign(t, f.key, f.kid, with("iat", now.Add(10*time.Minute).Unix())), false},
+		{"missing sub", f.sign(t, f.key, f.kid, with("sub", "")), false},
+		{"malformed", "not-a-jwt", false},
+	}
+	p := f.provider()
+	for _, tc := range cases {
+		_, err := p.Verify(context.Background(), tc.token, "n1", now)
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", tc.name, err)
+		}
+	}
+}
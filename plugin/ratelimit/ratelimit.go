@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	令牌桶限流: 每个 key(IP、用户、路由)一个桶, 桶容量为突发请求数, 按固定速率补充令牌, 每个请求消耗一个令牌;
+	桶只保存在内存中, 长时间未使用、已经补满的桶会被定期清理
+*/
+
+// sweepInterval 清理空闲桶的间隔
+const sweepInterval = time.Minute
+
+// Limit 令牌桶参数
+type Limit struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 桶容量, 即允许的突发请求数
+}
+
+// Parse 解析限流配置, 格式为 次数/时间单位:突发容量, 时间单位为 s、m、h, 如 600/m:100;
+// 省略突发容量时与次数相同; 为空、0 或 off 时返回 nil 表示不限流
+func Parse(spec string) (*Limit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" || strings.EqualFold(spec, "off") {
+		return nil, nil
+	}
+	rateSpec, burstSpec, hasBurst := strings.Cut(spec, ":")
+	countSpec, unit, ok := strings.Cut(rateSpec, "/")
+	if !ok {
+		return nil, fmt.Errorf("限流配置 %q 格式错误, 应为 次数/时间单位:突发容量, 如 600/m:100", spec)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countSpec))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("限流配置 %q 的次数必须为正整数", spec)
+	}
+	var period time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return nil, fmt.Errorf("限流配置 %q 的时间单位必须为 s、m 或 h", spec)
+	}
+	burst := count
+	if hasBurst {
+		if burst, err = strconv.Atoi(strings.TrimSpace(burstSpec)); err != nil || burst <= 0 {
+			return nil, fmt.Errorf("限流配置 %q 的突发容量必须为正整数", spec)
+		}
+	}
+	return &Limit{Rate: float64(count) / period.Seconds(), Burst: burst}, nil
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter 按 key 区分的令牌桶限流器, 可以并发使用
+type Limiter struct {
+	limit Limit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// New 创建限流器
+func New(limit Limit) *Limiter {
+	return &Limiter{limit: limit, buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Allow 为 key 消耗一个令牌; 令牌不足时返回 false 以及需要等待的时间
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	burst := float64(l.limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*l.limit.Rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// RetryAfter 返回 Retry-After 响应头的秒数: 向上取整, 至少为 1
+func RetryAfter(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// sweep 删除已经补满的桶, 这些桶与新建的桶等价
+func (l *Limiter) sweep(now time.Time) {
+	full := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestAllowBurstThenDeny(t *testing.T) {
+	l := New(Limit{Rate: 2, Burst: 3})
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a", base); !ok {
+			t.Fatalf("第 %d 个突发请求被拒绝", i+1)
+		}
+	}
+	ok, wait := l.Allow("a", base)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("桶空后 Allow = %v, %v, want false, 500ms", ok, wait)
+	}
+	if ok, _ = l.Allow("b", base); !ok {
+		t.Error("不同 key 应使用各自的桶")
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l := New(Limit{Rate: 2, Burst: 2})
+	l.Allow("a", base)
+	l.Allow("a", base)
+
+	// 补充速率为每秒 2 个, 1/Rate 之前令牌不足一个
+	if ok, wait := l.Allow("a", base.Add(400*time.Millisecond)); ok || (wait-100*time.Millisecond).Abs() > time.Microsecond {
+		t.Fatalf("400ms 后 Allow = %v, %v, want false, 约 100ms", ok, wait)
+	}
+	if ok, _ := l.Allow("a", base.Add(500*time.Millisecond)); !ok {
+		t.Fatal("经过 1/Rate 后应补充一个令牌")
+	}
+	if ok, _ := l.Allow("a", base.Add(500*time.Millisecond)); ok {
+		t.Fatal("补充的一个令牌已用完")
+	}
+
+	// 长时间空闲后最多补满到桶容量
+	later := base.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a", later); !ok {
+			t.Fatalf("补满后第 %d 个请求被拒绝", i+1)
+		}
+	}
+	if ok, _ := l.Allow("a", later); ok {
+		t.Fatal("令牌数不应超过桶容量")
+	}
+
+	// 时钟回拨时不补充令牌
+	if ok, _ := l.Allow("a", base); ok {
+		t.Fatal("时间倒退时不应补充令牌")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for wait, want := range map[time.Duration]int{
+		0:                       1,
+		time.Millisecond:        1,
+		time.Second:             1,
+		time.Second + 1:         2,
+		1500 * time.Millisecond: 2,
+		time.Minute:             60,
+	} {
+		if got := RetryAfter(wait); got != want {
+			t.Errorf("RetryAfter(%v) = %d, want %d", wait, got, want)
+		}
+	}
+
+	// 600/h: 令牌用尽后需要等待 6 秒
+	l := New(Limit{Rate: 600 / time.Hour.Seconds(), Burst: 1})
+	l.Allow("a", base)
+	if _, wait := l.Allow("a", base.Add(time.Second)); RetryAfter(wait) != 5 {
+		t.Errorf("1 秒后 Retry-After = %d (%v), want 5", RetryAfter(wait), wait)
+	}
+}
+
+func TestSweepDeletesOnlyFullBuckets(t *testing.T) {
+	l := New(Limit{Rate: 1, Burst: 120}) // 空桶补满需要 120 秒
+	l.lastSweep = base
+	l.Allow("full", base)
+	l.Allow("partial", base.Add(30*time.Second))
+
+	// 未到清理间隔时不清理
+	l.Allow("other", base.Add(sweepInterval-time.Second))
+	if len(l.buckets) != 3 {
+		t.Fatalf("未到清理间隔时桶数量 = %d, want 3", len(l.buckets))
+	}
+
+	// full 上次使用已过 121 秒, 已经补满; partial 与 other 尚未补满
+	l.Allow("new", base.Add(121*time.Second))
+	if _, ok := l.buckets["full"]; ok {
+		t.Error("已补满的桶应被清理")
+	}
+	for _, k := range []string{"partial", "other", "new"} {
+		if _, ok := l.buckets[k]; !ok {
+			t.Errorf("未补满的桶 %s 不应被清理", k)
+		}
+	}
+	if !l.lastSweep.Equal(base.Add(121 * time.Second)) {
+		t.Errorf("lastSweep = %v", l.lastSweep)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for spec, want := range map[string]*Limit{
+		"":           nil,
+		"0":          nil,
+		"OFF":        nil,
+		"600/m:100":  {Rate: 10, Burst: 100},
+		" 5 / s ":    {Rate: 5, Burst: 5},
+		"3600/h:1":   {Rate: 1, Burst: 1},
+		"120/m: 20 ": {Rate: 2, Burst: 20},
+	} {
+		got, err := Parse(spec)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", spec, err)
+			continue
+		}
+		if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", spec, got, want)
+		}
+	}
+
+	for _, spec := range []string{
+		"600",      // 缺少时间单位
+		"600/d",    // 不支持的时间单位
+		"600/min",  // 不支持的时间单位
+		"600/",     // 时间单位为空
+		"x/m",      // 次数不是整数
+		"0/m",      // 次数为 0
+		"-1/m",     // 次数为负数
+		"1.5/s",    // 次数不是整数
+		"600/m:",   // 突发容量为空
+		"600/m:0",  // 突发容量为 0
+		"600/m:-5", // 突发容量为负数
+		"600/m:x",  // 突发容量不是整数
+	} {
+		if got, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) = %+v, want error", spec, got)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package rollover
+
+import (
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/plugin/cron"
+	"go-film-demo/plugin/digest"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	逾期任务顺延: 每天定时将已结束但未完成的任务(kind=task)移动到下一个工作日,
+	保持原有的开始时刻与持续时长, 并累加 rollover_count; 同一用户的顺延记录为一次操作, 可以撤销, 且不会作废用户已撤销的操作(仍可重做).
+	存在前置或后置依赖的任务不自动顺延(顺延会破坏依赖顺序), 只记录日志, 需要用户手动调整
+*/
+
+var (
+	ScheduleDao = dao.NewScheduleDao()
+
+	running sync.Mutex // 防止上一轮未结束时重复执行
+)
+
+// Setup 注册每日顺延任务
+func Setup(cm *cron.CronManager) error {
+	hour, minute, err := digest.ParseClock(config.RolloverTime)
+	if err != nil {
+		return err
+	}
+	if _, err = ParseWorkingDays(config.WorkingDays); err != nil {
+		return err
+	}
+	return cm.AddDailyTask("schedule-rollover-daily", hour, minute, func() {
+		n, skipped, err := Run(time.Now())
+		if err != nil {
+			log.Printf("顺延逾期任务失败: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("已顺延 %d 个逾期任务", n)
+		}
+		if len(skipped) > 0 {
+			log.Printf("%d 个逾期任务存在依赖关系, 未自动顺延: %v", len(skipped), skipped)
+		}
+	})
+}
+
+// ParseWorkingDays 解析工作日配置, 如 "1,2,3,4,5", 0 或 7 表示周日
+func ParseWorkingDays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 0 || n > 7 {
+			return nil, fmt.Errorf("工作日配置错误: '%s'", value)
+		}
+		days[time.Weekday(n%7)] = true
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("工作日配置不能为空")
+	}
+	return days, nil
+}
+
+// NextWorkingDay 返回不早于 from 当天的第一个工作日零点
+func NextWorkingDay(from time.Time, workingDays map[time.Weekday]bool) time.Time {
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for i := 0; i < 7 && !workingDays[day.Weekday()]; i++ {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// Move 将任务移动到 day 当天, 保持开始时刻与持续时长
+func Move(s *schedule.Schedule, day time.Time) {
+	duration := s.EndTime.Sub(s.StartTime)
+	start := s.StartTime.In(day.Location())
+	s.StartTime = time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, day.Location())
+	s.EndTime = s.StartTime.Add(duration)
+	s.SyncDate()
+}
+
+// Run 顺延在 now 之前结束且未完成的任务, 返回顺延的数量以及因存在依赖关系而跳过的任务ID
+func Run(now time.Time) (int, []int64, error) {
+	if !running.TryLock() {
+		return 0, nil, nil
+	}
+	defer running.Unlock()
+
+	workingDays, err := ParseWorkingDays(config.WorkingDays)
+	if err != nil {
+		return 0, nil, err
+	}
+	tasks, err := ScheduleDao.ListOverdueTasks(now)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	byUser := make(map[int64][]schedule.Schedule)
+	var users []int64
+	for _, task := range tasks {
+		if _, ok := byUser[task.UserID]; !ok {
+			users = append(users, task.UserID)
+		}
+		byUser[task.UserID] = append(byUser[task.UserID], task)
+	}
+
+	day := NextWorkingDay(now, workingDays)
+	moved := 0
+	var skipped []int64
+	for _, userID := range users {
+		list := byUser[userID]
+		var userMoved int
+		var userSkipped []int64
+		err := ScheduleDao.SystemOperationTransaction(userID, schedule.OpRollover, func(tx *dao.ScheduleDao) error {
+			userMoved, userSkipped = 0, nil
+			ids := make([]int64, 0, len(list))
+			for _, task := range list {
+				ids = append(ids, task.ID)
+			}
+			dependent, err := tx.DependentScheduleIDs(ids)
+			if err != nil {
+				return err
+			}
+			for i := range list {
+				if dependent[list[i].ID] {
+					userSkipped = append(userSkipped, list[i].ID)
+					continue
+				}
+				before := list[i]
+				after := list[i]
+				Move(&after, day)
+				// 移动后仍已结束(如跨越多天的任务)时顺延到下一个工作日
+				for !after.EndTime.After(now) {
+					Move(&after, NextWorkingDay(after.StartTime.AddDate(0, 0, 1), workingDays))
+				}
+				if after.Status == schedule.StatusEnded {
+					after.Status = schedule.StatusNotStarted
+				}
+				after.RolloverCount++
+				after.UpdateAt = now
+				if err := tx.UpdateSchedule(&after); err != nil {
+					return err
+				}
+				if err := tx.RecordHistory(schedule.HistoryActionUpdate, 0, &before, &after); err != nil {
+					return err
+				}
+				userMoved++
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("顺延用户 %d 的逾期任务失败: %v", userID, err)
+			continue
+		}
+		moved += userMoved
+		skipped = append(skipped, userSkipped...)
+	}
+	return moved, skipped, nil
+}
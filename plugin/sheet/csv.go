@@ -0,0 +1,65 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// utf8BOM 写在 CSV 开头, 让 Excel 以 UTF-8 打开中文内容
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// WriteCSV 写入 CSV, 第一行为表头
+func WriteCSV(w io.Writer, header []string, rows [][]string) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// ReadCSV 逐行读取 CSV, 忽略开头的 BOM, 允许各行列数不同; maxRows 大于0时超过该行数返回 ErrTooManyRows
+func ReadCSV(data []byte, maxRows int) ([][]string, error) {
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	cr.FieldsPerRecord = -1
+	var rows [][]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if maxRows > 0 && len(rows) >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		rows = append(rows, record)
+	}
+}
+
+// formulaPrefixes 以这些字符开头的单元格会被 Excel 等软件当作公式执行
+const formulaPrefixes = "=+-@\t\r"
+
+// EscapeFormula 在可能被当作公式的文本前加单引号, 防止导出的文件在打开时执行公式(CSV/公式注入)
+func EscapeFormula(v string) string {
+	if v != "" && strings.ContainsRune(formulaPrefixes, rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// UnescapeFormula 去掉 EscapeFormula 添加的单引号, 使导出的文件可以原样导入
+func UnescapeFormula(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
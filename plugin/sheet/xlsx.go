@@ -0,0 +1,362 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	最小化的 XLSX(Office Open XML 电子表格)读写:
+	写入时生成单个工作表, 单元格均为内联字符串; 读取时逐行解析第一个工作表,
+	支持共享字符串、内联字符串、数字与布尔单元格, 不处理公式与样式;
+	读取时限制解压后的大小、列数与单元格总数, 防止构造的文件耗尽内存
+*/
+
+var (
+	// ErrInvalidXLSX 文件不是有效的 XLSX
+	ErrInvalidXLSX = errors.New("无效的 XLSX 文件")
+	// ErrTooLarge 解压后的内容或单元格数量超过上限
+	ErrTooLarge = errors.New("文件内容过大")
+	// ErrTooManyRows 行数超过读取上限
+	ErrTooManyRows = errors.New("行数超过上限")
+)
+
+const (
+	// MaxColumns Excel 支持的最大列数(XFD)
+	MaxColumns = 16384
+	// maxPartBytes 压缩包中单个 XML 文件解压后的大小上限
+	maxPartBytes = 64 << 20
+	// maxCells 读取的单元格总数上限(含为对齐列而补充的空单元格)
+	maxCells = 2_000_000
+)
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+	workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+)
+
+// WriteXLSX 写入只包含一个工作表的 XLSX, 第一行为表头
+func WriteXLSX(w io.Writer, sheetName string, header []string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXML, xmlEscape(sheetName))},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range append([][]string{header}, rows...) {
+		fmt.Fprintf(&sb, `<row r="%d">`, i+1)
+		for j, value := range row {
+			fmt.Fprintf(&sb, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`,
+				columnName(j), i+1, xmlEscape(value))
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	if _, err = io.WriteString(fw, sb.String()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// xmlEscape 转义 XML 文本并去除 XML 不允许的控制字符
+func xmlEscape(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// columnName 列序号(从0开始)转换为列名 A, B, ..., Z, AA, ...
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// columnIndex 解析单元格引用(如 C12)中的列序号, 从0开始; 超过 XFD 时返回 -1
+func columnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A') + 1
+		if index > MaxColumns {
+			return -1
+		}
+	}
+	return index - 1
+}
+
+type xlsxRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxText 文本节点, 富文本由多个 r/t 组成
+type xlsxText struct {
+	T  string `xml:"t"`
+	Rs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Rs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Rs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string    `xml:"r,attr"`
+		Type   string    `xml:"t,attr"`
+		Value  string    `xml:"v"`
+		Inline *xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// ReadXLSX 读取第一个工作表的行, maxRows 大于0时超过该行数返回 ErrTooManyRows
+func ReadXLSX(data []byte, maxRows int) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err = decodeXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, ErrInvalidXLSX
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+	defer rc.Close()
+
+	var rows [][]string
+	cells := 0
+	dec := xml.NewDecoder(&limitReader{r: rc, n: maxPartBytes})
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, xmlError(err, f.Name)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		if maxRows > 0 && len(rows) >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		var r xlsxRow
+		if err = dec.DecodeElement(&r, &start); err != nil {
+			return nil, xmlError(err, f.Name)
+		}
+
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				if col = columnIndex(c.Ref); col < 0 {
+					return nil, fmt.Errorf("%w: 单元格 %s 超过最大列 XFD", ErrInvalidXLSX, c.Ref)
+				}
+			}
+			if col >= MaxColumns {
+				return nil, fmt.Errorf("%w: 列数超过 %d", ErrInvalidXLSX, MaxColumns)
+			}
+			if col >= len(row) {
+				if cells += col + 1 - len(row); cells > maxCells {
+					return nil, ErrTooLarge
+				}
+				row = append(row, make([]string, col+1-len(row))...)
+			}
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, ErrInvalidXLSX
+				}
+				row[col] = shared.Items[idx].String()
+			case "inlineStr":
+				if c.Inline != nil {
+					row[col] = c.Inline.String()
+				}
+			case "b":
+				row[col] = map[string]string{"1": "TRUE", "0": "FALSE"}[c.Value]
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// firstSheetPath 通过 workbook.xml 与关系文件找到第一个工作表的路径
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wf, ok := files["xl/workbook.xml"]
+	rf, relsOK := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOK {
+		return fallback, nil
+	}
+	var wb xlsxWorkbook
+	var rels xlsxRels
+	if err := decodeXML(wf, &wb); err != nil {
+		return "", err
+	}
+	if err := decodeXML(rf, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", ErrInvalidXLSX
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == wb.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return fallback, nil
+}
+
+// decodeXML 解析压缩包中的 XML 文件, 解压后超过 maxPartBytes 时返回 ErrTooLarge
+func decodeXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return ErrInvalidXLSX
+	}
+	defer rc.Close()
+	if err = xml.NewDecoder(&limitReader{r: rc, n: maxPartBytes}).Decode(v); err != nil {
+		return xmlError(err, f.Name)
+	}
+	return nil
+}
+
+func xmlError(err error, name string) error {
+	if errors.Is(err, ErrTooLarge) {
+		return ErrTooLarge
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidXLSX, name)
+}
+
+// limitReader 与 io.LimitReader 相同, 但超过上限时返回 ErrTooLarge 而不是 EOF, 避免截断的内容被当作完整文件
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// excelEpoch Excel 日期序列号的起点(考虑 1900 年闰年错误后的等效起点)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// ParseExcelTime 将 Excel 日期序列号(如 46314.375)转换为 loc 时区的时间, 精确到秒
+func ParseExcelTime(serial string, loc *time.Location) (time.Time, bool) {
+	f, err := strconv.ParseFloat(serial, 64)
+	if err != nil || f <= 0 {
+		return time.Time{}, false
+	}
+	seconds := int64(f*86400 + 0.5)
+	t := excelEpoch.Add(time.Duration(seconds) * time.Second)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), true
+}
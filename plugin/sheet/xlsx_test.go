@@ -0,0 +1,140 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// buildXLSX 生成只包含一个工作表的最小 XLSX, sheetData 为 <sheetData> 内的 XML
+func buildXLSX(t *testing.T, sheetData string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	header := []string{"id", "content"}
+	rows := [][]string{{"1", "会议"}, {"2", "a & <b>"}}
+	if err := WriteXLSX(&buf, "日程", header, rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadXLSX(buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([][]string{header}, rows...)
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if strings.Join(got[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadXLSXRejectsColumnPastXFD(t *testing.T) {
+	for _, ref := range []string{"XFE1", "ZZZZZZ1", "ZZZZZZZZZZZZZZZZ1"} {
+		data := buildXLSX(t, `<row r="1"><c r="`+ref+`" t="inlineStr"><is><t>x</t></is></c></row>`)
+		if _, err := ReadXLSX(data, 0); !errors.Is(err, ErrInvalidXLSX) {
+			t.Errorf("%s: err = %v, want ErrInvalidXLSX", ref, err)
+		}
+	}
+
+	data := buildXLSX(t, `<row r="1"><c r="XFD1" t="inlineStr"><is><t>x</t></is></c></row>`)
+	rows, err := ReadXLSX(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || len(rows[0]) != MaxColumns || rows[0][MaxColumns-1] != "x" {
+		t.Errorf("XFD1 not read into the last column")
+	}
+}
+
+func TestReadXLSXLimits(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 10; i++ {
+		sb.WriteString(`<row><c t="inlineStr"><is><t>x</t></is></c></row>`)
+	}
+	data := buildXLSX(t, sb.String())
+	if _, err := ReadXLSX(data, 5); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("err = %v, want ErrTooManyRows", err)
+	}
+	if rows, err := ReadXLSX(data, 10); err != nil || len(rows) != 10 {
+		t.Errorf("rows = %d, err = %v, want 10 rows", len(rows), err)
+	}
+
+	// 每行只有最后一列有值, 补齐的空单元格超过 maxCells
+	sb.Reset()
+	for i := 0; i < maxCells/MaxColumns+1; i++ {
+		sb.WriteString(`<row><c r="XFD1" t="inlineStr"><is><t>x</t></is></c></row>`)
+	}
+	if _, err := ReadXLSX(buildXLSX(t, sb.String()), 0); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+
+	// 解压后超过 maxPartBytes 的工作表, 压缩后只有很小的体积
+	big := buildXLSX(t, `<row><c t="inlineStr"><is><t>`+strings.Repeat("x", maxPartBytes)+`</t></is></c></row>`)
+	if len(big) > 1<<20 {
+		t.Fatalf("compressed size %d too large for the test", len(big))
+	}
+	if _, err := ReadXLSX(big, 0); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestReadCSVLimit(t *testing.T) {
+	data := []byte("\xEF\xBB\xBFid,content\n1,a\n2,b\n")
+	rows, err := ReadCSV(data, 3)
+	if err != nil || len(rows) != 3 || rows[0][0] != "id" {
+		t.Errorf("rows = %q, err = %v", rows, err)
+	}
+	if _, err = ReadCSV(data, 2); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("err = %v, want ErrTooManyRows", err)
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	for _, v := range []string{"=1+1", "+1", "-1", "@SUM(A1)", "\tx", "\rx"} {
+		escaped := EscapeFormula(v)
+		if escaped != "'"+v {
+			t.Errorf("EscapeFormula(%q) = %q", v, escaped)
+		}
+		if got := UnescapeFormula(escaped); got != v {
+			t.Errorf("UnescapeFormula(%q) = %q, want %q", escaped, got, v)
+		}
+	}
+	for _, v := range []string{"", "会议", "'quoted", "a=b"} {
+		if got := EscapeFormula(v); got != v {
+			t.Errorf("EscapeFormula(%q) = %q, want unchanged", v, got)
+		}
+		if got := UnescapeFormula(v); got != v {
+			t.Errorf("UnescapeFormula(%q) = %q, want unchanged", v, got)
+		}
+	}
+}
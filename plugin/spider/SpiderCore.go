@@ -4,12 +4,43 @@ import (
 	"encoding/json"
 	"go-film-demo/dao"
 	"go-film-demo/model/news"
+	"go-film-demo/model/notify"
+	"go-film-demo/model/webhook"
+	"go-film-demo/plugin/notifier"
+	"go-film-demo/plugin/webhooks"
 	"log"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
 var NewsDao = dao.NewNewsRepository()
 
+// collecting 是否有采集任务正在执行, 手动触发与定时任务共用, 保证同一时间只有一个采集任务
+var collecting atomic.Bool
+
+// TryCollectNews 没有采集任务在执行时执行一次采集, 否则跳过并返回 false
+func TryCollectNews() bool {
+	if !collecting.CompareAndSwap(false, true) {
+		return false
+	}
+	defer collecting.Store(false)
+	CollectNews()
+	return true
+}
+
+// StartCollectNews 在后台启动一次采集, 已有采集任务在执行时返回 false
+func StartCollectNews() bool {
+	if !collecting.CompareAndSwap(false, true) {
+		return false
+	}
+	go func() {
+		defer collecting.Store(false)
+		CollectNews()
+	}()
+	return true
+}
+
 func CollectNews() {
 	r := &RequestInfo{
 		Uri:    "https://i.news.qq.com/web_feed/getHotModuleList",
@@ -58,8 +89,47 @@ func CollectNews() {
 		}
 		newsList = append(newsList, newItem)
 	}
+	newsList, err = freshNews(newsList)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
 	err = NewsDao.CreateBatch(newsList)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	if len(newsList) > 0 {
+		broadcastNews(newsList)
+	}
+}
+
+// freshNews 过滤掉已入库的新闻, 只保留本次新采集到的
+func freshNews(newsList []*news.News) ([]*news.News, error) {
+	ids := make([]string, 0, len(newsList))
+	for _, n := range newsList {
+		ids = append(ids, n.NewsID)
+	}
+	existing, err := NewsDao.ExistingNewsIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	fresh := make([]*news.News, 0, len(newsList))
+	for _, n := range newsList {
+		if !existing[n.NewsID] {
+			existing[n.NewsID] = true
+			fresh = append(fresh, n)
+		}
+	}
+	return fresh, nil
+}
+
+// broadcastNews 向订阅了新闻速递的渠道与 Webhook 推送新采集的新闻
+func broadcastNews(newsList []*news.News) {
+	key := strconv.FormatInt(newsList[len(newsList)-1].ID, 10)
+	if err := notifier.Broadcast(notify.EventNews, key, notifier.NewsMessage(newsList)); err != nil {
+		log.Printf("推送新闻速递失败: %v", err)
+	}
+	if err := webhooks.Emit(0, webhook.EventNewsCreated, map[string]any{"news": newsList}); err != nil {
+		log.Printf("生成新闻 Webhook 事件失败: %v", err)
+	}
 }
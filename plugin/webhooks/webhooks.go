@@ -0,0 +1,259 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-film-demo/config"
+	"go-film-demo/dao"
+	"go-film-demo/model/schedule"
+	"go-film-demo/model/webhook"
+	"go-film-demo/plugin/cron"
+	"go-film-demo/plugin/netguard"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+	Webhook 投递: 事件发生时为每个订阅写入一条投递记录, 由定时任务 POST 到订阅地址,
+	请求体为 JSON, 使用订阅密钥对 "时间戳.请求体" 做 HMAC-SHA256 签名;
+	非 2xx 响应或网络错误按 30s×2^(n-1) 退避重试, 超过最大次数标记为失败;
+	订阅地址只能指向公网, 发送时不跟随重定向, 指向内网的地址直接标记为失败且不保存响应内容
+*/
+
+// 请求头
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	batchSize   = 50
+	baseBackoff = 30 * time.Second
+)
+
+// errSubscriptionGone 订阅已删除或停用, 无需重试
+var errSubscriptionGone = errors.New("订阅已删除或停用")
+
+var (
+	WebhookDao = dao.NewWebhookDao()
+
+	client  = netguard.NewClient(10 * time.Second)
+	running sync.Mutex // 防止上一轮投递未结束时重复执行
+)
+
+// Setup 注册投递定时任务, 并监听日程变更
+func Setup(cm *cron.CronManager) error {
+	if err := cm.AddTask("webhook-delivery-15s", "*/15 * * * * *", ProcessQueue); err != nil {
+		return err
+	}
+	dao.OnScheduleChange(func(changes []schedule.OperationChange) {
+		go scheduleChanged(changes)
+	})
+	return nil
+}
+
+// Emit 向订阅了事件的订阅投递, userID 为 0 时投递给全部订阅者(如新闻事件)
+func Emit(userID int64, event string, data any) error {
+	subs, err := WebhookDao.ListSubscribers(userID, event)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+	envelope := webhook.Envelope{ID: NewEventID(), Event: event, CreatedAt: time.Now(), Data: data}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	list := make([]webhook.Delivery, 0, len(subs))
+	for _, sub := range subs {
+		list = append(list, webhook.Delivery{
+			SubscriptionID: sub.ID,
+			EventID:        envelope.ID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         webhook.DeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if err = WebhookDao.CreateDeliveries(list); err != nil {
+		return err
+	}
+	go ProcessQueue()
+	return nil
+}
+
+// Redeliver 以原请求体重新投递, 生成新的投递记录
+func Redeliver(d *webhook.Delivery) (*webhook.Delivery, error) {
+	redelivery := webhook.Delivery{
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Payload:        d.Payload,
+		Status:         webhook.DeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   d.ID,
+	}
+	list := []webhook.Delivery{redelivery}
+	if err := WebhookDao.CreateDeliveries(list); err != nil {
+		return nil, err
+	}
+	go ProcessQueue()
+	return &list[0], nil
+}
+
+// ProcessQueue 投递全部到期的 Webhook
+func ProcessQueue() {
+	if !running.TryLock() {
+		return
+	}
+	defer running.Unlock()
+
+	list, err := WebhookDao.ListDueDeliveries(time.Now(), batchSize)
+	if err != nil {
+		return
+	}
+	for i := range list {
+		deliver(&list[i])
+	}
+}
+
+// deliver 投递单条记录并保存结果
+func deliver(d *webhook.Delivery) {
+	status, body, err := send(d)
+	now := time.Now()
+	d.Attempts++
+	d.UpdateAt = now
+	d.ResponseStatus = status
+	d.ResponseBody = truncate(body, 1000)
+	switch {
+	case err == nil && status >= 200 && status < 300:
+		d.Status = webhook.DeliverySuccess
+		d.DeliveredAt = &now
+		d.LastError = ""
+	default:
+		if err == nil {
+			err = fmt.Errorf("响应状态码 %d", status)
+		}
+		log.Printf("投递 Webhook %d 失败(第 %d 次): %v", d.ID, d.Attempts, err)
+		d.LastError = truncate(err.Error(), 1000)
+		if d.Attempts >= config.WebhookMaxAttempts || errors.Is(err, errSubscriptionGone) ||
+			errors.Is(err, netguard.ErrBlockedAddress) {
+			d.Status = webhook.DeliveryFailed
+		} else {
+			d.NextAttemptAt = now.Add(baseBackoff << (d.Attempts - 1))
+		}
+	}
+	_ = WebhookDao.UpdateDeliveryResult(d)
+}
+
+// send 签名并发送请求, 返回响应状态码与响应内容
+func send(d *webhook.Delivery) (int, string, error) {
+	sub, err := WebhookDao.GetSubscription(d.SubscriptionID)
+	if err != nil {
+		return 0, "", err
+	}
+	if sub == nil || !sub.Enabled {
+		return 0, "", errSubscriptionGone
+	}
+
+	if err = netguard.CheckURL(context.Background(), sub.URL); err != nil {
+		return 0, "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Go-Schedule-Webhook/1.0")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(sub.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	return resp.StatusCode, string(body), nil
+}
+
+// Sign 计算签名: HMAC-SHA256(secret, timestamp + "." + body) 的十六进制
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret 生成随机签名密钥
+func NewSecret() string {
+	return "whsec_" + randomHex(24)
+}
+
+// NewEventID 生成事件ID, 同一事件的重新投递使用相同ID, 便于接收方去重
+func NewEventID() string {
+	return "evt_" + randomHex(16)
+}
+
+// randomHex 生成 n 字节随机数的十六进制
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// scheduleEventData 日程事件的数据, Previous 为变更前的日程
+type scheduleEventData struct {
+	Schedule *schedule.Schedule `json:"schedule"`
+	Previous *schedule.Schedule `json:"previous,omitempty"`
+}
+
+// scheduleChanged 将已提交的日程变更转换为 Webhook 事件
+func scheduleChanged(changes []schedule.OperationChange) {
+	for _, change := range changes {
+		var event string
+		var userID int64
+		data := scheduleEventData{Schedule: change.After, Previous: change.Before}
+		switch {
+		case change.Before == nil:
+			event, userID = webhook.EventScheduleCreated, change.After.UserID
+		case change.After == nil:
+			event, userID = webhook.EventScheduleDeleted, change.Before.UserID
+			data = scheduleEventData{Schedule: change.Before}
+		case change.After.Status == schedule.StatusCompleted && change.Before.Status != schedule.StatusCompleted:
+			event, userID = webhook.EventScheduleCompleted, change.After.UserID
+		default:
+			event, userID = webhook.EventScheduleUpdated, change.After.UserID
+		}
+		if err := Emit(userID, event, data); err != nil {
+			log.Printf("生成日程 %d 的 Webhook 事件失败: %v", change.ScheduleID, err)
+		}
+	}
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
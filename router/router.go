@@ -1,8 +1,12 @@
 package router
 
 import (
+	"go-film-demo/config"
 	"go-film-demo/controller"
+	"go-film-demo/model/user"
 	"go-film-demo/plugin/middleware"
+	"log"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,20 +14,159 @@ import (
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
 
+	// 未配置可信代理时忽略 X-Forwarded-For, 避免伪造请求头绕过按 IP 限流
+	var proxies []string
+	for _, p := range strings.Split(config.TrustedProxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("TRUSTED_PROXIES 配置错误: %v", err)
+	}
+
 	r.Use(middleware.Cors())
+	r.Use(middleware.RateLimit(config.RateLimitIP, middleware.ByIP))
+
+	// 个人访问令牌需要对应的权限范围, 登录会话不受限制
+	read := middleware.Scope(user.ScopeScheduleRead)
+	write := middleware.Scope(user.ScopeScheduleWrite)
+	newsRead := middleware.Scope(user.ScopeNewsRead)
+	session := middleware.SessionOnly()
+
+	// 登录、注册等接口按 IP 限流, 校验密码或验证码的接口按用户限流, 防止暴力破解; 写接口按用户限流
+	authLimit := middleware.RouteRateLimit(config.RateLimitAuth, middleware.ByIP)
+	codeLimit := middleware.RouteRateLimit(config.RateLimitAuth, middleware.ByUser)
+	writeLimit := middleware.RouteRateLimit(config.RateLimitWrite, middleware.ByUser)
+
+	authGroup := r.Group("/auth")
+	{
+		authGroup.POST("/register", authLimit, controller.Register)
+		authGroup.POST("/login", authLimit, controller.Login)
+		authGroup.POST("/refresh", controller.RefreshToken)
+		authGroup.POST("/logout", controller.Logout)
+		authGroup.GET("/oidc/login", authLimit, controller.OIDCLogin)
+		authGroup.GET("/oidc/callback", controller.OIDCCallback)
+		authGroup.GET("/me", middleware.AuthAllowEnroll(), controller.Me)
+		authGroup.POST("/password", middleware.Auth(), session, codeLimit, controller.ChangePassword)
+	}
+
+	// 角色要求两步验证而尚未启用的会话只能查询状态与完成绑定
+	mfa := r.Group("/auth/2fa")
+	{
+		mfa.POST("/verify", authLimit, controller.VerifyMFA)
+		mfa.GET("/status", middleware.AuthAllowEnroll(), session, controller.TOTPStatus)
+		mfa.POST("/setup", middleware.AuthAllowEnroll(), session, controller.SetupTOTP)
+		mfa.POST("/enable", middleware.AuthAllowEnroll(), session, codeLimit, controller.EnableTOTP)
+		mfa.POST("/disable", middleware.Auth(), session, codeLimit, controller.DisableTOTP)
+		mfa.POST("/recovery-codes", middleware.Auth(), session, codeLimit, controller.RegenerateRecoveryCodes)
+	}
+
+	token := r.Group("/auth/token", middleware.Auth(), session)
+	{
+		token.POST("/store", controller.CreateAPIToken)
+		token.POST("/list", controller.ListAPITokens)
+		token.POST("/revoke", controller.RevokeAPIToken)
+	}
+
+	schedule := r.Group("/schedule", middleware.Auth())
+	{
+		schedule.GET("/:id", read, controller.Detail)
+		schedule.PATCH("/:id", write, writeLimit, controller.Patch)
+		schedule.GET("/:id/notes", read, controller.Notes)
+		schedule.POST("/query", read, controller.Query)
+		schedule.POST("/list", read, controller.List)
+		schedule.POST("/store", write, writeLimit, controller.Store)
+		schedule.POST("/update", write, writeLimit, controller.Update)
+		schedule.POST("/queryMonth", read, controller.Query)
+		schedule.POST("/delete", write, writeLimit, controller.Delete)
+		schedule.POST("/history", read, controller.History)
+		schedule.POST("/revert", write, writeLimit, controller.Revert)
+		schedule.POST("/bulk", write, writeLimit, controller.Bulk)
+		schedule.POST("/undo", write, writeLimit, controller.Undo)
+		schedule.POST("/redo", write, writeLimit, controller.Redo)
+		schedule.POST("/export", read, controller.Export)
+		schedule.POST("/import", write, writeLimit, controller.Import)
+		schedule.POST("/conflicts", read, controller.Conflicts)
+		schedule.POST("/todo", read, controller.Todo)
+		schedule.POST("/rollover/chronic", read, controller.Chronic)
+	}
+
+	dependency := r.Group("/schedule/dependency", middleware.Auth())
+	{
+		dependency.POST("/add", write, writeLimit, controller.AddDependency)
+		dependency.POST("/remove", write, writeLimit, controller.RemoveDependency)
+		dependency.POST("/list", read, controller.Dependencies)
+	}
+
+	countdown := r.Group("/countdown", middleware.Auth())
+	{
+		countdown.POST("/store", write, writeLimit, controller.StoreCountdown)
+		countdown.POST("/update", write, writeLimit, controller.UpdateCountdown)
+		countdown.POST("/delete", write, writeLimit, controller.DeleteCountdown)
+		countdown.POST("/upcoming", read, controller.Upcoming)
+	}
+
+	news := r.Group("/news", middleware.Auth())
+	{
+		news.GET("/start", session, middleware.Permission(user.PermSpiderManage), writeLimit, controller.Start)
+		news.POST("/query", newsRead, controller.QueryNews)
+		news.POST("/list", newsRead, controller.ListNews)
+	}
+
+	briefing := r.Group("/briefing", middleware.Auth())
+	{
+		briefing.GET("", read, controller.Briefing)
+		briefing.POST("/setting", write, writeLimit, controller.SaveBriefingSetting)
+	}
+
+	notify := r.Group("/notify", middleware.Auth())
+	{
+		notify.POST("/channel/store", write, writeLimit, controller.SaveChannel)
+		notify.POST("/channel/delete", write, writeLimit, controller.DeleteChannel)
+		notify.POST("/channel/list", read, controller.ListChannels)
+		notify.POST("/channel/test", write, writeLimit, controller.TestChannel)
+		notify.POST("/deliveries", read, controller.Deliveries)
+	}
+
+	webhook := r.Group("/webhook", middleware.Auth())
+	{
+		webhook.POST("/store", write, writeLimit, controller.StoreWebhook)
+		webhook.POST("/delete", write, writeLimit, controller.DeleteWebhook)
+		webhook.POST("/list", read, controller.ListWebhooks)
+		webhook.POST("/deliveries", read, controller.WebhookDeliveries)
+		webhook.POST("/redeliver", write, writeLimit, controller.RedeliverWebhook)
+	}
+
+	shareLink := r.Group("/share")
+	{
+		shareLink.GET("/:token", controller.PublicShare)
+		shareLink.POST("/store", middleware.Auth(), write, writeLimit, controller.CreateShare)
+		shareLink.POST("/revoke", middleware.Auth(), write, writeLimit, controller.RevokeShare)
+		shareLink.POST("/list", middleware.Auth(), read, controller.ListShares)
+	}
+
+	r.GET("/stream", middleware.StreamAuth(), read, controller.Stream)
+	r.POST("/stream/ticket", middleware.Auth(), read, controller.StreamTicket)
 
-	schedule := r.Group("/schedule")
+	teamGroup := r.Group("/team", middleware.Auth())
 	{
-		schedule.POST("/query", controller.Query)
-		schedule.POST("/store", controller.Store)
-		schedule.POST("/update", controller.Update)
-		schedule.POST("/queryMonth", controller.Query)
+		teamGroup.POST("/store", session, middleware.Permission(user.PermTeamManage), controller.StoreTeam)
+		teamGroup.POST("/delete", session, middleware.Permission(user.PermTeamManage), controller.DeleteTeam)
+		teamGroup.POST("/list", read, controller.ListTeams)
+		teamGroup.POST("/member/list", read, controller.TeamMembers)
+		teamGroup.POST("/member/add", session, middleware.Permission(user.PermTeamManage), controller.AddTeamMember)
+		teamGroup.POST("/member/remove", session, controller.RemoveTeamMember)
+		teamGroup.POST("/schedules", read, controller.TeamSchedules)
 	}
 
-	news := r.Group("/news")
+	adminGroup := r.Group("/admin", middleware.Auth(), session)
 	{
-		news.GET("/start", controller.Start)
-		news.POST("/query", controller.QueryNews)
+		adminGroup.POST("/user/list", middleware.Permission(user.PermUserManage), controller.ListUsers)
+		adminGroup.POST("/user/role", middleware.Permission(user.PermUserManage), controller.SetUserRole)
+		adminGroup.POST("/user/2fa/reset", middleware.Permission(user.PermUserManage), controller.ResetUserTOTP)
+		adminGroup.GET("/cron/list", middleware.Permission(user.PermCronManage), controller.CronTasks)
+		adminGroup.POST("/cron/run", middleware.Permission(user.PermCronManage), controller.RunCronTask)
 	}
 
 	r.Group("/agent")